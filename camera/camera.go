@@ -26,6 +26,7 @@ type Camera struct {
 	target     math32.Vector3 // camera target in world coordinates
 	up         math32.Vector3 // camera Up vector
 	viewMatrix math32.Matrix4 // last calculated view matrix
+	layerMask  uint32         // layers rendered by this camera
 }
 
 // Initialize initializes the base camera.
@@ -35,6 +36,7 @@ func (cam *Camera) Initialize() {
 	cam.Node.Init()
 	cam.target.Set(0, 0, 0)
 	cam.up.Set(0, 1, 0)
+	cam.layerMask = core.LayersAll
 	cam.SetDirection(0, 0, -1)
 	cam.updateQuaternion()
 }
@@ -100,6 +102,20 @@ func (cam *Camera) SetPositionVec(vpos *math32.Vector3) {
 	cam.updateQuaternion()
 }
 
+// SetLayerMask sets the bitmask of the layers rendered by this camera
+// (default = core.LayersAll). Only the nodes whose layers overlap the
+// mask are rendered and intersected by the raycasters set by this camera.
+func (cam *Camera) SetLayerMask(mask uint32) {
+
+	cam.layerMask = mask
+}
+
+// LayerMask returns the bitmask of the layers rendered by this camera
+func (cam *Camera) LayerMask() uint32 {
+
+	return cam.layerMask
+}
+
 // ViewMatrix returns the current view matrix of this camera
 func (cam *Camera) ViewMatrix(m *math32.Matrix4) {
 
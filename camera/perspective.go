@@ -124,6 +124,7 @@ func (cam *Perspective) SetRaycaster(rc *core.Raycaster, sx, sy float32) {
 	rc.Set(&origin, &direction)
 	// Updates the view matrix of the raycaster
 	cam.ViewMatrix(&rc.ViewMatrix)
+	rc.Layers = cam.layerMask
 }
 
 // updateProjMatrix updates this camera projection matrix if necessary
@@ -9,6 +9,7 @@ import (
 )
 
 type RenderInfo struct {
-	ViewMatrix math32.Matrix4 // Current camera view matrix
-	ProjMatrix math32.Matrix4 // Current camera projection matrix
+	ViewMatrix  math32.Matrix4 // Current camera view matrix
+	ProjMatrix  math32.Matrix4 // Current camera projection matrix
+	TextureUnit int            // First texture unit not used by the material and the renderer
 }
@@ -18,6 +18,13 @@ type INode interface {
 	Dispose()
 }
 
+// Layers of nodes, used in the layers bitmasks of nodes,
+// cameras and raycasters. Up to 32 layers may be used.
+const (
+	LayerDefault uint32 = 1          // Layer of new nodes
+	LayersAll    uint32 = 0xFFFFFFFF // Mask of all the layers
+)
+
 type Node struct {
 	Dispatcher                    // Embedded event dispatcher
 	loaderID    string            // ID used by loader
@@ -30,6 +37,8 @@ type Node struct {
 	matrix      math32.Matrix4    // Transform matrix relative to this node parent.
 	matrixWorld math32.Matrix4    // Transform world matrix
 	visible     bool              // Visible flag
+	renderOrder int               // Render order override
+	layers      uint32            // Bitmask of the layers of this node
 	parent      INode             // Parent node
 	children    []INode           // Array with node children
 	userData    interface{}       // Generic user data
@@ -57,6 +66,7 @@ func (n *Node) Init() {
 	n.matrixWorld.Identity()
 	n.children = make([]INode, 0)
 	n.visible = true
+	n.layers = LayerDefault
 }
 
 // GetNode satisfies the INode interface and returns
@@ -328,6 +338,53 @@ func (n *Node) Visible() bool {
 	return n.visible
 }
 
+// SetLayers sets the bitmask of the layers this node belongs to
+// (default = LayerDefault). The node is only rendered by cameras and
+// only intersected by raycasters whose layer masks overlap its layers.
+// The layers of its children are not changed.
+func (n *Node) SetLayers(mask uint32) {
+
+	n.layers = mask
+}
+
+// Layers returns the bitmask of the layers this node belongs to
+func (n *Node) Layers() uint32 {
+
+	return n.layers
+}
+
+// SetLayer adds or removes this node from the specified layer (0 to 31)
+func (n *Node) SetLayer(layer int, state bool) {
+
+	if state {
+		n.layers |= 1 << uint(layer)
+	} else {
+		n.layers &^= 1 << uint(layer)
+	}
+}
+
+// InLayers returns if this node belongs to any of the
+// layers of the specified layers bitmask
+func (n *Node) InLayers(mask uint32) bool {
+
+	return n.layers&mask != 0
+}
+
+// SetRenderOrder sets the render order of this node (default = 0).
+// Graphics with lower render orders are rendered first, overriding
+// the depth sorting done by the renderer inside its opaque and
+// transparent render queues.
+func (n *Node) SetRenderOrder(order int) {
+
+	n.renderOrder = order
+}
+
+// RenderOrder returns the current render order of this node
+func (n *Node) RenderOrder() int {
+
+	return n.renderOrder
+}
+
 // WorldPosition updates this node world matrix and gets
 // the current world position vector.
 func (n *Node) WorldPosition(result *math32.Vector3) {
@@ -20,12 +20,17 @@ type Raycaster struct {
 	Far float32
 	// Minimum distance in world coordinates between the ray and
 	// a line segment when checking intersects with lines.
+	// Lines drawn with the Line material use their width instead.
 	// The default value is 0.1
 	LinePrecision float32
 	// Minimum distance in world coordinates between the ray and
 	// a point when checking intersects with points.
 	// The default value is 0.1
 	PointPrecision float32
+	// Bitmask of the layers of the nodes which are checked.
+	// It is set automatically with the camera layer mask when
+	// using camera.SetRaycaster. The default value is LayersAll.
+	Layers uint32
 	// This field must be set with the camera view matrix used
 	// when checking for sprite intersections.
 	// It is set automatically when using camera.SetRaycaster
@@ -49,6 +54,9 @@ type Intersect struct {
 	// index in the positions buffer of the vertex intersected
 	// or the first vertex of the insersected face.
 	Index uint32
+	// If the intersected node draws several instances of its geometry,
+	// this field is the index of the intersected instance.
+	Instance int
 }
 
 // New creates and returns a pointer to a new raycaster object
@@ -61,6 +69,7 @@ func NewRaycaster(origin, direction *math32.Vector3) *Raycaster {
 	rc.Far = math32.Inf(1)
 	rc.LinePrecision = 0.1
 	rc.PointPrecision = 0.1
+	rc.Layers = LayersAll
 	return rc
 }
 
@@ -96,7 +105,10 @@ func (rc *Raycaster) intersectObject(inode INode, intersects *[]Intersect, recur
 	if !node.Visible() {
 		return
 	}
-	inode.Raycast(rc, intersects)
+	// The children of nodes in other layers may still be intersected
+	if node.InLayers(rc.Layers) {
+		inode.Raycast(rc, intersects)
+	}
 	if recursive {
 		for _, child := range node.Children() {
 			rc.intersectObject(child, intersects, true)
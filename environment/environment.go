@@ -0,0 +1,174 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package environment
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
+)
+
+// Background types
+type Background int
+
+const (
+	BackgroundNone     Background = 0 // The background is not changed
+	BackgroundColor    Background = 1 // Solid color
+	BackgroundGradient Background = 2 // Vertical gradient between two colors
+	BackgroundCube     Background = 3 // Cube map texture
+)
+
+// Fog modes
+type Fog int
+
+const (
+	FogNone         Fog = 0 // No fog
+	FogLinear       Fog = 1 // Fog increases linearly between the near and far distances
+	FogExponential  Fog = 2 // Fog increases exponentially with the distance
+	FogExponential2 Fog = 3 // Fog increases exponentially with the squared distance
+)
+
+// Environment is a node which sets the background and the fog of the
+// scene it is added to. The renderer uses the first visible environment
+// found in the scene. The background is drawn before the graphics of the
+// scene in the current viewport and the fog is applied by the shaders of
+// the Standard, Phong, Physical, Point and sprite materials.
+type Environment struct {
+	core.Node                       // Embedded node
+	background Background           // Background type
+	color      math32.Color         // Background color or gradient top color
+	bottom     math32.Color         // Background gradient bottom color
+	cube       *texture.TextureCube // Background cube map texture
+	fog        Fog                  // Fog mode
+	fogColor   math32.Color         // Fog color
+	fogNear    float32              // Linear fog start distance
+	fogFar     float32              // Linear fog end distance
+	fogDensity float32              // Exponential fog density
+}
+
+// NewEnvironment creates and returns a pointer to a new environment
+// without background and fog
+func NewEnvironment() *Environment {
+
+	env := new(Environment)
+	env.Node.Init()
+	env.fogNear = 1
+	env.fogFar = 1000
+	env.fogDensity = 0.01
+	return env
+}
+
+// SetBackgroundColor sets the background to the specified solid color
+func (env *Environment) SetBackgroundColor(color *math32.Color) {
+
+	env.background = BackgroundColor
+	env.color = *color
+}
+
+// SetBackgroundGradient sets the background to a vertical gradient in world
+// coordinates from the specified bottom color, straight below the camera,
+// to the specified top color, straight above it.
+func (env *Environment) SetBackgroundGradient(top, bottom *math32.Color) {
+
+	env.background = BackgroundGradient
+	env.color = *top
+	env.bottom = *bottom
+}
+
+// SetBackgroundCube sets the background to the specified cube map texture
+// sampled in world coordinates, so it rotates with the camera.
+func (env *Environment) SetBackgroundCube(cube *texture.TextureCube) {
+
+	env.background = BackgroundCube
+	env.cube = cube
+}
+
+// SetBackgroundNone removes the background, so the renderer
+// does not change the current framebuffer colors.
+func (env *Environment) SetBackgroundNone() {
+
+	env.background = BackgroundNone
+	env.cube = nil
+}
+
+// Background returns the current background type
+func (env *Environment) Background() Background {
+
+	return env.background
+}
+
+// BackgroundColor returns the solid background color
+// or the top color of the gradient background
+func (env *Environment) BackgroundColor() math32.Color {
+
+	return env.color
+}
+
+// BackgroundGradient returns the top and bottom colors of the gradient background
+func (env *Environment) BackgroundGradient() (math32.Color, math32.Color) {
+
+	return env.color, env.bottom
+}
+
+// BackgroundCube returns the cube map texture of the background or nil
+func (env *Environment) BackgroundCube() *texture.TextureCube {
+
+	return env.cube
+}
+
+// SetLinearFog sets a fog with the specified color which increases linearly
+// from none at the near distance from the camera to full at the far distance.
+func (env *Environment) SetLinearFog(color *math32.Color, near, far float32) {
+
+	env.fog = FogLinear
+	env.fogColor = *color
+	env.fogNear = near
+	env.fogFar = far
+}
+
+// SetExponentialFog sets a fog with the specified color and density
+// which increases exponentially with the distance from the camera.
+// If squared is true the exponent is the square of the distance times
+// the density, which keeps the scene clearer near the camera.
+func (env *Environment) SetExponentialFog(color *math32.Color, density float32, squared bool) {
+
+	if squared {
+		env.fog = FogExponential2
+	} else {
+		env.fog = FogExponential
+	}
+	env.fogColor = *color
+	env.fogDensity = density
+}
+
+// SetFogNone removes the fog
+func (env *Environment) SetFogNone() {
+
+	env.fog = FogNone
+}
+
+// Fog returns the current fog mode
+func (env *Environment) Fog() Fog {
+
+	return env.fog
+}
+
+// FogColor returns the current fog color
+func (env *Environment) FogColor() math32.Color {
+
+	return env.fogColor
+}
+
+// FogRange returns the near and far distances of the linear fog
+func (env *Environment) FogRange() (float32, float32) {
+
+	return env.fogNear, env.fogFar
+}
+
+// FogDensity returns the density of the exponential fog
+func (env *Environment) FogDensity() float32 {
+
+	return env.fogDensity
+}
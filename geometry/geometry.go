@@ -7,6 +7,7 @@ package geometry
 import (
 	"github.com/g3n/engine/gls"
 	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
 )
 
 // Interface for all geometries
@@ -17,18 +18,25 @@ type IGeometry interface {
 }
 
 type Geometry struct {
-	refcount            int             // Current number of references
-	vbos                []*gls.VBO      // Array of VBOs
-	groups              []Group         // Array geometry groups
-	indices             math32.ArrayU32 // Buffer with indices
-	gs                  *gls.GLS        // Pointer to gl context. Valid after first render setup
-	handleVAO           uint32          // Handle to OpenGL VAO
-	handleIndices       uint32          // Handle to OpenGL buffer for indices
-	updateIndices       bool            // Flag to indicate that indices must be transferred
-	boundingBox         math32.Box3     // Last calculated bounding box
-	boundingBoxValid    bool            // Indicates if last calculated bounding box is valid
-	boundingSphere      math32.Sphere   // Last calculated bounding sphere
-	boundingSphereValid bool            // Indicates if last calculated bounding sphere is valid
+	refcount            int                // Current number of references
+	vbos                []*gls.VBO         // Array of VBOs
+	groups              []Group            // Array geometry groups
+	indices             math32.ArrayU32    // Buffer with indices
+	gs                  *gls.GLS           // Pointer to gl context. Valid after first render setup
+	handleVAO           uint32             // Handle to OpenGL VAO
+	handleIndices       uint32             // Handle to OpenGL buffer for indices
+	updateIndices       bool               // Flag to indicate that indices must be transferred
+	boundingBox         math32.Box3        // Last calculated bounding box
+	boundingBoxValid    bool               // Indicates if last calculated bounding box is valid
+	boundingSphere      math32.Sphere      // Last calculated bounding sphere
+	boundingSphereValid bool               // Indicates if last calculated bounding sphere is valid
+	version             uint32             // Incremented when the indices or VBOs are set
+	boundsVBO           *gls.VBO           // Positions VBO of the last calculated bounding volumes
+	boundsVersion       uint32             // Version of the positions VBO of the last calculated bounding volumes
+	morphTargets        []morphTarget      // Morph targets
+	morphCount          int                // Number of vertices of the morph targets
+	morphTex            *texture.Texture2D // Texture with the morph targets offsets
+	updateMorph         bool               // Flag to indicate that the morph texture must be updated
 }
 
 // Geometry group object
@@ -82,6 +90,12 @@ func (g *Geometry) Dispose() {
 		g.gs.DeleteVertexArrays(g.handleVAO)
 		g.gs.DeleteBuffers(g.handleIndices)
 	}
+	if g.morphTex != nil {
+		g.morphTex.Dispose()
+		g.morphTex = nil
+	}
+	g.morphTargets = nil
+	g.morphCount = 0
 	g.Init()
 }
 
@@ -121,6 +135,8 @@ func (g *Geometry) GroupAt(idx int) *Group {
 func (g *Geometry) SetIndices(indices math32.ArrayU32) {
 
 	g.indices = indices
+	g.updateIndices = true
+	g.version++
 	g.boundingBoxValid = false
 	g.boundingSphereValid = false
 }
@@ -135,6 +151,20 @@ func (g *Geometry) Indices() math32.ArrayU32 {
 func (g *Geometry) AddVBO(vbo *gls.VBO) {
 
 	g.vbos = append(g.vbos, vbo)
+	g.version++
+}
+
+// Version returns a counter which changes each time the indices of
+// this geometry are set or a VBO is added or has its buffer set or
+// updated, used to know if data derived from the geometry is outdated.
+func (g *Geometry) Version() uint32 {
+
+	// The counters only increase, so their sum changes with any of them
+	version := g.version
+	for _, vbo := range g.vbos {
+		version += vbo.Version()
+	}
+	return version
 }
 
 // VBO returns a pointer to this geometry VBO for the specified attribute.
@@ -170,22 +200,23 @@ func (g *Geometry) Items() int {
 func (g *Geometry) BoundingBox() math32.Box3 {
 
 	// If valid, returns its value
+	vbPos := g.positionsVBO()
 	if g.boundingBoxValid {
 		return g.boundingBox
 	}
 
 	// Get buffer with position vertices
-	vbPos := g.VBO("VertexPosition")
 	if vbPos == nil {
 		return g.boundingBox
 	}
 	positions := vbPos.Buffer()
+	stride := vbPos.Stride()
 
 	// Calculates bounding box
 	var vertex math32.Vector3
 	g.boundingBox.Min.Set(0, 0, 0)
 	g.boundingBox.Max.Set(0, 0, 0)
-	for i := 0; i < positions.Size(); i += 3 {
+	for i := vbPos.AttribOffset("VertexPosition"); i < positions.Size(); i += stride {
 		positions.GetVector3(i, &vertex)
 		g.boundingBox.ExpandByPoint(&vertex)
 	}
@@ -198,16 +229,17 @@ func (g *Geometry) BoundingBox() math32.Box3 {
 func (g *Geometry) BoundingSphere() math32.Sphere {
 
 	// if valid, returns its value
+	vbPos := g.positionsVBO()
 	if g.boundingSphereValid {
 		return g.boundingSphere
 	}
 
 	// Get buffer with position vertices
-	vbPos := g.VBO("VertexPosition")
 	if vbPos == nil {
 		return g.boundingSphere
 	}
 	positions := vbPos.Buffer()
+	stride := vbPos.Stride()
 
 	// Get/calculates the bounding box
 	box := g.BoundingBox()
@@ -218,7 +250,7 @@ func (g *Geometry) BoundingSphere() math32.Sphere {
 
 	// Find the radius of the bounding sphere
 	maxRadiusSq := float32(0.0)
-	for i := 0; i < positions.Size(); i += 3 {
+	for i := vbPos.AttribOffset("VertexPosition"); i < positions.Size(); i += stride {
 		var vertex math32.Vector3
 		positions.GetVector3(i, &vertex)
 		maxRadiusSq = math32.Max(maxRadiusSq, center.DistanceToSquared(&vertex))
@@ -232,6 +264,23 @@ func (g *Geometry) BoundingSphere() math32.Sphere {
 	return g.boundingSphere
 }
 
+// positionsVBO returns the VBO with the position vertices or nil and
+// invalidates the bounding volumes if it was replaced or updated since
+// they were calculated, as when its buffer is changed in place.
+func (g *Geometry) positionsVBO() *gls.VBO {
+
+	vbPos := g.VBO("VertexPosition")
+	if vbPos != g.boundsVBO || (vbPos != nil && vbPos.Version() != g.boundsVersion) {
+		g.boundingBoxValid = false
+		g.boundingSphereValid = false
+		g.boundsVBO = vbPos
+		if vbPos != nil {
+			g.boundsVersion = vbPos.Version()
+		}
+	}
+	return vbPos
+}
+
 // ApplyMatrix multiplies each of the geometry position vertices
 // by the specified matrix and apply the correspondent normal
 // transform matrix to the geometry normal vectors.
@@ -252,6 +301,8 @@ func (g *Geometry) ApplyMatrix(m *math32.Matrix4) {
 		positions.SetVector3(i, &vertex)
 	}
 	vboPos.Update()
+	g.boundingBoxValid = false
+	g.boundingSphereValid = false
 
 	// Get normals buffer
 	vboNormals := g.VBO("VertexNormal")
@@ -0,0 +1,147 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
+)
+
+// Width in texels of the morph targets textures
+const morphTextureWidth = 1024
+
+// morphTarget is a set of vertices positions and normals
+// of a geometry, stored as offsets from the base ones
+type morphTarget struct {
+	name      string          // Target name
+	positions math32.ArrayF32 // Offsets of the positions
+	normals   math32.ArrayF32 // Offsets of the normals
+}
+
+// AddMorphTarget adds a morph target (blend shape) with the specified name
+// and the specified positions and normals of all the vertices of this
+// geometry, in the same order as the VertexPosition attribute, and returns
+// its index. The normals may be nil to keep the base normals.
+// The vertices of the meshes with this geometry are blended between the base
+// positions and normals and the ones of the targets by the mesh morph weights
+// in the vertex shader. Raycasting uses the base positions.
+func (g *Geometry) AddMorphTarget(name string, positions, normals math32.ArrayF32) int {
+
+	// The vertex count of the first target is used by all the targets
+	if len(g.morphTargets) == 0 {
+		g.morphCount = g.morphVertices()
+	}
+	target := morphTarget{name: name}
+	target.positions = g.morphOffsets("VertexPosition", positions)
+	target.normals = g.morphOffsets("VertexNormal", normals)
+	g.morphTargets = append(g.morphTargets, target)
+	g.updateMorph = true
+	return len(g.morphTargets) - 1
+}
+
+// morphOffsets returns the offsets of the specified target values
+// from the values of the specified vertex attribute
+func (g *Geometry) morphOffsets(attrib string, values math32.ArrayF32) math32.ArrayF32 {
+
+	offsets := math32.NewArrayF32(3*g.morphCount, 3*g.morphCount)
+	if values == nil {
+		return offsets
+	}
+	var base math32.ArrayF32
+	stride := 3
+	pos := 0
+	vbo := g.VBO(attrib)
+	if vbo != nil {
+		// The base values may be interleaved with other attributes
+		base = *vbo.Buffer()
+		stride = vbo.Stride()
+		pos = vbo.AttribOffset(attrib)
+	}
+	for i := 0; i < len(offsets) && i < len(values); i++ {
+		offsets[i] = values[i]
+		idx := pos + (i/3)*stride + i%3
+		if idx < len(base) {
+			offsets[i] -= base[idx]
+		}
+	}
+	return offsets
+}
+
+// morphVertices returns the current number of vertices of the VertexPosition attribute
+func (g *Geometry) morphVertices() int {
+
+	vbo := g.VBO("VertexPosition")
+	if vbo == nil || vbo.Stride() == 0 {
+		return 0
+	}
+	return vbo.Buffer().Size() / vbo.Stride()
+}
+
+// MorphVertexCount returns the number of vertices of the morph targets
+// of this geometry, which is the number of vertices of the geometry when
+// its first morph target was added.
+func (g *Geometry) MorphVertexCount() int {
+
+	return g.morphCount
+}
+
+// MorphTargetCount returns the current number of morph targets of this geometry
+func (g *Geometry) MorphTargetCount() int {
+
+	return len(g.morphTargets)
+}
+
+// MorphTargetName returns the name of the morph target with the specified index
+func (g *Geometry) MorphTargetName(idx int) string {
+
+	return g.morphTargets[idx].name
+}
+
+// MorphTargetIndex returns the index of the morph target
+// with the specified name or -1 if not found
+func (g *Geometry) MorphTargetIndex(name string) int {
+
+	for i := 0; i < len(g.morphTargets); i++ {
+		if g.morphTargets[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// MorphTexture returns the texture with the offsets of the positions and
+// normals of the morph targets of this geometry, creating or updating it
+// if necessary, or nil if there are no morph targets. The offsets of the
+// vertex of index v of the target t are in the texels with indices
+// (2*t)*n+v and (2*t+1)*n+v, where n is the number of vertices, in rows
+// of 1024 texels. It is normally used by the mesh render setup.
+func (g *Geometry) MorphTexture() *texture.Texture2D {
+
+	if len(g.morphTargets) == 0 {
+		return nil
+	}
+	if !g.updateMorph {
+		return g.morphTex
+	}
+	count := g.morphCount
+	texels := 2 * len(g.morphTargets) * count
+	height := (texels + morphTextureWidth - 1) / morphTextureWidth
+	data := math32.NewArrayF32(3*morphTextureWidth*height, 3*morphTextureWidth*height)
+	for t, target := range g.morphTargets {
+		copy(data[3*(2*t)*count:], target.positions)
+		copy(data[3*(2*t+1)*count:], target.normals)
+	}
+	if g.morphTex == nil {
+		g.morphTex = texture.NewTexture2DFromData(morphTextureWidth, height, gls.RGB, gls.FLOAT, gls.RGB32F, data)
+		g.morphTex.SetMagFilter(gls.NEAREST)
+		g.morphTex.SetMinFilter(gls.NEAREST)
+		g.morphTex.SetGenMipmap(false)
+	} else {
+		g.morphTex.SetData(morphTextureWidth, height, gls.RGB, gls.FLOAT, gls.RGB32F, data)
+	}
+	g.updateMorph = false
+	return g.morphTex
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geometry
+
+import (
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// ComputeTangents computes the tangent of each vertex of this geometry from
+// its positions, normals and texture coordinates and sets the VertexTangent
+// attribute, used by normal maps. The tangents point to the direction of
+// increasing U texture coordinate and their W component (1 or -1) is the
+// handedness of the bitangent, which is cross(normal, tangent) * W.
+// Returns false if the geometry has no positions, normals or texture
+// coordinates.
+func (g *Geometry) ComputeTangents() bool {
+
+	vboPos := g.VBO("VertexPosition")
+	vboNorm := g.VBO("VertexNormal")
+	vboUV := g.VBO("VertexTexcoord")
+	if vboPos == nil || vboNorm == nil || vboUV == nil {
+		return false
+	}
+	positions := *vboPos.Buffer()
+	normals := *vboNorm.Buffer()
+	uvs := *vboUV.Buffer()
+	count := positions.Size() / 3
+	if normals.Size() < 3*count || uvs.Size() < 2*count {
+		return false
+	}
+
+	// Accumulates the tangents and bitangents of the triangles of each vertex
+	tan := make([]math32.Vector3, count)
+	bitan := make([]math32.Vector3, count)
+	addTriangle := func(a, b, c int) {
+
+		var pA, pB, pC, e1, e2 math32.Vector3
+		positions.GetVector3(3*a, &pA)
+		positions.GetVector3(3*b, &pB)
+		positions.GetVector3(3*c, &pC)
+		e1.SubVectors(&pB, &pA)
+		e2.SubVectors(&pC, &pA)
+		du1 := uvs[2*b] - uvs[2*a]
+		dv1 := uvs[2*b+1] - uvs[2*a+1]
+		du2 := uvs[2*c] - uvs[2*a]
+		dv2 := uvs[2*c+1] - uvs[2*a+1]
+		det := du1*dv2 - du2*dv1
+		if det == 0 {
+			return
+		}
+		r := 1 / det
+		var t, bt math32.Vector3
+		t.Set((e1.X*dv2-e2.X*dv1)*r, (e1.Y*dv2-e2.Y*dv1)*r, (e1.Z*dv2-e2.Z*dv1)*r)
+		bt.Set((e2.X*du1-e1.X*du2)*r, (e2.Y*du1-e1.Y*du2)*r, (e2.Z*du1-e1.Z*du2)*r)
+		for _, v := range [3]int{a, b, c} {
+			tan[v].Add(&t)
+			bitan[v].Add(&bt)
+		}
+	}
+	if g.indices.Size() > 0 {
+		for i := 0; i+2 < g.indices.Size(); i += 3 {
+			addTriangle(int(g.indices[i]), int(g.indices[i+1]), int(g.indices[i+2]))
+		}
+	} else {
+		for i := 0; i+2 < count; i += 3 {
+			addTriangle(i, i+1, i+2)
+		}
+	}
+
+	// Orthogonalizes the tangents to the normals (Gram-Schmidt)
+	tangents := math32.NewArrayF32(0, 4*count)
+	for i := 0; i < count; i++ {
+		var n, t, tmp math32.Vector3
+		normals.GetVector3(3*i, &n)
+		t = tan[i]
+		tmp = n
+		t.Sub(tmp.MultiplyScalar(n.Dot(&t)))
+		if t.Length() == 0 {
+			// Any direction perpendicular to the normal
+			if math32.Abs(n.X) < 0.9 {
+				t.Set(1, 0, 0)
+			} else {
+				t.Set(0, 1, 0)
+			}
+			tmp = n
+			t.Sub(tmp.MultiplyScalar(n.Dot(&t)))
+		}
+		t.Normalize()
+		var w float32 = 1
+		tmp.CrossVectors(&n, &t)
+		if tmp.Dot(&bitan[i]) < 0 {
+			w = -1
+		}
+		tangents.Append(t.X, t.Y, t.Z, w)
+	}
+
+	vbo := g.VBO("VertexTangent")
+	if vbo == nil {
+		g.AddVBO(gls.NewVBO().AddAttrib("VertexTangent", 4).SetBuffer(tangents))
+		return true
+	}
+	vbo.SetBuffer(tangents)
+	vbo.Update()
+	return true
+}
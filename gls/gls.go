@@ -8,6 +8,7 @@ package gls
 import (
 	"github.com/g3n/engine/util/logger"
 	"github.com/go-gl/gl/v3.3-core/gl"
+	"image"
 	"math"
 )
 
@@ -17,10 +18,13 @@ import (
 type GLS struct {
 	// Statistics
 	Stats struct {
-		Vaos     int // Number of Vertex Array Objects
-		Vbos     int // Number of Vertex Buffer Objects
-		Textures int // Number of Textures
+		Vaos          int // Number of Vertex Array Objects
+		Vbos          int // Number of Vertex Buffer Objects
+		Textures      int // Number of Textures
+		Framebuffers  int // Number of Framebuffer Objects
+		Renderbuffers int // Number of Renderbuffer Objects
 	}
+	FrameStats         FrameStats        // Counters of OpenGL calls since the last ResetFrameStats
 	Prog               *Program          // Current active program
 	programs           map[*Program]bool // Programs cache
 	checkErrors        bool              // Check openGL API errors flag
@@ -42,6 +46,24 @@ type GLS struct {
 	blendSrcAlpha      uint32
 	blendDstRGB        uint32
 	blendDstAlpha      uint32
+	drawFramebuffer    uint32
+	readFramebuffer    uint32
+	renderbuffer       uint32
+}
+
+// FrameStats contains counters of the OpenGL calls made through a GLS
+// since the last call to ResetFrameStats, which is normally called
+// at the beginning of each frame.
+type FrameStats struct {
+	DrawCalls        int // Number of draw calls
+	Vertices         int // Number of vertices drawn including all instances
+	Primitives       int // Number of points, lines or triangles drawn including all instances
+	Programs         int // Number of programs activated
+	Uniforms         int // Number of uniforms transferred
+	TextureBinds     int // Number of textures bound
+	BufferUploads    int // Number of buffers and textures data transfers
+	FramebufferBinds int // Number of framebuffers bound
+	StateChanges     int // Number of capabilities, blending, depth, faces, lines and polygons state changes
 }
 
 const (
@@ -118,6 +140,40 @@ func (gs *GLS) Reset() {
 	gs.blendSrcAlpha = uintUndef
 	gs.blendDstRGB = uintUndef
 	gs.blendDstAlpha = uintUndef
+	gs.drawFramebuffer = uintUndef
+	gs.readFramebuffer = uintUndef
+	gs.renderbuffer = uintUndef
+}
+
+// ResetFrameStats clears the counters of OpenGL calls in FrameStats
+func (gs *GLS) ResetFrameStats() {
+
+	gs.FrameStats = FrameStats{}
+}
+
+// countDraw updates the frame statistics for a draw call
+// of the specified number of vertices and instances.
+func (gs *GLS) countDraw(mode uint32, count int32, instances int32) {
+
+	var prims int32
+	switch mode {
+	case TRIANGLES:
+		prims = count / 3
+	case TRIANGLE_STRIP, TRIANGLE_FAN:
+		prims = count - 2
+	case LINES:
+		prims = count / 2
+	case LINE_STRIP:
+		prims = count - 1
+	case LINE_LOOP, POINTS:
+		prims = count
+	}
+	if prims < 0 {
+		prims = 0
+	}
+	gs.FrameStats.DrawCalls++
+	gs.FrameStats.Vertices += int(count * instances)
+	gs.FrameStats.Primitives += int(prims * instances)
 }
 
 func (gs *GLS) SetDefaultState() {
@@ -144,16 +200,71 @@ func (gs *GLS) ActiveTexture(texture uint32) {
 	gs.checkError("ActiveTexture")
 }
 
+// BeginQuery starts the specified query object for the specified target
+func (gs *GLS) BeginQuery(target uint32, query uint32) {
+
+	gl.BeginQuery(target, query)
+	gs.checkError("BeginQuery")
+}
+
 func (gs *GLS) BindBuffer(target int, vbo uint32) {
 
 	gl.BindBuffer(uint32(target), vbo)
 	gs.checkError("BindBuffer")
 }
 
+// BindBufferBase binds the specified buffer object to the
+// specified binding point of the specified indexed target
+func (gs *GLS) BindBufferBase(target uint32, index uint32, buffer uint32) {
+
+	gl.BindBufferBase(target, index, buffer)
+	gs.checkError("BindBufferBase")
+}
+
+// BindFramebuffer binds the specified framebuffer object to the specified
+// target: FRAMEBUFFER, DRAW_FRAMEBUFFER or READ_FRAMEBUFFER.
+// The value 0 binds the default framebuffer.
+func (gs *GLS) BindFramebuffer(target uint32, fb uint32) {
+
+	switch target {
+	case FRAMEBUFFER:
+		if gs.drawFramebuffer == fb && gs.readFramebuffer == fb {
+			return
+		}
+		gs.drawFramebuffer = fb
+		gs.readFramebuffer = fb
+	case DRAW_FRAMEBUFFER:
+		if gs.drawFramebuffer == fb {
+			return
+		}
+		gs.drawFramebuffer = fb
+	case READ_FRAMEBUFFER:
+		if gs.readFramebuffer == fb {
+			return
+		}
+		gs.readFramebuffer = fb
+	}
+	gl.BindFramebuffer(target, fb)
+	gs.checkError("BindFramebuffer")
+	gs.FrameStats.FramebufferBinds++
+}
+
+// BindRenderbuffer binds the specified renderbuffer object
+func (gs *GLS) BindRenderbuffer(rb uint32) {
+
+	if gs.renderbuffer == rb {
+		return
+	}
+	gl.BindRenderbuffer(RENDERBUFFER, rb)
+	gs.checkError("BindRenderbuffer")
+	gs.renderbuffer = rb
+}
+
 func (gs *GLS) BindTexture(target int, tex uint32) {
 
 	gl.BindTexture(uint32(target), tex)
 	gs.checkError("BindTexture")
+	gs.FrameStats.TextureBinds++
 }
 
 func (gs *GLS) BindVertexArray(vao uint32) {
@@ -169,6 +280,7 @@ func (gs *GLS) BlendEquation(mode uint32) {
 	}
 	gl.BlendEquation(mode)
 	gs.checkError("BlendEquation")
+	gs.FrameStats.StateChanges++
 	gs.blendEquation = mode
 }
 
@@ -179,6 +291,7 @@ func (gs *GLS) BlendEquationSeparate(modeRGB uint32, modeAlpha uint32) {
 	}
 	gl.BlendEquationSeparate(uint32(modeRGB), uint32(modeAlpha))
 	gs.checkError("BlendEquationSeparate")
+	gs.FrameStats.StateChanges++
 	gs.blendEquationRGB = modeRGB
 	gs.blendEquationAlpha = modeAlpha
 }
@@ -190,6 +303,7 @@ func (gs *GLS) BlendFunc(sfactor, dfactor uint32) {
 	}
 	gl.BlendFunc(sfactor, dfactor)
 	gs.checkError("BlendFunc")
+	gs.FrameStats.StateChanges++
 	gs.blendSrc = sfactor
 	gs.blendDst = dfactor
 }
@@ -202,6 +316,7 @@ func (gs *GLS) BlendFuncSeparate(srcRGB uint32, dstRGB uint32, srcAlpha uint32,
 	}
 	gl.BlendFuncSeparate(srcRGB, dstRGB, srcAlpha, dstAlpha)
 	gs.checkError("BlendFuncSeparate")
+	gs.FrameStats.StateChanges++
 	gs.blendSrcRGB = srcRGB
 	gs.blendDstRGB = dstRGB
 	gs.blendSrcAlpha = srcAlpha
@@ -212,6 +327,40 @@ func (gs *GLS) BufferData(target uint32, size int, data interface{}, usage uint3
 
 	gl.BufferData(target, size, gl.Ptr(data), usage)
 	gs.checkError("BufferData")
+	gs.FrameStats.BufferUploads++
+}
+
+// BufferSubData updates the specified number of bytes from the
+// specified offset of the data store of the buffer bound to the target
+func (gs *GLS) BufferSubData(target uint32, offset int, size int, data interface{}) {
+
+	gl.BufferSubData(target, offset, size, gl.Ptr(data))
+	gs.checkError("BufferSubData")
+	gs.FrameStats.BufferUploads++
+}
+
+func (gs *GLS) CheckFramebufferStatus(target uint32) uint32 {
+
+	status := gl.CheckFramebufferStatus(target)
+	gs.checkError("CheckFramebufferStatus")
+	return status
+}
+
+// ClearBufferfv clears the specified buffer (COLOR or DEPTH) of the
+// currently bound draw framebuffer to the specified values without
+// changing the current clear color or depth.
+func (gs *GLS) ClearBufferfv(buffer uint32, drawbuffer int32, value []float32) {
+
+	gl.ClearBufferfv(buffer, drawbuffer, &value[0])
+	gs.checkError("ClearBufferfv")
+}
+
+// ClearBufferuiv clears the specified draw buffer of the current
+// framebuffer, which must be an unsigned integer buffer, with the specified value
+func (gs *GLS) ClearBufferuiv(buffer uint32, drawbuffer int32, value []uint32) {
+
+	gl.ClearBufferuiv(buffer, drawbuffer, &value[0])
+	gs.checkError("ClearBufferuiv")
 }
 
 func (gs *GLS) ClearColor(r, g, b, a float32) {
@@ -230,6 +379,41 @@ func (gs *GLS) DeleteBuffers(vbos ...uint32) {
 	gs.checkError("DeleteBuffers")
 }
 
+func (gs *GLS) DeleteFramebuffers(fbs ...uint32) {
+
+	gl.DeleteFramebuffers(int32(len(fbs)), &fbs[0])
+	gs.checkError("DeleteFramebuffers")
+	gs.Stats.Framebuffers -= len(fbs)
+	// Deleting a bound framebuffer binds the default framebuffer
+	for _, fb := range fbs {
+		if gs.drawFramebuffer == fb {
+			gs.drawFramebuffer = 0
+		}
+		if gs.readFramebuffer == fb {
+			gs.readFramebuffer = 0
+		}
+	}
+}
+
+// DeleteQueries deletes the specified query objects
+func (gs *GLS) DeleteQueries(queries ...uint32) {
+
+	gl.DeleteQueries(int32(len(queries)), &queries[0])
+	gs.checkError("DeleteQueries")
+}
+
+func (gs *GLS) DeleteRenderbuffers(rbs ...uint32) {
+
+	gl.DeleteRenderbuffers(int32(len(rbs)), &rbs[0])
+	gs.checkError("DeleteRenderbuffers")
+	gs.Stats.Renderbuffers -= len(rbs)
+	for _, rb := range rbs {
+		if gs.renderbuffer == rb {
+			gs.renderbuffer = 0
+		}
+	}
+}
+
 func (gs *GLS) DeleteTextures(tex ...uint32) {
 
 	gl.DeleteTextures(int32(len(tex)), &tex[0])
@@ -250,6 +434,7 @@ func (gs *GLS) DepthFunc(mode uint32) {
 	}
 	gl.DepthFunc(mode)
 	gs.checkError("DepthFunc")
+	gs.FrameStats.StateChanges++
 	gs.depthFunc = mode
 }
 
@@ -263,6 +448,7 @@ func (gs *GLS) DepthMask(flag bool) {
 	}
 	gl.DepthMask(flag)
 	gs.checkError("DepthMask")
+	gs.FrameStats.StateChanges++
 	if flag {
 		gs.depthMask = intTrue
 	} else {
@@ -274,12 +460,53 @@ func (gs *GLS) DrawArrays(mode uint32, first int32, count int32) {
 
 	gl.DrawArrays(mode, first, count)
 	gs.checkError("DrawArrays")
+	gs.countDraw(mode, count, 1)
+}
+
+// DrawArraysInstanced draws the specified number of instances
+// of the range of vertices of the current vertex array object.
+func (gs *GLS) DrawArraysInstanced(mode uint32, first int32, count int32, instances int32) {
+
+	gl.DrawArraysInstanced(mode, first, count, instances)
+	gs.checkError("DrawArraysInstanced")
+	gs.countDraw(mode, count, instances)
+}
+
+func (gs *GLS) DrawBuffer(mode uint32) {
+
+	gl.DrawBuffer(mode)
+	gs.checkError("DrawBuffer")
+}
+
+// DrawBuffers specifies the list of color attachments of the current
+// framebuffer to be written by the fragment shader outputs (MRT).
+func (gs *GLS) DrawBuffers(bufs ...uint32) {
+
+	gl.DrawBuffers(int32(len(bufs)), &bufs[0])
+	gs.checkError("DrawBuffers")
 }
 
 func (gs *GLS) DrawElements(mode uint32, count int32, itype uint32, start uint32) {
 
 	gl.DrawElements(mode, int32(count), itype, gl.PtrOffset(int(start)))
 	gs.checkError("DrawElements")
+	gs.countDraw(mode, count, 1)
+}
+
+// DrawElementsInstanced draws the specified number of instances
+// of the indexed vertices of the current vertex array object.
+func (gs *GLS) DrawElementsInstanced(mode uint32, count int32, itype uint32, start uint32, instances int32) {
+
+	gl.DrawElementsInstanced(mode, count, itype, gl.PtrOffset(int(start)), instances)
+	gs.checkError("DrawElementsInstanced")
+	gs.countDraw(mode, count, instances)
+}
+
+// EndQuery ends the active query object of the specified target
+func (gs *GLS) EndQuery(target uint32) {
+
+	gl.EndQuery(target)
+	gs.checkError("EndQuery")
 }
 
 func (gs *GLS) Enable(cap int) {
@@ -289,9 +516,17 @@ func (gs *GLS) Enable(cap int) {
 	}
 	gl.Enable(uint32(cap))
 	gs.checkError("Enable")
+	gs.FrameStats.StateChanges++
 	gs.capabilities[cap] = capEnabled
 }
 
+// IsEnabled returns if the specified capability was enabled by Enable.
+// Capabilities not changed since the last Reset are reported as disabled.
+func (gs *GLS) IsEnabled(cap int) bool {
+
+	return gs.capabilities[cap] == capEnabled
+}
+
 func (gs *GLS) EnableVertexAttribArray(index uint32) {
 
 	gl.EnableVertexAttribArray(index)
@@ -305,13 +540,37 @@ func (gs *GLS) Disable(cap int) {
 	}
 	gl.Disable(uint32(cap))
 	gs.checkError("Disable")
+	gs.FrameStats.StateChanges++
 	gs.capabilities[cap] = capDisabled
 }
 
+// Framebuffer returns the handle of the framebuffer object currently
+// bound to the draw framebuffer target. Zero is the default framebuffer.
+func (gs *GLS) Framebuffer() uint32 {
+
+	if gs.drawFramebuffer == uintUndef {
+		return 0
+	}
+	return gs.drawFramebuffer
+}
+
+func (gs *GLS) FramebufferRenderbuffer(target, attachment uint32, rb uint32) {
+
+	gl.FramebufferRenderbuffer(target, attachment, RENDERBUFFER, rb)
+	gs.checkError("FramebufferRenderbuffer")
+}
+
+func (gs *GLS) FramebufferTexture2D(target, attachment, textarget uint32, tex uint32, level int32) {
+
+	gl.FramebufferTexture2D(target, attachment, textarget, tex, level)
+	gs.checkError("FramebufferTexture2D")
+}
+
 func (gs *GLS) FrontFace(mode uint32) {
 
 	gl.FrontFace(mode)
 	gs.checkError("FrontFace")
+	gs.FrameStats.StateChanges++
 }
 
 func (gs *GLS) GenBuffer() uint32 {
@@ -329,6 +588,33 @@ func (gs *GLS) GenerateMipmap(target uint32) {
 	gs.checkError("GenerateMipmap")
 }
 
+func (gs *GLS) GenFramebuffer() uint32 {
+
+	var fb uint32
+	gl.GenFramebuffers(1, &fb)
+	gs.checkError("GenFramebuffers")
+	gs.Stats.Framebuffers++
+	return fb
+}
+
+// GenQuery generates and returns the name of a new query object
+func (gs *GLS) GenQuery() uint32 {
+
+	var query uint32
+	gl.GenQueries(1, &query)
+	gs.checkError("GenQueries")
+	return query
+}
+
+func (gs *GLS) GenRenderbuffer() uint32 {
+
+	var rb uint32
+	gl.GenRenderbuffers(1, &rb)
+	gs.checkError("GenRenderbuffers")
+	gs.Stats.Renderbuffers++
+	return rb
+}
+
 func (gs *GLS) GenTexture() uint32 {
 
 	var tex uint32
@@ -353,6 +639,26 @@ func (gs *GLS) GetString(name uint32) string {
 	return gl.GoStr(cstr)
 }
 
+// GetQueryObjectiv returns the specified parameter of a query object,
+// such as QUERY_RESULT_AVAILABLE
+func (gs *GLS) GetQueryObjectiv(query uint32, pname uint32) int32 {
+
+	var param int32
+	gl.GetQueryObjectiv(query, pname, &param)
+	gs.checkError("GetQueryObjectiv")
+	return param
+}
+
+// GetQueryObjectui64v returns the specified 64 bits parameter of a
+// query object, such as the QUERY_RESULT of a TIME_ELAPSED query.
+func (gs *GLS) GetQueryObjectui64v(query uint32, pname uint32) uint64 {
+
+	var param uint64
+	gl.GetQueryObjectui64v(query, pname, &param)
+	gs.checkError("GetQueryObjectui64v")
+	return param
+}
+
 func (gs *GLS) GetViewport() (x, y, width, height int32) {
 
 	return gs.viewportX, gs.viewportY, gs.viewportWidth, gs.viewportHeight
@@ -365,9 +671,62 @@ func (gs *GLS) LineWidth(width float32) {
 	}
 	gl.LineWidth(width)
 	gs.checkError("LineWidth")
+	gs.FrameStats.StateChanges++
 	gs.lineWidth = width
 }
 
+func (gs *GLS) ReadBuffer(mode uint32) {
+
+	gl.ReadBuffer(mode)
+	gs.checkError("ReadBuffer")
+}
+
+// ReadPixels reads the specified rectangle of pixels of the current read
+// buffer of the current read framebuffer into the specified data slice,
+// which must be large enough for the pixels in the specified format and type.
+func (gs *GLS) ReadPixels(x, y, width, height int32, format, formatType uint32, data interface{}) {
+
+	gl.ReadPixels(x, y, width, height, format, formatType, gl.Ptr(data))
+	gs.checkError("ReadPixels")
+}
+
+// ReadImage reads the specified rectangle of pixels of the current read
+// buffer of the current read framebuffer and returns them as a new RGBA
+// image. OpenGL rows start at the bottom, so they are flipped to the
+// top down order of the image.
+func (gs *GLS) ReadImage(x, y, width, height int32) *image.RGBA {
+
+	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	if width <= 0 || height <= 0 {
+		return img
+	}
+	gs.ReadPixels(x, y, width, height, RGBA, UNSIGNED_BYTE, &img.Pix[0])
+	stride := img.Stride
+	row := make([]uint8, stride)
+	for top, bottom := 0, int(height)-1; top < bottom; top, bottom = top+1, bottom-1 {
+		copy(row, img.Pix[top*stride:(top+1)*stride])
+		copy(img.Pix[top*stride:(top+1)*stride], img.Pix[bottom*stride:(bottom+1)*stride])
+		copy(img.Pix[bottom*stride:(bottom+1)*stride], row)
+	}
+	return img
+}
+
+// RenderbufferStorage allocates the storage of the currently
+// bound renderbuffer with the specified format and size.
+func (gs *GLS) RenderbufferStorage(iformat uint32, width, height int32) {
+
+	gl.RenderbufferStorage(RENDERBUFFER, iformat, width, height)
+	gs.checkError("RenderbufferStorage")
+}
+
+// Scissor sets the rectangle in window coordinates outside of which
+// fragments are discarded when SCISSOR_TEST is enabled.
+func (gs *GLS) Scissor(x, y, width, height int32) {
+
+	gl.Scissor(x, y, width, height)
+	gs.checkError("Scissor")
+}
+
 func (gs *GLS) SetDepthTest(mode bool) {
 
 	if mode {
@@ -404,6 +763,7 @@ func (gs *GLS) TexImage2D(target uint32, level int32, iformat int32, width int32
 
 	gl.TexImage2D(uint32(target), int32(level), int32(iformat), int32(width), int32(height), int32(border), uint32(format), uint32(itype), gl.Ptr(data))
 	gs.checkError("TexImage2D")
+	gs.FrameStats.BufferUploads++
 }
 
 func (gs *GLS) TexStorage2D(target int, levels int, iformat int, width, height int) {
@@ -422,54 +782,70 @@ func (gs *GLS) PolygonMode(face, mode int) {
 
 	gl.PolygonMode(uint32(face), uint32(mode))
 	gs.checkError("PolygonMode")
+	gs.FrameStats.StateChanges++
 }
 
 func (gs *GLS) PolygonOffset(factor float32, units float32) {
 
 	gl.PolygonOffset(factor, units)
 	gs.checkError("PolygonOffset")
+	gs.FrameStats.StateChanges++
 }
 
 func (gs *GLS) Uniform1i(location int32, v0 int32) {
 
 	gl.Uniform1i(location, v0)
 	gs.checkError("Uniform1i")
+	gs.FrameStats.Uniforms++
 }
 
 func (gs *GLS) Uniform1f(location int32, v0 float32) {
 
 	gl.Uniform1f(location, v0)
 	gs.checkError("Uniform1f")
+	gs.FrameStats.Uniforms++
 }
 
 func (gs *GLS) Uniform2f(location int32, v0, v1 float32) {
 
 	gl.Uniform2f(location, v0, v1)
 	gs.checkError("Uniform2f")
+	gs.FrameStats.Uniforms++
 }
 
 func (gs *GLS) Uniform3f(location int32, v0, v1, v2 float32) {
 
 	gl.Uniform3f(location, v0, v1, v2)
 	gs.checkError("Uniform3f")
+	gs.FrameStats.Uniforms++
 }
 
 func (gs *GLS) Uniform4f(location int32, v0, v1, v2, v3 float32) {
 
 	gl.Uniform4f(location, v0, v1, v2, v3)
 	gs.checkError("Uniform4f")
+	gs.FrameStats.Uniforms++
+}
+
+func (gs *GLS) Uniform1fv(location int32, count int32, v []float32) {
+
+	gl.Uniform1fv(location, count, &v[0])
+	gs.checkError("Uniform1fv")
+	gs.FrameStats.Uniforms++
 }
 
 func (gs *GLS) UniformMatrix3fv(location int32, count int32, transpose bool, v []float32) {
 
 	gl.UniformMatrix3fv(location, count, transpose, &v[0])
 	gs.checkError("UniformMatrix3fv")
+	gs.FrameStats.Uniforms++
 }
 
 func (gs *GLS) UniformMatrix4fv(location int32, count int32, transpose bool, v []float32) {
 
 	gl.UniformMatrix4fv(location, count, transpose, &v[0])
 	gs.checkError("UniformMatrix4fv")
+	gs.FrameStats.Uniforms++
 }
 
 // Use set this program as the current program.
@@ -480,6 +856,7 @@ func (gs *GLS) UseProgram(prog *Program) {
 	}
 	gl.UseProgram(prog.handle)
 	gs.checkError("UseProgram")
+	gs.FrameStats.Programs++
 	gs.Prog = prog
 
 	// Inserts program in cache if not already there.
@@ -489,6 +866,14 @@ func (gs *GLS) UseProgram(prog *Program) {
 	}
 }
 
+// VertexAttribDivisor sets the number of instances which use the same
+// value of the specified vertex attribute. Zero means per vertex values.
+func (gs *GLS) VertexAttribDivisor(index uint32, divisor uint32) {
+
+	gl.VertexAttribDivisor(index, divisor)
+	gs.checkError("VertexAttribDivisor")
+}
+
 func (gs *GLS) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset uint32) {
 
 	gl.VertexAttribPointer(index, size, xtype, normalized, stride, gl.PtrOffset(int(offset)))
@@ -149,6 +149,22 @@ func (prog *Program) Handle() uint32 {
 	return prog.handle
 }
 
+// Dispose deletes this program from OpenGL and from the programs cache.
+// The program must not be the current active program when drawing.
+func (prog *Program) Dispose() {
+
+	if prog.handle == 0 {
+		return
+	}
+	gl.DeleteProgram(prog.handle)
+	prog.gs.checkError("DeleteProgram")
+	prog.handle = 0
+	delete(prog.gs.programs, prog)
+	if prog.gs.Prog == prog {
+		prog.gs.Prog = nil
+	}
+}
+
 // GetActiveUniformBlockSize returns the minimum number of bytes
 // to contain the data for the uniform block specified by its index.
 func (prog *Program) GetActiveUniformBlockSize(ubindex uint32) int32 {
@@ -202,6 +218,20 @@ func (prog *Program) GetUniformBlockIndex(name string) uint32 {
 	return index
 }
 
+// SetUniformBlockBinding sets the binding point of the named uniform block
+// of this program, which is used by the UBO with the same binding point.
+// Returns false if the program has no uniform block with the specified name.
+func (prog *Program) SetUniformBlockBinding(name string, binding uint32) bool {
+
+	index := prog.GetUniformBlockIndex(name)
+	if index == gl.INVALID_INDEX {
+		return false
+	}
+	gl.UniformBlockBinding(prog.handle, index, binding)
+	prog.gs.checkError("UniformBlockBinding")
+	return true
+}
+
 // GetUniformIndices returns the indices for each specified named
 // uniform. If an specified name is not valid the corresponding
 // index value will be gl.INVALID_INDEX
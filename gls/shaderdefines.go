@@ -0,0 +1,63 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+// ShaderDefines is a map of preprocessor symbols to their values which are
+// defined at the beginning of the generated shaders sources.
+// Symbols with empty values are defined without a value.
+type ShaderDefines map[string]string
+
+// NewShaderDefines creates and returns a new empty map of shader defines
+func NewShaderDefines() ShaderDefines {
+
+	return make(ShaderDefines)
+}
+
+// Set defines the specified symbol with the specified value
+func (sd ShaderDefines) Set(name, value string) {
+
+	sd[name] = value
+}
+
+// Unset removes the definition of the specified symbol
+func (sd ShaderDefines) Unset(name string) {
+
+	delete(sd, name)
+}
+
+// Add defines all the symbols of the other map in this map
+func (sd ShaderDefines) Add(other ShaderDefines) {
+
+	for name, value := range other {
+		sd[name] = value
+	}
+}
+
+// Clone returns a copy of this map of shader defines
+func (sd ShaderDefines) Clone() ShaderDefines {
+
+	if sd == nil {
+		return nil
+	}
+	clone := make(ShaderDefines, len(sd))
+	clone.Add(sd)
+	return clone
+}
+
+// Equals returns if this map contains exactly the same
+// symbols and values of the other map
+func (sd ShaderDefines) Equals(other ShaderDefines) bool {
+
+	if len(sd) != len(other) {
+		return false
+	}
+	for name, value := range sd {
+		ovalue, ok := other[name]
+		if !ok || ovalue != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"time"
+)
+
+// Number of queries used in turn by a TimerQuery
+const timerQueries = 4
+
+// TimerQuery measures the GPU time spent executing the OpenGL commands
+// issued between Begin and End, normally once per frame.
+// The results are only read when available, some frames later,
+// so measuring does not stall the pipeline.
+type TimerQuery struct {
+	gs      *GLS                 // OpenGL state
+	queries [timerQueries]uint32 // Query objects used in turn
+	pending [timerQueries]bool   // Query has a result not yet read
+	current int                  // Index of the next query to begin
+	active  bool                 // A query was begun and not yet ended
+	elapsed time.Duration        // Last GPU time read
+	valid   bool                 // A GPU time was already read
+}
+
+// NewTimerQuery creates and returns a pointer to a new GPU timer query
+func NewTimerQuery(gs *GLS) *TimerQuery {
+
+	tq := new(TimerQuery)
+	tq.gs = gs
+	for i := 0; i < timerQueries; i++ {
+		tq.queries[i] = gs.GenQuery()
+	}
+	return tq
+}
+
+// Begin starts measuring the GPU time of the following commands.
+// If all the queries are still waiting for their results
+// this measure is skipped.
+func (tq *TimerQuery) Begin() {
+
+	tq.poll()
+	if tq.pending[tq.current] {
+		return
+	}
+	tq.gs.BeginQuery(TIME_ELAPSED, tq.queries[tq.current])
+	tq.active = true
+}
+
+// End stops measuring the GPU time begun by the last Begin
+func (tq *TimerQuery) End() {
+
+	if !tq.active {
+		return
+	}
+	tq.gs.EndQuery(TIME_ELAPSED)
+	tq.pending[tq.current] = true
+	tq.current = (tq.current + 1) % timerQueries
+	tq.active = false
+}
+
+// Elapsed returns the last GPU time measured and if
+// any measure is already available.
+func (tq *TimerQuery) Elapsed() (time.Duration, bool) {
+
+	tq.poll()
+	return tq.elapsed, tq.valid
+}
+
+// Dispose releases the OpenGL query objects
+func (tq *TimerQuery) Dispose() {
+
+	tq.gs.DeleteQueries(tq.queries[:]...)
+}
+
+// poll reads the results of the pending queries in the order
+// they were issued, stopping at the first one not yet available.
+func (tq *TimerQuery) poll() {
+
+	for i := 0; i < timerQueries; i++ {
+		idx := (tq.current + i) % timerQueries
+		if !tq.pending[idx] {
+			continue
+		}
+		if tq.active && idx == tq.current {
+			break
+		}
+		query := tq.queries[idx]
+		if tq.gs.GetQueryObjectiv(query, QUERY_RESULT_AVAILABLE) == 0 {
+			break
+		}
+		tq.elapsed = time.Duration(tq.gs.GetQueryObjectui64v(query, QUERY_RESULT))
+		tq.valid = true
+		tq.pending[idx] = false
+	}
+}
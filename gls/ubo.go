@@ -0,0 +1,100 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gls
+
+import (
+	"github.com/g3n/engine/math32"
+)
+
+// UBO abstracts an OpenGL Uniform Buffer Object.
+// Its data is shared by the uniform blocks of all the programs which
+// have the same binding point, so it is transferred once for all of them.
+// The data must be laid out by the user following the std140 rules
+// of the uniform blocks.
+type UBO struct {
+	gs      *GLS
+	handle  uint32          // OpenGL handle for this UBO
+	binding uint32          // Uniform block binding point
+	usage   uint32          // Expected usage pattern of the buffer
+	update  bool            // Update flag
+	size    int             // Size in bytes of the allocated buffer
+	buffer  math32.ArrayF32 // Data buffer
+}
+
+// NewUBO creates and returns a pointer to a new OpenGL Uniform Buffer Object
+// for the specified binding point with a buffer of the specified number of floats
+func NewUBO(binding uint32, size int) *UBO {
+
+	ubo := new(UBO)
+	ubo.binding = binding
+	ubo.usage = DYNAMIC_DRAW
+	ubo.update = true
+	ubo.buffer = math32.NewArrayF32(size, size)
+	return ubo
+}
+
+// Binding returns the uniform block binding point of this UBO
+func (ubo *UBO) Binding() uint32 {
+
+	return ubo.binding
+}
+
+// SetUsage sets the expected usage pattern of the buffer.
+// The default value is GL_DYNAMIC_DRAW.
+func (ubo *UBO) SetUsage(usage uint32) {
+
+	ubo.usage = usage
+}
+
+// Buffer returns pointer to the UBO buffer
+func (ubo *UBO) Buffer() *math32.ArrayF32 {
+
+	return &ubo.buffer
+}
+
+// Update sets the update flag to force the UBO update
+func (ubo *UBO) Update() {
+
+	ubo.update = true
+}
+
+// Dispose releases the OpenGL buffer of this UBO
+func (ubo *UBO) Dispose() {
+
+	if ubo.gs != nil {
+		ubo.gs.DeleteBuffers(ubo.handle)
+		ubo.gs = nil
+	}
+	ubo.size = 0
+	ubo.update = true
+}
+
+// Transfer transfers the data of the UBO buffer to OpenGL if
+// necessary and binds the UBO to its binding point
+func (ubo *UBO) Transfer(gs *GLS) {
+
+	// If the UBO buffer is empty, ignore
+	if ubo.buffer.Bytes() == 0 {
+		return
+	}
+
+	// First time initialization
+	if ubo.gs == nil {
+		ubo.handle = gs.GenBuffer()
+		ubo.gs = gs
+	}
+	if ubo.update {
+		gs.BindBuffer(UNIFORM_BUFFER, ubo.handle)
+		// Reallocates the buffer only if its size changed
+		if ubo.size != ubo.buffer.Bytes() {
+			ubo.size = ubo.buffer.Bytes()
+			gs.BufferData(UNIFORM_BUFFER, ubo.size, &ubo.buffer[0], ubo.usage)
+		} else {
+			gs.BufferSubData(UNIFORM_BUFFER, 0, ubo.size, &ubo.buffer[0])
+		}
+		ubo.update = false
+	}
+	gs.BindBufferBase(UNIFORM_BUFFER, ubo.binding, ubo.handle)
+}
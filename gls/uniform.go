@@ -120,6 +120,62 @@ func (uni *Uniform1f) TransferIdx(gs *GLS, idx int) {
 	gs.Uniform1f(uni.LocationIdx(gs, idx), uni.v0)
 }
 
+//
+// Type Uniform1fv is a Uniform containing an array
+// of float32 values
+//
+type Uniform1fv struct {
+	Uniform
+	v []float32
+}
+
+func NewUniform1fv(name string, count int) *Uniform1fv {
+
+	uni := new(Uniform1fv)
+	uni.Init(name, count)
+	return uni
+}
+
+func (uni *Uniform1fv) Init(name string, count int) {
+
+	uni.name = name
+	uni.SetCount(count)
+}
+
+// SetCount sets the number of values of the array
+func (uni *Uniform1fv) SetCount(count int) {
+
+	if cap(uni.v) >= count {
+		uni.v = uni.v[:count]
+		return
+	}
+	uni.v = make([]float32, count)
+}
+
+// Count returns the number of values of the array
+func (uni *Uniform1fv) Count() int {
+
+	return len(uni.v)
+}
+
+func (uni *Uniform1fv) Set(idx int, v float32) {
+
+	uni.v[idx] = v
+}
+
+func (uni *Uniform1fv) Get(idx int) float32 {
+
+	return uni.v[idx]
+}
+
+func (uni *Uniform1fv) Transfer(gs *GLS) {
+
+	if len(uni.v) == 0 {
+		return
+	}
+	gs.Uniform1fv(uni.Location(gs), int32(len(uni.v)), uni.v)
+}
+
 //
 // Type Uniform2f is a Uniform containing two float32 values
 //
@@ -399,3 +455,61 @@ func (uni *UniformMatrix4f) TransferIdx(gl *GLS, idx int) {
 
 	gl.UniformMatrix4fv(uni.LocationIdx(gl, idx), 1, false, uni.v[0:16])
 }
+
+//
+// Type UniformMatrix4fv is a Uniform containing an array
+// of 4x4 matrices
+//
+type UniformMatrix4fv struct {
+	Uniform
+	v []float32
+}
+
+func NewUniformMatrix4fv(name string, count int) *UniformMatrix4fv {
+
+	uni := new(UniformMatrix4fv)
+	uni.Init(name, count)
+	return uni
+}
+
+func (uni *UniformMatrix4fv) Init(name string, count int) {
+
+	uni.name = name
+	uni.SetCount(count)
+}
+
+// SetCount sets the number of matrices of the array
+func (uni *UniformMatrix4fv) SetCount(count int) {
+
+	if cap(uni.v) >= 16*count {
+		uni.v = uni.v[:16*count]
+		return
+	}
+	uni.v = make([]float32, 16*count)
+}
+
+// Count returns the number of matrices of the array
+func (uni *UniformMatrix4fv) Count() int {
+
+	return len(uni.v) / 16
+}
+
+func (uni *UniformMatrix4fv) SetMatrix4(idx int, m *math32.Matrix4) {
+
+	copy(uni.v[16*idx:], m[:])
+}
+
+func (uni *UniformMatrix4fv) GetMatrix4(idx int) math32.Matrix4 {
+
+	var m math32.Matrix4
+	copy(m[:], uni.v[16*idx:])
+	return m
+}
+
+func (uni *UniformMatrix4fv) Transfer(gl *GLS) {
+
+	if len(uni.v) == 0 {
+		return
+	}
+	gl.UniformMatrix4fv(uni.Location(gl), int32(len(uni.v)/16), false, uni.v)
+}
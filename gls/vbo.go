@@ -14,9 +14,13 @@ type VBO struct {
 	gs      *GLS
 	handle  uint32          // OpenGL handle for this VBO
 	usage   uint32          // Expected usage patter of the buffer
+	divisor uint32          // Attributes divisor for instanced rendering
 	update  bool            // Update flag
+	version uint32          // Incremented each time the buffer is set or updated
 	buffer  math32.ArrayF32 // Data buffer
 	attribs []VBOattrib     // List of attributes
+	located bool            // All the attributes were found in the program of the last setup
+	prog    *Program        // Program current in the last setup of the attributes
 }
 
 // VBOattrib describes one attribute of an OpenGL Vertex Buffer Object
@@ -39,8 +43,11 @@ func (vbo *VBO) init() {
 	vbo.gs = nil
 	vbo.handle = 0
 	vbo.usage = STATIC_DRAW
+	vbo.divisor = 0
 	vbo.update = true
 	vbo.attribs = make([]VBOattrib, 0)
+	vbo.located = false
+	vbo.prog = nil
 }
 
 // AddAttrib adds a new attribute to this VBO
@@ -81,9 +88,35 @@ func (vbo *VBO) AttribCount() int {
 func (vbo *VBO) SetBuffer(buffer math32.ArrayF32) *VBO {
 
 	vbo.buffer = buffer
+	vbo.version++
 	return vbo
 }
 
+// Stride returns the number of buffer elements of each vertex,
+// which is the sum of the sizes of the attributes of this VBO
+func (vbo *VBO) Stride() int {
+
+	stride := 0
+	for _, attrib := range vbo.attribs {
+		stride += int(attrib.ItemSize)
+	}
+	return stride
+}
+
+// AttribOffset returns the index in the buffer elements of each vertex of
+// the attribute with the specified name or -1 if not found
+func (vbo *VBO) AttribOffset(name string) int {
+
+	offset := 0
+	for _, attrib := range vbo.attribs {
+		if attrib.Name == name {
+			return offset
+		}
+		offset += int(attrib.ItemSize)
+	}
+	return -1
+}
+
 // Sets the expected usage pattern of the buffer.
 // The default value is GL_STATIC_DRAW.
 func (vbo *VBO) SetUsage(usage uint32) {
@@ -91,6 +124,25 @@ func (vbo *VBO) SetUsage(usage uint32) {
 	vbo.usage = usage
 }
 
+// SetDivisor sets the number of instances which use the same attributes
+// values of this VBO when drawing instances. Zero (the default) means
+// the values are per vertex. VBOs with divisors may be shared by the
+// vertex arrays of several geometries, so their attributes are set
+// each time they are transferred.
+// Attributes with more than 4 elements, such as mat4 with 16, are set
+// as consecutive attributes of 4 elements.
+func (vbo *VBO) SetDivisor(divisor uint32) *VBO {
+
+	vbo.divisor = divisor
+	return vbo
+}
+
+// Divisor returns the current attributes divisor
+func (vbo *VBO) Divisor() uint32 {
+
+	return vbo.divisor
+}
+
 // Buffer returns pointer to the VBO buffer
 func (vbo *VBO) Buffer() *math32.ArrayF32 {
 
@@ -101,6 +153,24 @@ func (vbo *VBO) Buffer() *math32.ArrayF32 {
 func (vbo *VBO) Update() {
 
 	vbo.update = true
+	vbo.version++
+}
+
+// Version returns a counter which is incremented each time the buffer
+// is set or updated, used to know if data derived from it is outdated.
+func (vbo *VBO) Version() uint32 {
+
+	return vbo.version
+}
+
+// Dispose releases the OpenGL buffer of this VBO
+func (vbo *VBO) Dispose() {
+
+	if vbo.gs != nil {
+		vbo.gs.DeleteBuffers(vbo.handle)
+		vbo.gs = nil
+	}
+	vbo.update = true
 }
 
 // Transfer is called internally and transfer the data in the VBO buffer to OpenGL if necessary
@@ -114,29 +184,13 @@ func (vbo *VBO) Transfer(gs *GLS) {
 	// First time initialization
 	if vbo.gs == nil {
 		vbo.handle = gs.GenBuffer()
-		gs.BindBuffer(ARRAY_BUFFER, vbo.handle)
-		// Calculates stride
-		elsize := int32(unsafe.Sizeof(float32(0)))
-		var stride int32 = 0
-		for _, attrib := range vbo.attribs {
-			stride += elsize * attrib.ItemSize
-		}
-		// For each attribute
-		var items uint32 = 0
-		var offset uint32 = 0
-		for _, attrib := range vbo.attribs {
-			// Get attribute location in the current program
-			loc := gs.Prog.GetAttribLocation(attrib.Name)
-			if loc < 0 {
-				continue
-			}
-			// Enables attribute and sets its stride and offset in the buffer
-			gs.EnableVertexAttribArray(uint32(loc))
-			gs.VertexAttribPointer(uint32(loc), attrib.ItemSize, FLOAT, false, stride, offset)
-			items += uint32(attrib.ItemSize)
-			offset = uint32(elsize) * items
-		}
+		vbo.setAttribs(gs)
 		vbo.gs = gs // this indicates that the vbo was initialized
+	} else if vbo.divisor > 0 || (!vbo.located && gs.Prog != vbo.prog) {
+		// Attributes not used by the program of the first setup, such as
+		// the texture coordinates in a depth pass, are set when a program
+		// which uses them is current.
+		vbo.setAttribs(gs)
 	}
 	if !vbo.update {
 		return
@@ -146,3 +200,46 @@ func (vbo *VBO) Transfer(gs *GLS) {
 	gs.BufferData(ARRAY_BUFFER, vbo.buffer.Bytes(), &vbo.buffer[0], vbo.usage)
 	vbo.update = false
 }
+
+// setAttribs binds this VBO and sets the pointers of its attributes
+// in the current vertex array object
+func (vbo *VBO) setAttribs(gs *GLS) {
+
+	gs.BindBuffer(ARRAY_BUFFER, vbo.handle)
+	// Calculates stride
+	elsize := int32(unsafe.Sizeof(float32(0)))
+	var stride int32 = 0
+	for _, attrib := range vbo.attribs {
+		stride += elsize * attrib.ItemSize
+	}
+	// For each attribute
+	var offset uint32 = 0
+	vbo.located = true
+	vbo.prog = gs.Prog
+	for _, attrib := range vbo.attribs {
+		size := attrib.ItemSize
+		// Get attribute location in the current program
+		loc := gs.Prog.GetAttribLocation(attrib.Name)
+		if loc >= 0 {
+			// Attributes larger than vec4 use consecutive locations
+			cols := int32(1)
+			csize := size
+			if size > 4 {
+				cols = size / 4
+				csize = 4
+			}
+			// Enables attribute and sets its stride and offset in the buffer
+			for c := int32(0); c < cols; c++ {
+				cloc := uint32(loc + c)
+				gs.EnableVertexAttribArray(cloc)
+				gs.VertexAttribPointer(cloc, csize, FLOAT, false, stride, offset+uint32(c*csize*elsize))
+				if vbo.divisor > 0 {
+					gs.VertexAttribDivisor(cloc, vbo.divisor)
+				}
+			}
+		} else {
+			vbo.located = false
+		}
+		offset += uint32(size * elsize)
+	}
+}
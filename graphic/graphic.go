@@ -21,6 +21,13 @@ type Graphic struct {
 	materials  []GraphicMaterial  // Materials
 	mode       uint32             // OpenGL primitive
 	renderable bool               // Renderable flag
+	cullable   bool               // Cullable flag
+	screen     bool               // Screen space flag
+	castShadow bool               // Cast shadow flag
+	recShadow  bool               // Receive shadow flag
+	instanced  bool               // Drawn as instances flag
+	instances  int                // Number of instances drawn if instanced
+	skeleton   *Skeleton          // Skeleton which deforms the vertices or nil
 }
 
 // GraphicMaterial specifies the material to be used for
@@ -40,6 +47,12 @@ type IGraphic interface {
 	GetGeometry() *geometry.Geometry
 	Renderable() bool
 	SetRenderable(bool)
+	Cullable() bool
+	SetCullable(bool)
+	CastShadow() bool
+	SetCastShadow(bool)
+	ReceiveShadow() bool
+	SetReceiveShadow(bool)
 	RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo)
 }
 
@@ -52,6 +65,9 @@ func (gr *Graphic) Init(igeom geometry.IGeometry, mode uint32) *Graphic {
 	gr.mode = mode
 	gr.materials = make([]GraphicMaterial, 0)
 	gr.renderable = true
+	gr.cullable = true
+	gr.castShadow = false
+	gr.recShadow = false
 	return gr
 }
 
@@ -92,6 +108,79 @@ func (gr *Graphic) Renderable() bool {
 	return gr.renderable
 }
 
+// SetCullable satisfies the IGraphic interface and sets the cullable
+// state of this Graphic (default = true).
+// If not cullable the graphic is always rendered, even if its bounding
+// volume is outside of the camera frustum.
+func (gr *Graphic) SetCullable(state bool) {
+
+	gr.cullable = state
+}
+
+// Cullable satisfies the IGraphic interface and
+// returns the cullable state of this graphic
+func (gr *Graphic) Cullable() bool {
+
+	return gr.cullable
+}
+
+// SetScreenSpace sets if this graphic is positioned in screen coordinates
+// and not affected by the camera, such as GUI panels (default = false).
+// Screen space graphics are not culled nor picked and are rendered after
+// the other graphics in the scene order, parents before children.
+func (gr *Graphic) SetScreenSpace(state bool) {
+
+	gr.screen = state
+}
+
+// ScreenSpace returns if this graphic is positioned in screen coordinates
+func (gr *Graphic) ScreenSpace() bool {
+
+	return gr.screen
+}
+
+// SetCastShadow satisfies the IGraphic interface and sets if this
+// graphic is rendered into the shadow maps of lights which cast shadows (default = false).
+func (gr *Graphic) SetCastShadow(state bool) {
+
+	gr.castShadow = state
+}
+
+// CastShadow satisfies the IGraphic interface and
+// returns the cast shadow state of this graphic
+func (gr *Graphic) CastShadow() bool {
+
+	return gr.castShadow
+}
+
+// SetReceiveShadow satisfies the IGraphic interface and sets if shadows
+// cast by other graphics are rendered over this graphic (default = false).
+func (gr *Graphic) SetReceiveShadow(state bool) {
+
+	gr.recShadow = state
+}
+
+// ReceiveShadow satisfies the IGraphic interface and
+// returns the receive shadow state of this graphic
+func (gr *Graphic) ReceiveShadow() bool {
+
+	return gr.recShadow
+}
+
+// Instanced returns if this graphic is drawn as several instances of its
+// geometry with a single draw call, as done by InstancedMesh.
+func (gr *Graphic) Instanced() bool {
+
+	return gr.instanced
+}
+
+// Skeleton returns the skeleton whose bones deform the vertices
+// of this graphic, as done by SkinnedMesh, or nil.
+func (gr *Graphic) Skeleton() *Skeleton {
+
+	return gr.skeleton
+}
+
 // Add material for the specified subset of vertices.
 // If the material applies to all vertices, start and count must be 0.
 func (gr *Graphic) AddMaterial(igr IGraphic, imat material.IMaterial, start, count int) {
@@ -142,19 +231,62 @@ func (grmat *GraphicMaterial) GetMaterial() material.IMaterial {
 	return grmat.imat
 }
 
+// IGraphic returns the graphic which contains this graphic material
+func (grmat *GraphicMaterial) IGraphic() IGraphic {
+
+	return grmat.igraphic
+}
+
 // Render is called by the renderer to render this graphic material
 func (grmat *GraphicMaterial) Render(gs *gls.GLS, rinfo *core.RenderInfo) {
 
 	// Setup the associated material (set states and transfer material uniforms and textures)
 	grmat.imat.RenderSetup(gs)
 
-	// Setup the associated geometry (set VAO and transfer VBOS)
+	// Lines with Line materials draw the screen-space quads of their segments
+	if lq := grmat.lineQuads(); lq != nil {
+		lq.saveCamera(gs, rinfo)
+		lq.render(gs, rinfo, grmat)
+		return
+	}
+
+	// Computes the tangents used by the material normal map if necessary
 	gr := grmat.igraphic.GetGraphic()
+	geom := gr.igeom.GetGeometry()
+	if _, ok := grmat.imat.GetMaterial().ShaderDefines()["HAS_NORMALMAP"]; ok && geom.VBO("VertexTangent") == nil {
+		geom.ComputeTangents()
+	}
+
+	// Setup the associated geometry (set VAO and transfer VBOS)
 	gr.igeom.RenderSetup(gs)
 
 	// Setup current graphic (transfer matrices)
 	grmat.igraphic.RenderSetup(gs, rinfo)
 
+	grmat.draw(gs)
+}
+
+// RenderDepth is called by the renderer to render only the geometry of
+// this graphic material using the current program, such as when
+// rendering shadow maps. The associated material is not setup.
+func (grmat *GraphicMaterial) RenderDepth(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	// Lines with Line materials are only drawn by programs which expand their quads
+	if lq := grmat.lineQuads(); lq != nil {
+		lq.render(gs, rinfo, grmat)
+		return
+	}
+	gr := grmat.igraphic.GetGraphic()
+	gr.igeom.RenderSetup(gs)
+	grmat.igraphic.RenderSetup(gs, rinfo)
+	grmat.draw(gs)
+}
+
+// draw issues the draw call for the vertices of this graphic material
+func (grmat *GraphicMaterial) draw(gs *gls.GLS) {
+
+	gr := grmat.igraphic.GetGraphic()
+
 	// Get the number of vertices for the current material
 	count := grmat.count
 
@@ -165,12 +297,20 @@ func (grmat *GraphicMaterial) Render(gs *gls.GLS, rinfo *core.RenderInfo) {
 		if count == 0 {
 			count = indices.Size()
 		}
-		gs.DrawElements(gr.mode, int32(count), gls.UNSIGNED_INT, 4*uint32(grmat.start))
+		if gr.instanced {
+			gs.DrawElementsInstanced(gr.mode, int32(count), gls.UNSIGNED_INT, 4*uint32(grmat.start), int32(gr.instances))
+		} else {
+			gs.DrawElements(gr.mode, int32(count), gls.UNSIGNED_INT, 4*uint32(grmat.start))
+		}
 		// Non indexed geometry
 	} else {
 		if count == 0 {
 			count = geom.Items()
 		}
-		gs.DrawArrays(gr.mode, int32(grmat.start), int32(count))
+		if gr.instanced {
+			gs.DrawArraysInstanced(gr.mode, int32(grmat.start), int32(count), int32(gr.instances))
+		} else {
+			gs.DrawArrays(gr.mode, int32(grmat.start), int32(count))
+		}
 	}
 }
@@ -0,0 +1,182 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// Number of floats of the attributes of each instance:
+// the transform matrix followed by the color.
+const instanceStride = 16 + 4
+
+// InstancedMesh is a mesh which draws several instances of its geometry
+// with a single draw call for each of its materials.
+// Each instance has a transform relative to the mesh and a color
+// which multiplies the material color.
+// As the instances may be anywhere relative to the mesh,
+// instanced meshes are not culled by default.
+type InstancedMesh struct {
+	Mesh          // Embedded mesh
+	vbo  *gls.VBO // Instances attributes buffer
+}
+
+// NewInstancedMesh creates and returns a pointer to a new instanced mesh
+// with the specified geometry and material and no instances.
+func NewInstancedMesh(igeom geometry.IGeometry, imat material.IMaterial) *InstancedMesh {
+
+	im := new(InstancedMesh)
+	im.Init(igeom, imat)
+	return im
+}
+
+// Init initializes an InstancedMesh embedded in another type
+func (im *InstancedMesh) Init(igeom geometry.IGeometry, imat material.IMaterial) {
+
+	im.Mesh.Init(igeom, nil)
+	im.instanced = true
+	im.instances = 0
+	im.cullable = false
+
+	im.vbo = gls.NewVBO().
+		AddAttrib("InstanceMatrix", 16).
+		AddAttrib("InstanceColor", 4).
+		SetDivisor(1).
+		SetBuffer(math32.NewArrayF32(0, 0))
+	im.vbo.SetUsage(gls.DYNAMIC_DRAW)
+
+	// Adds single material if not nil
+	if imat != nil {
+		im.AddMaterial(imat, 0, 0)
+	}
+}
+
+// AddMaterial adds a material for the specified subset of vertices
+func (im *InstancedMesh) AddMaterial(imat material.IMaterial, start, count int) {
+
+	im.Graphic.AddMaterial(im, imat, start, count)
+}
+
+// AddGroupMaterial adds a material for the specified geometry group
+func (im *InstancedMesh) AddGroupMaterial(imat material.IMaterial, gindex int) {
+
+	im.Graphic.AddGroupMaterial(im, imat, gindex)
+}
+
+// AddInstance adds an instance with the specified transform relative to
+// the mesh and color and returns its index.
+// If the color is nil the instance is white.
+func (im *InstancedMesh) AddInstance(matrix *math32.Matrix4, color *math32.Color4) int {
+
+	buffer := im.vbo.Buffer()
+	buffer.Append(make([]float32, instanceStride)...)
+	idx := im.instances
+	im.instances++
+	im.SetInstanceMatrix(idx, matrix)
+	if color != nil {
+		im.SetInstanceColor(idx, color)
+	} else {
+		im.SetInstanceColor(idx, &math32.Color4{R: 1, G: 1, B: 1, A: 1})
+	}
+	return idx
+}
+
+// RemoveInstance removes the instance at the specified index.
+// The indices of the following instances are decremented.
+func (im *InstancedMesh) RemoveInstance(idx int) {
+
+	buffer := im.vbo.Buffer()
+	pos := idx * instanceStride
+	copy((*buffer)[pos:], (*buffer)[pos+instanceStride:])
+	*buffer = (*buffer)[:len(*buffer)-instanceStride]
+	im.instances--
+	im.vbo.Update()
+}
+
+// ClearInstances removes all the instances
+func (im *InstancedMesh) ClearInstances() {
+
+	buffer := im.vbo.Buffer()
+	*buffer = (*buffer)[0:0]
+	im.instances = 0
+	im.vbo.Update()
+}
+
+// InstanceCount returns the current number of instances
+func (im *InstancedMesh) InstanceCount() int {
+
+	return im.instances
+}
+
+// SetInstanceMatrix sets the transform relative to the mesh
+// of the instance at the specified index
+func (im *InstancedMesh) SetInstanceMatrix(idx int, matrix *math32.Matrix4) {
+
+	im.vbo.Buffer().Set(idx*instanceStride, matrix[:]...)
+	im.vbo.Update()
+}
+
+// InstanceMatrix returns the transform relative to the mesh
+// of the instance at the specified index
+func (im *InstancedMesh) InstanceMatrix(idx int) math32.Matrix4 {
+
+	var matrix math32.Matrix4
+	pos := idx * instanceStride
+	copy(matrix[:], (*im.vbo.Buffer())[pos:pos+16])
+	return matrix
+}
+
+// SetInstanceColor sets the color of the instance at the specified index
+func (im *InstancedMesh) SetInstanceColor(idx int, color *math32.Color4) {
+
+	im.vbo.Buffer().Set(idx*instanceStride+16, color.R, color.G, color.B, color.A)
+	im.vbo.Update()
+}
+
+// InstanceColor returns the color of the instance at the specified index
+func (im *InstancedMesh) InstanceColor(idx int) math32.Color4 {
+
+	pos := idx*instanceStride + 16
+	buffer := *im.vbo.Buffer()
+	return math32.Color4{R: buffer[pos], G: buffer[pos+1], B: buffer[pos+2], A: buffer[pos+3]}
+}
+
+// RenderSetup is called by the engine before drawing the instances.
+// It transfers the mesh matrices and the instances attributes.
+func (im *InstancedMesh) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	im.Mesh.RenderSetup(gs, rinfo)
+	im.vbo.Transfer(gs)
+}
+
+// Raycast checks intersections between the instances of this mesh and
+// the specified raycaster and if any found appends them to the specified
+// intersects array with the index of the intersected instance.
+func (im *InstancedMesh) Raycast(rc *core.Raycaster, intersects *[]core.Intersect) {
+
+	matrixWorld := im.MatrixWorld()
+	for idx := 0; idx < im.instances; idx++ {
+		instMatrix := im.InstanceMatrix(idx)
+		var matrix math32.Matrix4
+		matrix.MultiplyMatrices(&matrixWorld, &instMatrix)
+		first := len(*intersects)
+		im.raycast(rc, im, &matrix, intersects)
+		for i := first; i < len(*intersects); i++ {
+			(*intersects)[i].Instance = idx
+		}
+	}
+}
+
+// Dispose releases the instances buffer and the resources
+// of the mesh geometry and materials
+func (im *InstancedMesh) Dispose() {
+
+	im.vbo.Dispose()
+	im.Mesh.Dispose()
+}
@@ -0,0 +1,307 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// Number of floats of the attributes of each vertex of the quads
+const lineQuadStride = 3 + 4 + 4 + 3 + 1
+
+// Kinds of the vertices of the quads packed in the LineOther attribute
+const (
+	lineKindStart = 0 // Vertex at the start of a segment
+	lineKindEnd   = 1 // Vertex at the end of a segment
+	lineKindJoin  = 2 // Vertex of the join between two segments
+)
+
+// quadsGraphic is the interface of the graphics whose segments
+// are drawn as screen-space quads with Line materials
+type quadsGraphic interface {
+	lineQuads() *lineQuads
+}
+
+// lineQuads contains the geometry of the screen-space quads of the segments
+// of Lines and LineStrip graphics rendered with Line materials, and of the
+// joins between the consecutive segments of line strips.
+// The geometry is built from the lines geometry and rebuilt when its
+// version changes, as when its VBOs are updated or its indices are set.
+type lineQuads struct {
+	strip     bool               // Consecutive segments are joined
+	geom      *geometry.Geometry // Quads geometry or nil
+	vbo       *gls.VBO           // Quads vertices attributes
+	source    *geometry.Geometry // Lines geometry of the last build
+	version   uint32             // Version of the lines geometry of the last build
+	segments  int                // Number of segments
+	uViewport gls.Uniform2f      // Viewport size uniform
+	uFirst    gls.Uniform1i      // First segment of the material group uniform
+	rinfo     core.RenderInfo    // Camera matrices of the last render
+	height    float32            // Viewport height in pixels of the last render
+}
+
+// init initializes the quads of independent lines or of a line strip
+func (lq *lineQuads) init(strip bool) {
+
+	lq.strip = strip
+	lq.uViewport.Init("Viewport")
+	lq.uFirst.Init("LineFirst")
+}
+
+// lineQuads returns the quads used to draw this graphic material
+// if it is a Line material of a Lines or LineStrip graphic or nil
+func (grmat *GraphicMaterial) lineQuads() *lineQuads {
+
+	if _, ok := grmat.imat.(*material.Line); !ok {
+		return nil
+	}
+	qg, ok := grmat.igraphic.(quadsGraphic)
+	if !ok {
+		return nil
+	}
+	return qg.lineQuads()
+}
+
+// render draws the quads of the segments of the specified graphic material
+func (lq *lineQuads) render(gs *gls.GLS, rinfo *core.RenderInfo, grmat *GraphicMaterial) {
+
+	gr := grmat.igraphic.GetGraphic()
+	lq.update(gr.GetGeometry())
+	if lq.segments == 0 {
+		return
+	}
+	lq.geom.RenderSetup(gs)
+	grmat.igraphic.RenderSetup(gs, rinfo)
+
+	_, _, width, height := gs.GetViewport()
+	lq.uViewport.Set(float32(width), float32(height))
+	lq.uViewport.Transfer(gs)
+
+	// Segments of the material group
+	first := 0
+	count := lq.segments
+	if grmat.count > 0 {
+		if lq.strip {
+			first = grmat.start
+			count = grmat.count - 1
+		} else {
+			first = grmat.start / 2
+			count = grmat.count / 2
+		}
+		if first+count > lq.segments {
+			count = lq.segments - first
+		}
+		if count <= 0 {
+			return
+		}
+	}
+	lq.uFirst.Set(int32(first))
+	lq.uFirst.Transfer(gs)
+
+	// The indices of each segment of strips are followed by the indices
+	// of its join to the next segment, which is not drawn for the last
+	// segment of the group.
+	unit := 6
+	if lq.strip {
+		unit = 12
+	}
+	start := first * unit
+	items := count * unit
+	if lq.strip {
+		items -= 6
+	}
+	gs.DrawElements(gls.TRIANGLES, int32(items), gls.UNSIGNED_INT, 4*uint32(start))
+}
+
+// saveCamera saves the camera matrices and the viewport height of the
+// current render, which are used to convert the width of the lines in
+// pixels to world units when raycasting
+func (lq *lineQuads) saveCamera(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	_, _, _, height := gs.GetViewport()
+	lq.rinfo = *rinfo
+	lq.height = float32(height)
+}
+
+// precision returns the distance in world coordinates from the segment
+// starting at the specified element, at the specified point, which is
+// covered by the width of its Line material when last rendered.
+// Returns false if the segment does not have a Line material or
+// was not rendered yet.
+func (lq *lineQuads) precision(gr *Graphic, elem int, point *math32.Vector3) (float32, bool) {
+
+	if lq.height == 0 {
+		return 0, false
+	}
+	var lm *material.Line
+	for _, grmat := range gr.materials {
+		if grmat.count == 0 || (elem >= grmat.start && elem < grmat.start+grmat.count) {
+			lm, _ = grmat.imat.(*material.Line)
+			break
+		}
+	}
+	if lm == nil {
+		return 0, false
+	}
+	// Size of a pixel in world units at the depth of the point
+	var view math32.Vector3
+	view.Copy(point).ApplyMatrix4(&lq.rinfo.ViewMatrix)
+	proj := &lq.rinfo.ProjMatrix
+	w := proj[11]*view.Z + proj[15]
+	pixel := 2 * w / (proj[5] * lq.height)
+	return lm.Width() * 0.5 * pixel, true
+}
+
+// update rebuilds the quads if the specified lines geometry changed
+func (lq *lineQuads) update(src *geometry.Geometry) {
+
+	version := src.Version()
+	if lq.geom != nil && src == lq.source && version == lq.version {
+		return
+	}
+	lq.source = src
+	lq.version = version
+	positions, pstride, poffset := vboAttrib(src, "VertexPosition")
+	colors, cstride, coffset := vboAttrib(src, "VertexColor")
+	distances, dstride, doffset := vboAttrib(src, "VertexDistance")
+	indices := src.Indices()
+
+	// Vertices of the lines in the order they are drawn
+	var elems []uint32
+	if len(indices) > 0 {
+		elems = indices
+	} else if pstride > 0 {
+		elems = make([]uint32, len(positions)/pstride)
+		for i := range elems {
+			elems[i] = uint32(i)
+		}
+	}
+
+	// Attributes of the vertices of the lines
+	position := func(e uint32) []float32 {
+		pos := int(e)*pstride + poffset
+		return positions[pos : pos+3]
+	}
+	white := []float32{1, 1, 1}
+	color := func(e uint32) []float32 {
+		if cstride == 0 {
+			return white
+		}
+		pos := int(e)*cstride + coffset
+		return colors[pos : pos+3]
+	}
+	// Distances along the lines from their first vertices if not specified
+	dists := make([]float32, len(elems))
+	for i := range elems {
+		if dstride > 0 {
+			dists[i] = distances[int(elems[i])*dstride+doffset]
+			continue
+		}
+		if i == 0 || (!lq.strip && i%2 == 0) {
+			continue
+		}
+		var a, b math32.Vector3
+		a.FromArray(position(elems[i-1]), 0)
+		b.FromArray(position(elems[i]), 0)
+		dists[i] = dists[i-1] + a.DistanceTo(&b)
+	}
+
+	// Builds the quads of the segments and of the joins
+	if lq.strip {
+		lq.segments = len(elems) - 1
+	} else {
+		lq.segments = len(elems) / 2
+	}
+	if lq.segments < 0 {
+		lq.segments = 0
+	}
+	buffer := math32.NewArrayF32(0, lq.segments*8*lineQuadStride)
+	quads := math32.NewArrayU32(0, lq.segments*12)
+	// The corner, kind and cap flag of the vertex are packed in the fourth
+	// element of LineOther and the segment index in the one of LineNeighbor,
+	// so the line attributes fit the locations guaranteed by OpenGL.
+	vertex := func(e uint32, other, neighbor []float32, dist, corner, kind, capFlag float32, seg int) {
+		buffer.Append(position(e)...)
+		buffer.Append(other...)
+		buffer.Append(corner + 1 + 8*kind + 32*capFlag)
+		buffer.Append(neighbor...)
+		buffer.Append(float32(seg))
+		buffer.Append(color(e)...)
+		buffer.Append(dist)
+	}
+	for seg := 0; seg < lq.segments; seg++ {
+		i := 2 * seg
+		if lq.strip {
+			i = seg
+		}
+		a, b := elems[i], elems[i+1]
+		capStart, capEnd := float32(1), float32(1)
+		if lq.strip {
+			if seg > 0 {
+				capStart = 0
+			}
+			if seg < lq.segments-1 {
+				capEnd = 0
+			}
+		}
+		base := uint32(buffer.Size() / lineQuadStride)
+		vertex(a, position(b), position(a), dists[i], -1, lineKindStart, capStart, seg)
+		vertex(a, position(b), position(a), dists[i], 1, lineKindStart, capStart, seg)
+		vertex(b, position(a), position(b), dists[i+1], -1, lineKindEnd, capEnd, seg)
+		vertex(b, position(a), position(b), dists[i+1], 1, lineKindEnd, capEnd, seg)
+		quads.Append(base, base+2, base+3, base, base+3, base+1)
+		if !lq.strip || seg == lq.segments-1 {
+			continue
+		}
+		// Join to the next segment
+		c := elems[i+2]
+		base = uint32(buffer.Size() / lineQuadStride)
+		for corner := 0; corner < 4; corner++ {
+			vertex(b, position(a), position(c), dists[i+1], float32(corner), lineKindJoin, 0, seg)
+		}
+		quads.Append(base, base+1, base+2, base, base+2, base+3)
+	}
+
+	if lq.geom == nil {
+		lq.vbo = gls.NewVBO().
+			AddAttrib("VertexPosition", 3).
+			AddAttrib("LineOther", 4).
+			AddAttrib("LineNeighbor", 4).
+			AddAttrib("VertexColor", 3).
+			AddAttrib("VertexDistance", 1)
+		lq.geom = geometry.NewGeometry()
+		lq.geom.AddVBO(lq.vbo)
+	}
+	lq.vbo.SetBuffer(buffer)
+	lq.vbo.Update()
+	lq.geom.SetIndices(quads)
+}
+
+// dispose releases the quads geometry
+func (lq *lineQuads) dispose() {
+
+	if lq.geom != nil {
+		lq.geom.Dispose()
+		lq.geom = nil
+	}
+	lq.source = nil
+	lq.segments = 0
+}
+
+// vboAttrib returns the buffer of the VBO of the specified geometry which
+// contains the specified attribute, and the stride and offset in floats
+// of the attribute in the buffer. The stride is zero if not found.
+func vboAttrib(geom *geometry.Geometry, name string) (math32.ArrayF32, int, int) {
+
+	vbo := geom.VBO(name)
+	if vbo == nil {
+		return nil, 0, 0
+	}
+	return *vbo.Buffer(), vbo.Stride(), vbo.AttribOffset(name)
+}
@@ -12,9 +12,13 @@ import (
 	"github.com/g3n/engine/math32"
 )
 
+// LineStrip is a Graphic which is rendered as a line through its vertices.
+// With the Line material the line is drawn as screen-space quads
+// with a width in pixels and joins between its segments.
 type LineStrip struct {
 	Graphic
-	mvpm gls.UniformMatrix4f // Model view projection matrix uniform
+	mvpm  gls.UniformMatrix4f // Model view projection matrix uniform
+	quads lineQuads           // Screen-space quads drawn with Line materials
 }
 
 // NewLineStrip creates and returns a pointer to a new LineStrip graphic
@@ -25,9 +29,24 @@ func NewLineStrip(igeom geometry.IGeometry, imat material.IMaterial) *LineStrip
 	l.Graphic.Init(igeom, gls.LINE_STRIP)
 	l.AddMaterial(l, imat, 0, 0)
 	l.mvpm.Init("MVP")
+	l.quads.init(true)
 	return l
 }
 
+// lineQuads satisfies the quadsGraphic interface
+func (l *LineStrip) lineQuads() *lineQuads {
+
+	return &l.quads
+}
+
+// Dispose overrides the embedded Graphic Dispose method
+// and also releases the screen-space quads geometry
+func (l *LineStrip) Dispose() {
+
+	l.quads.dispose()
+	l.Graphic.Dispose()
+}
+
 // RenderSetup is called by the engine before drawing this geometry
 func (l *LineStrip) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 
@@ -41,8 +60,10 @@ func (l *LineStrip) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 }
 
 // Raycast satisfies the INode interface and checks the intersections
-// of this geometry with the specified raycaster
+// of this geometry with the specified raycaster.
+// Lines drawn with the Line material are intersected within their width
+// in pixels when last rendered instead of the raycaster line precision.
 func (l *LineStrip) Raycast(rc *core.Raycaster, intersects *[]core.Intersect) {
 
-	lineRaycast(l, rc, intersects, 1)
+	lineRaycast(l, rc, intersects, 1, &l.quads)
 }
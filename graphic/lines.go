@@ -12,10 +12,13 @@ import (
 	"github.com/g3n/engine/math32"
 )
 
-// Lines is a Graphic which is rendered as a collection of independent lines
+// Lines is a Graphic which is rendered as a collection of independent lines.
+// With the Line material the lines are drawn as screen-space quads
+// with a width in pixels.
 type Lines struct {
 	Graphic
-	mvpm gls.UniformMatrix4f // Model view projection matrix uniform
+	mvpm  gls.UniformMatrix4f // Model view projection matrix uniform
+	quads lineQuads           // Screen-space quads drawn with Line materials
 }
 
 func (l *Lines) Init(igeom geometry.IGeometry, imat material.IMaterial) {
@@ -23,6 +26,7 @@ func (l *Lines) Init(igeom geometry.IGeometry, imat material.IMaterial) {
 	l.Graphic.Init(igeom, gls.LINES)
 	l.AddMaterial(l, imat, 0, 0)
 	l.mvpm.Init("MVP")
+	l.quads.init(false)
 }
 
 func NewLines(igeom geometry.IGeometry, imat material.IMaterial) *Lines {
@@ -32,6 +36,20 @@ func NewLines(igeom geometry.IGeometry, imat material.IMaterial) *Lines {
 	return l
 }
 
+// lineQuads satisfies the quadsGraphic interface
+func (l *Lines) lineQuads() *lineQuads {
+
+	return &l.quads
+}
+
+// Dispose overrides the embedded Graphic Dispose method
+// and also releases the screen-space quads geometry
+func (l *Lines) Dispose() {
+
+	l.quads.dispose()
+	l.Graphic.Dispose()
+}
+
 // RenderSetup is called by the engine before drawing this geometry
 func (l *Lines) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 
@@ -45,14 +63,16 @@ func (l *Lines) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 }
 
 // Raycast satisfies the INode interface and checks the intersections
-// of this geometry with the specified raycaster
+// of this geometry with the specified raycaster.
+// Lines drawn with the Line material are intersected within their width
+// in pixels when last rendered instead of the raycaster line precision.
 func (l *Lines) Raycast(rc *core.Raycaster, intersects *[]core.Intersect) {
 
-	lineRaycast(l, rc, intersects, 2)
+	lineRaycast(l, rc, intersects, 2, &l.quads)
 }
 
 // Internal function used by raycasting for Lines and LineStrip
-func lineRaycast(igr IGraphic, rc *core.Raycaster, intersects *[]core.Intersect, step int) {
+func lineRaycast(igr IGraphic, rc *core.Raycaster, intersects *[]core.Intersect, step int, quads *lineQuads) {
 
 	// Get the bounding sphere
 	gr := igr.GetGraphic()
@@ -82,67 +102,51 @@ func lineRaycast(igr IGraphic, rc *core.Raycaster, intersects *[]core.Intersect,
 	var interRay math32.Vector3
 
 	// Get geometry positions and indices buffers
-	vboPos := geom.VBO("VertexPosition")
-	if vboPos == nil {
+	positions, stride, offset := vboAttrib(geom, "VertexPosition")
+	if stride == 0 {
 		return
 	}
-	positions := vboPos.Buffer()
 	indices := geom.Indices()
 	precisionSq := rc.LinePrecision * rc.LinePrecision
 
-	// Checks intersection with individual lines for indexed geometry
+	// Number of vertices of the lines, which are the
+	// indices of indexed geometries
+	count := len(positions) / stride
 	if indices.Size() > 0 {
-		for i := 0; i < indices.Size()-1; i += step {
-			// Calculates distance from ray to this line segment
-			a := indices[i]
-			b := indices[i+1]
-			positions.GetVector3(int(3*a), &vstart)
-			positions.GetVector3(int(3*b), &vend)
-			distSq := ray.DistanceSqToSegment(&vstart, &vend, &interRay, &interSegment)
-			if distSq > precisionSq {
-				continue
-			}
-			// Move back to world coordinates for distance calculation
-			interRay.ApplyMatrix4(&matrixWorld)
-			origin := rc.Ray.Origin()
-			distance := origin.DistanceTo(&interRay)
-			if distance < rc.Near || distance > rc.Far {
-				continue
-			}
-
-			interSegment.ApplyMatrix4(&matrixWorld)
-			*intersects = append(*intersects, core.Intersect{
-				Distance: distance,
-				Point:    interSegment,
-				Index:    uint32(i),
-				Object:   igr,
-			})
+		count = indices.Size()
+	}
+	for i := 0; i < count-1; i += step {
+		// Calculates distance from ray to this line segment
+		a, b := i, i+1
+		if indices.Size() > 0 {
+			a = int(indices[i])
+			b = int(indices[i+1])
 		}
-		// Checks intersection with individual lines for NON indexed geometry
-	} else {
-		for i := 0; i < positions.Size()/3-1; i += step {
-			positions.GetVector3(int(3*i), &vstart)
-			positions.GetVector3(int(3*i+3), &vend)
-			distSq := ray.DistanceSqToSegment(&vstart, &vend, &interRay, &interSegment)
-			if distSq > precisionSq {
+		vstart.FromArray(positions, a*stride+offset)
+		vend.FromArray(positions, b*stride+offset)
+		distSq := ray.DistanceSqToSegment(&vstart, &vend, &interRay, &interSegment)
+
+		// Move back to world coordinates for distance calculation
+		interRay.ApplyMatrix4(&matrixWorld)
+		interSegment.ApplyMatrix4(&matrixWorld)
+		if precision, ok := quads.precision(gr, i, &interSegment); ok {
+			if interRay.DistanceTo(&interSegment) > precision {
 				continue
 			}
-
-			// Move back to world coordinates for distance calculation
-			interRay.ApplyMatrix4(&matrixWorld)
-			origin := rc.Ray.Origin()
-			distance := origin.DistanceTo(&interRay)
-			if distance < rc.Near || distance > rc.Far {
-				continue
-			}
-
-			interSegment.ApplyMatrix4(&matrixWorld)
-			*intersects = append(*intersects, core.Intersect{
-				Distance: distance,
-				Point:    interSegment,
-				Index:    uint32(i),
-				Object:   igr,
-			})
+		} else if distSq > precisionSq {
+			continue
 		}
+		origin := rc.Ray.Origin()
+		distance := origin.DistanceTo(&interRay)
+		if distance < rc.Near || distance > rc.Far {
+			continue
+		}
+
+		*intersects = append(*intersects, core.Intersect{
+			Distance: distance,
+			Point:    interSegment,
+			Index:    uint32(i),
+			Object:   igr,
+		})
 	}
 }
@@ -0,0 +1,292 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+)
+
+// LOD is a level of detail node which contains several versions of a
+// graphic, each one used from a distance of the camera. The renderer
+// selects the level to render at each frame. The levels may be graphics
+// or nodes with graphics, such as the ones created by the obj and collada
+// loaders. They are not children of the LOD node, but their transforms
+// are relative to it. Raycasting always uses the first, highest detail level.
+// The current level is kept for each camera, so cameras at different
+// distances of the node switch levels independently, up to lodMaxCameras
+// cameras. The camera which rendered the node least recently is forgotten.
+type LOD struct {
+	core.Node              // Embedded node
+	levels     []lodLevel  // Levels sorted by distance
+	cameras    []lodCamera // Current levels of the cameras which rendered this node
+	selects    uint64      // Number of selections, used to find the least recent camera
+	hysteresis float32     // Fraction of the distances used to delay switching levels
+	fadeWidth  float32     // Width of the distance ranges of the cross-fades
+}
+
+// lodMaxCameras is the maximum number of cameras whose current level is kept
+const lodMaxCameras = 8
+
+// lodCamera is the current level of a camera which rendered a LOD node
+type lodCamera struct {
+	icam  core.INode // Camera
+	level int        // Index of the current level
+	last  uint64     // Selection number of the last render by the camera
+}
+
+// lodLevel describes a level of detail of a LOD node
+type lodLevel struct {
+	inode    core.INode // Level node
+	distance float32    // Minimum distance of the camera
+}
+
+// NewLOD creates and returns a pointer to a new level of detail node without levels
+func NewLOD() *LOD {
+
+	lod := new(LOD)
+	lod.Node.Init()
+	lod.levels = make([]lodLevel, 0)
+	return lod
+}
+
+// AddLevel adds a level of detail to this node, used when the distance
+// from the camera to this node is greater or equal than the specified
+// distance and less than the distance of the next level.
+// The first level should be the highest detail one with distance zero.
+func (lod *LOD) AddLevel(inode core.INode, distance float32) {
+
+	pos := len(lod.levels)
+	for i := 0; i < len(lod.levels); i++ {
+		if distance < lod.levels[i].distance {
+			pos = i
+			break
+		}
+	}
+	lod.levels = append(lod.levels, lodLevel{})
+	copy(lod.levels[pos+1:], lod.levels[pos:])
+	lod.levels[pos] = lodLevel{inode, distance}
+	inode.GetNode().SetParent(lod)
+	lod.reset()
+}
+
+// RemoveLevel removes the specified level node from this node.
+// Returns false if the node is not a level of this node.
+func (lod *LOD) RemoveLevel(inode core.INode) bool {
+
+	for i := 0; i < len(lod.levels); i++ {
+		if lod.levels[i].inode == inode {
+			copy(lod.levels[i:], lod.levels[i+1:])
+			lod.levels[len(lod.levels)-1] = lodLevel{}
+			lod.levels = lod.levels[:len(lod.levels)-1]
+			inode.GetNode().SetParent(nil)
+			lod.reset()
+			return true
+		}
+	}
+	return false
+}
+
+// Levels returns the number of levels of detail of this node
+func (lod *LOD) Levels() int {
+
+	return len(lod.levels)
+}
+
+// Level returns the node and the distance of the level with the specified index
+func (lod *LOD) Level(idx int) (core.INode, float32) {
+
+	return lod.levels[idx].inode, lod.levels[idx].distance
+}
+
+// CurrentLevel returns the index of the level selected by the last
+// render with the specified camera or -1 if the camera did not render
+// this node yet (or was forgotten after other cameras rendered it).
+func (lod *LOD) CurrentLevel(icam core.INode) int {
+
+	if c := lod.camera(icam); c != nil {
+		return c.level
+	}
+	return -1
+}
+
+// SetHysteresis sets the fraction of the levels distances by which the
+// camera must move past them to switch levels (default = 0). It avoids
+// switching levels at each frame when the camera is close to a distance.
+func (lod *LOD) SetHysteresis(fraction float32) {
+
+	lod.hysteresis = fraction
+}
+
+// Hysteresis returns the current hysteresis fraction
+func (lod *LOD) Hysteresis() float32 {
+
+	return lod.hysteresis
+}
+
+// SetFadeWidth sets the width of the distance ranges, ending at the
+// levels distances, in which the previous level is dithered out while the
+// next level is dithered in (default = 0). When zero, the levels are
+// switched at once. The hysteresis is not used by the cross-fades.
+func (lod *LOD) SetFadeWidth(width float32) {
+
+	lod.fadeWidth = width
+}
+
+// FadeWidth returns the current width of the cross-fades distance ranges
+func (lod *LOD) FadeWidth() float32 {
+
+	return lod.fadeWidth
+}
+
+// Select selects the levels to render for the specified camera from the
+// distance between the specified camera position in world coordinates and
+// this node, and keeps the current level of the camera.
+// Returns the index of the current level, or -1 if there are no levels,
+// and the index of the next level being faded in, or -1 if not fading,
+// with the visible fraction of the next level.
+// It is normally called by the renderer.
+func (lod *LOD) Select(icam core.INode, camPos *math32.Vector3) (int, int, float32) {
+
+	cur, next, t := lod.choose(icam, camPos)
+	if cur < 0 {
+		return cur, next, t
+	}
+	lod.selects++
+	c := lod.camera(icam)
+	if c == nil {
+		if len(lod.cameras) < lodMaxCameras {
+			lod.cameras = append(lod.cameras, lodCamera{icam: icam})
+			c = &lod.cameras[len(lod.cameras)-1]
+		} else {
+			// Replaces the camera which rendered this node least recently
+			c = &lod.cameras[0]
+			for i := 1; i < len(lod.cameras); i++ {
+				if lod.cameras[i].last < c.last {
+					c = &lod.cameras[i]
+				}
+			}
+			c.icam = icam
+		}
+	}
+	c.level = cur
+	c.last = lod.selects
+	return cur, next, t
+}
+
+// Peek returns the same levels as Select for the specified camera
+// without changing its current level, as used by the picker.
+func (lod *LOD) Peek(icam core.INode, camPos *math32.Vector3) (int, int, float32) {
+
+	return lod.choose(icam, camPos)
+}
+
+// choose returns the levels to render for the specified camera
+// and camera position considering the current level of the camera.
+func (lod *LOD) choose(icam core.INode, camPos *math32.Vector3) (int, int, float32) {
+
+	if len(lod.levels) == 0 {
+		return -1, -1, 0
+	}
+	var pos math32.Vector3
+	lod.WorldPosition(&pos)
+	distance := pos.DistanceTo(camPos)
+
+	// Cross-fades between the level in use and the following level
+	cur := lod.levelAt(distance)
+	if lod.fadeWidth > 0 {
+		if cur+1 < len(lod.levels) {
+			start := lod.levels[cur+1].distance - lod.fadeWidth
+			if distance > start {
+				return cur, cur + 1, (distance - start) / lod.fadeWidth
+			}
+		}
+		return cur, -1, 0
+	}
+
+	// Switches levels only if the distance is past the hysteresis range
+	if c := lod.camera(icam); c != nil && cur != c.level {
+		prev := c.level
+		if cur > prev {
+			if distance < lod.levels[cur].distance*(1+lod.hysteresis) {
+				cur = prev
+			}
+		} else if distance > lod.levels[prev].distance*(1-lod.hysteresis) {
+			cur = prev
+		}
+	}
+	return cur, -1, 0
+}
+
+// camera returns the current level of the specified camera or nil if not found
+func (lod *LOD) camera(icam core.INode) *lodCamera {
+
+	for i := range lod.cameras {
+		if lod.cameras[i].icam == icam {
+			return &lod.cameras[i]
+		}
+	}
+	return nil
+}
+
+// reset clears the current levels after the levels are changed
+func (lod *LOD) reset() {
+
+	lod.cameras = lod.cameras[0:0]
+}
+
+// levelAt returns the index of the level used at the specified distance
+func (lod *LOD) levelAt(distance float32) int {
+
+	cur := 0
+	for i := 1; i < len(lod.levels); i++ {
+		if distance < lod.levels[i].distance {
+			break
+		}
+		cur = i
+	}
+	return cur
+}
+
+// UpdateMatrixWorld updates the world transform matrices
+// of this node, of its children and of its levels
+func (lod *LOD) UpdateMatrixWorld() {
+
+	lod.Node.UpdateMatrixWorld()
+	for i := 0; i < len(lod.levels); i++ {
+		lod.levels[i].inode.UpdateMatrixWorld()
+	}
+}
+
+// Raycast checks intersections between the specified raycaster
+// and the highest detail level of this node and its children
+func (lod *LOD) Raycast(rc *core.Raycaster, intersects *[]core.Intersect) {
+
+	if len(lod.levels) == 0 {
+		return
+	}
+	var raycast func(inode core.INode)
+	raycast = func(inode core.INode) {
+		node := inode.GetNode()
+		if !node.Visible() {
+			return
+		}
+		if node.InLayers(rc.Layers) {
+			inode.Raycast(rc, intersects)
+		}
+		for _, ichild := range node.Children() {
+			raycast(ichild)
+		}
+	}
+	raycast(lod.levels[0].inode)
+}
+
+// Dispose disposes the levels of this node
+func (lod *LOD) Dispose() {
+
+	for i := 0; i < len(lod.levels); i++ {
+		lod.levels[i].inode.Dispose()
+	}
+}
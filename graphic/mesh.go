@@ -12,11 +12,30 @@ import (
 	"github.com/g3n/engine/math32"
 )
 
+// MaxMorphTargets is the maximum number of morph targets of a mesh geometry
+// which are blended in the shaders
+const MaxMorphTargets = 64
+
+// MorphTargetsMax returns the size of the shaders morph weights array for a
+// geometry with the specified number of morph targets. It is rounded up so
+// geometries with similar numbers of targets share the same programs.
+func MorphTargetsMax(targets int) int {
+
+	if targets > MaxMorphTargets {
+		targets = MaxMorphTargets
+	}
+	return (targets + 7) / 8 * 8
+}
+
 type Mesh struct {
-	Graphic                     // Embedded graphic
-	mvm     gls.UniformMatrix4f // Model view matrix uniform
-	mvpm    gls.UniformMatrix4f // Model view projection matrix uniform
-	nm      gls.UniformMatrix3f // Normal matrix uniform
+	Graphic                           // Embedded graphic
+	mvm           gls.UniformMatrix4f // Model view matrix uniform
+	mvpm          gls.UniformMatrix4f // Model view projection matrix uniform
+	nm            gls.UniformMatrix3f // Normal matrix uniform
+	morphWeights  []float32           // Weights of the geometry morph targets
+	uMorphTex     gls.Uniform1i       // Morph targets texture unit uniform
+	uMorphCount   gls.Uniform1i       // Number of vertices of the morph targets uniform
+	uMorphWeights gls.Uniform1fv      // Morph targets weights uniform
 }
 
 // NewMesh creates and returns a pointer to a mesh with the specified geometry and material
@@ -37,6 +56,9 @@ func (m *Mesh) Init(igeom geometry.IGeometry, imat material.IMaterial) {
 	m.mvm.Init("ModelViewMatrix")
 	m.mvpm.Init("MVP")
 	m.nm.Init("NormalMatrix")
+	m.uMorphTex.Init("MorphTexture")
+	m.uMorphCount.Init("MorphVertexCount")
+	m.uMorphWeights.Init("MorphWeights", 0)
 
 	// Adds single material if not nil
 	if imat != nil {
@@ -55,9 +77,61 @@ func (m *Mesh) AddGroupMaterial(imat material.IMaterial, gindex int) {
 	m.Graphic.AddGroupMaterial(m, imat, gindex)
 }
 
+// SetMorphWeight sets the weight of the morph target of the mesh geometry
+// with the specified index. The vertices are blended between the geometry
+// base positions and normals and the ones of the targets with non zero
+// weights (default = 0).
+func (m *Mesh) SetMorphWeight(idx int, weight float32) {
+
+	if idx >= len(m.morphWeights) {
+		weights := make([]float32, idx+1)
+		copy(weights, m.morphWeights)
+		m.morphWeights = weights
+	}
+	m.morphWeights[idx] = weight
+}
+
+// MorphWeight returns the current weight of the morph
+// target of the mesh geometry with the specified index
+func (m *Mesh) MorphWeight(idx int) float32 {
+
+	if idx >= len(m.morphWeights) {
+		return 0
+	}
+	return m.morphWeights[idx]
+}
+
+// SetMorphWeightByName sets the weight of the morph target of the mesh
+// geometry with the specified name and returns false if not found
+func (m *Mesh) SetMorphWeightByName(name string, weight float32) bool {
+
+	idx := m.GetGeometry().MorphTargetIndex(name)
+	if idx < 0 {
+		return false
+	}
+	m.SetMorphWeight(idx, weight)
+	return true
+}
+
+// SetMorphWeights sets the weights of the morph targets
+// of the mesh geometry from the specified slice
+func (m *Mesh) SetMorphWeights(weights []float32) {
+
+	m.morphWeights = append(m.morphWeights[:0], weights...)
+}
+
+// MorphWeights returns a copy of the current weights
+// of the morph targets of the mesh geometry
+func (m *Mesh) MorphWeights() []float32 {
+
+	weights := make([]float32, len(m.morphWeights))
+	copy(weights, m.morphWeights)
+	return weights
+}
+
 // RenderSetup is called by the engine before drawing the mesh geometry
 // It is responsible to updating the current shader uniforms with
-// the model matrices.
+// the model matrices and the morph targets weights.
 func (m *Mesh) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 
 	// Calculates model view matrix and updates uniform
@@ -78,17 +152,47 @@ func (m *Mesh) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 	nm.GetNormalMatrix(&mvm)
 	m.nm.SetMatrix3(&nm)
 	m.nm.Transfer(gs)
+
+	// Binds the texture with the geometry morph targets and updates their weights
+	geom := m.GetGeometry()
+	tex := geom.MorphTexture()
+	if tex == nil {
+		return
+	}
+	tex.Bind(gs, rinfo.TextureUnit)
+	m.uMorphTex.Set(int32(rinfo.TextureUnit))
+	m.uMorphTex.Transfer(gs)
+	m.uMorphCount.Set(int32(geom.MorphVertexCount()))
+	m.uMorphCount.Transfer(gs)
+	// All the weights of the shader array are sent, as its uniform is shared
+	// by the meshes drawn with the same program. Targets without weights and
+	// the padding of the array have zero weights.
+	count := MorphTargetsMax(geom.MorphTargetCount())
+	m.uMorphWeights.SetCount(count)
+	for i := 0; i < count; i++ {
+		m.uMorphWeights.Set(i, m.MorphWeight(i))
+	}
+	m.uMorphWeights.Transfer(gs)
 }
 
 // Raycast checks intersections between this geometry and the specified raycaster
 // and if any found appends it to the specified intersects array.
 func (m *Mesh) Raycast(rc *core.Raycaster, intersects *[]core.Intersect) {
 
+	matrixWorld := m.MatrixWorld()
+	m.raycast(rc, m, &matrixWorld, intersects)
+}
+
+// raycast checks intersections between the raycaster and this mesh
+// geometry transformed by the specified matrix and appends the
+// intersections found with the specified object to intersects.
+func (m *Mesh) raycast(rc *core.Raycaster, object core.INode, matrix *math32.Matrix4, intersects *[]core.Intersect) {
+
 	// Transform this mesh geometry bounding sphere from model
 	// to world coordinates and checks intersection with raycaster
 	geom := m.GetGeometry()
 	sphere := geom.BoundingSphere()
-	matrixWorld := m.MatrixWorld()
+	matrixWorld := *matrix
 	sphere.ApplyMatrix4(&matrixWorld)
 	if !rc.IsIntersectionSphere(&sphere) {
 		return
@@ -140,7 +244,7 @@ func (m *Mesh) Raycast(rc *core.Raycaster, intersects *[]core.Intersect) {
 		return &core.Intersect{
 			Distance: distance,
 			Point:    intersectionPointWorld,
-			Object:   m,
+			Object:   object,
 		}
 	}
 
@@ -0,0 +1,88 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+)
+
+// Skeleton is a list of bones which deform the vertices of skinned meshes.
+// The bones are nodes of the scene, normally organized in a hierarchy,
+// which are transformed or animated as any other node.
+// Each bone has an inverse bind matrix which transforms the vertices
+// from the mesh coordinates in the bind pose to the bone coordinates.
+// A skeleton may be shared by several skinned meshes.
+type Skeleton struct {
+	bones       []core.INode     // Bone nodes
+	inverseBind []math32.Matrix4 // Inverse bind matrices of the bones
+}
+
+// NewSkeleton creates and returns a pointer to a new skeleton without bones
+func NewSkeleton() *Skeleton {
+
+	sk := new(Skeleton)
+	sk.bones = make([]core.INode, 0)
+	sk.inverseBind = make([]math32.Matrix4, 0)
+	return sk
+}
+
+// AddBone appends the specified bone node with the specified inverse bind
+// matrix to this skeleton and returns its index, used by the VertexJoints
+// attribute of the skinned meshes geometries. If the matrix is nil, it is
+// the inverse of the current world matrix of the bone, which should be
+// in its bind pose.
+func (sk *Skeleton) AddBone(bone core.INode, inverseBind *math32.Matrix4) int {
+
+	var m math32.Matrix4
+	if inverseBind != nil {
+		m = *inverseBind
+	} else {
+		bone.UpdateMatrixWorld()
+		mw := bone.GetNode().MatrixWorld()
+		m.GetInverse(&mw, false)
+	}
+	sk.bones = append(sk.bones, bone)
+	sk.inverseBind = append(sk.inverseBind, m)
+	return len(sk.bones) - 1
+}
+
+// BoneCount returns the current number of bones of this skeleton
+func (sk *Skeleton) BoneCount() int {
+
+	return len(sk.bones)
+}
+
+// Bone returns the bone node with the specified index
+func (sk *Skeleton) Bone(idx int) core.INode {
+
+	return sk.bones[idx]
+}
+
+// BoneIndex returns the index of the specified bone node or -1 if not found
+func (sk *Skeleton) BoneIndex(bone core.INode) int {
+
+	for i := 0; i < len(sk.bones); i++ {
+		if sk.bones[i] == bone {
+			return i
+		}
+	}
+	return -1
+}
+
+// InverseBindMatrix returns the inverse bind matrix of the bone with the specified index
+func (sk *Skeleton) InverseBindMatrix(idx int) math32.Matrix4 {
+
+	return sk.inverseBind[idx]
+}
+
+// BoneMatrix sets the specified matrix to the transform of the bone with the
+// specified index from its bind pose to its current pose, in world coordinates.
+// The bone world matrix must be updated.
+func (sk *Skeleton) BoneMatrix(idx int, m *math32.Matrix4) {
+
+	mw := sk.bones[idx].GetNode().MatrixWorld()
+	m.MultiplyMatrices(&mw, &sk.inverseBind[idx])
+}
@@ -0,0 +1,117 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphic
+
+import (
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// MaxBones is the maximum number of bones of the skeleton of a skinned mesh.
+// The bone matrices are an array of uniforms of the vertex shader, so their
+// 16 components each plus the other uniforms must fit in the 1024 vertex
+// uniform components guaranteed by OpenGL 3.3.
+const MaxBones = 60
+
+// SkinnedMesh is a mesh whose vertices are deformed by the bones of a
+// skeleton in the vertex shader. Its geometry must have, besides the usual
+// attributes, the "VertexJoints" attribute with the indices in the skeleton
+// of up to four bones which influence each vertex and the "VertexWeights"
+// attribute with the weights of these bones, whose sum should be one.
+// Raycasting uses the vertices in the bind pose and, as the bones may move
+// the vertices anywhere, skinned meshes are not culled by default.
+type SkinnedMesh struct {
+	Mesh                            // Embedded mesh
+	bindMatrix math32.Matrix4       // Bind shape matrix
+	uBones     gls.UniformMatrix4fv // Bone matrices uniform
+}
+
+// NewSkinnedMesh creates and returns a pointer to a new skinned mesh
+// with the specified geometry and material and without skeleton.
+func NewSkinnedMesh(igeom geometry.IGeometry, imat material.IMaterial) *SkinnedMesh {
+
+	sm := new(SkinnedMesh)
+	sm.Init(igeom, imat)
+	return sm
+}
+
+// Init initializes a SkinnedMesh embedded in another type
+func (sm *SkinnedMesh) Init(igeom geometry.IGeometry, imat material.IMaterial) {
+
+	sm.Mesh.Init(igeom, nil)
+	sm.cullable = false
+	sm.bindMatrix.Identity()
+	sm.uBones.Init("BoneMatrices", 0)
+
+	// Adds single material if not nil
+	if imat != nil {
+		sm.AddMaterial(imat, 0, 0)
+	}
+}
+
+// AddMaterial adds a material for the specified subset of vertices
+func (sm *SkinnedMesh) AddMaterial(imat material.IMaterial, start, count int) {
+
+	sm.Graphic.AddMaterial(sm, imat, start, count)
+}
+
+// AddGroupMaterial adds a material for the specified geometry group
+func (sm *SkinnedMesh) AddGroupMaterial(imat material.IMaterial, gindex int) {
+
+	sm.Graphic.AddGroupMaterial(sm, imat, gindex)
+}
+
+// SetSkeleton sets the skeleton whose bones deform this mesh.
+// A nil skeleton renders the mesh in its bind pose.
+// Only the first MaxBones bones of the skeleton are used.
+func (sm *SkinnedMesh) SetSkeleton(sk *Skeleton) {
+
+	sm.skeleton = sk
+}
+
+// SetBindMatrix sets the bind shape matrix, which transforms the vertices
+// of the geometry to the mesh coordinates of the bind pose before skinning
+// (default = identity).
+func (sm *SkinnedMesh) SetBindMatrix(m *math32.Matrix4) {
+
+	sm.bindMatrix = *m
+}
+
+// BindMatrix returns the current bind shape matrix
+func (sm *SkinnedMesh) BindMatrix() math32.Matrix4 {
+
+	return sm.bindMatrix
+}
+
+// RenderSetup is called by the engine before drawing the mesh geometry.
+// It transfers the mesh matrices and the matrices of the skeleton bones,
+// which transform the vertices from the bind pose to the current pose
+// in the mesh coordinates.
+func (sm *SkinnedMesh) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
+
+	sm.Mesh.RenderSetup(gs, rinfo)
+	if sm.skeleton == nil {
+		return
+	}
+	count := sm.skeleton.BoneCount()
+	if count > MaxBones {
+		count = MaxBones
+	}
+	mw := sm.MatrixWorld()
+	var invWorld math32.Matrix4
+	invWorld.GetInverse(&mw, false)
+	sm.uBones.SetCount(count)
+	for i := 0; i < count; i++ {
+		var bm, m math32.Matrix4
+		sm.skeleton.BoneMatrix(i, &bm)
+		m.MultiplyMatrices(&invWorld, &bm)
+		m.Multiply(&sm.bindMatrix)
+		sm.uBones.SetMatrix4(i, &m)
+	}
+	sm.uBones.Transfer(gs)
+}
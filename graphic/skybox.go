@@ -13,67 +13,102 @@ import (
 	"github.com/g3n/engine/texture"
 )
 
+// SkyboxData contains the names of the image files of the skybox faces,
+// which are DirAndPrefix + Suffixes[i] + "." + Extension, in the order:
+// +X, -X, +Y, -Y, +Z, -Z.
 type SkyboxData struct {
 	DirAndPrefix string
 	Extension    string
 	Suffixes     [6]string
 }
 
+// Skybox is a graphic which draws a cube map texture around the camera at
+// infinite depth, behind all the other graphics of the scene.
+// It rotates with the camera and its own rotation but does not move.
 type Skybox struct {
-	Graphic                     // embedded graphic object
-	mvm     gls.UniformMatrix4f // model view matrix uniform
-	mvpm    gls.UniformMatrix4f // model view projection matrix uniform
-	nm      gls.UniformMatrix3f // normal matrix uniform
+	Graphic                      // embedded graphic object
+	cube    *texture.TextureCube // cube map texture
+	mvpm    gls.UniformMatrix4f  // model view projection matrix uniform
+	uCube   gls.Uniform1i        // cube map texture unit uniform
 }
 
-// NewSkybox creates and returns a pointer to a skybox with the specified textures
+// NewSkybox creates and returns a pointer to a skybox with a cube map
+// texture loaded from the specified image files
 func NewSkybox(data SkyboxData) (*Skybox, error) {
 
+	var files [6]string
+	for i := 0; i < 6; i++ {
+		files[i] = data.DirAndPrefix + data.Suffixes[i] + "." + data.Extension
+	}
+	cube, err := texture.NewTextureCubeFromImages(files)
+	if err != nil {
+		return nil, err
+	}
+	return NewSkyboxCube(cube), nil
+}
+
+// NewSkyboxCube creates and returns a pointer to a skybox
+// with the specified cube map texture
+func NewSkyboxCube(cube *texture.TextureCube) *Skybox {
+
 	skybox := new(Skybox)
 
-	geom := geometry.NewBox(50, 50, 50, 1, 1, 1)
+	geom := geometry.NewBox(2, 2, 2, 1, 1, 1)
 	skybox.Graphic.Init(geom, gls.TRIANGLES)
+	// The skybox follows the camera and must never be frustum culled
+	skybox.SetCullable(false)
+
+	// The inner faces of the box are drawn at the far plane without
+	// writing the depth buffer, so the other graphics are drawn over them.
+	mat := material.NewMaterial()
+	mat.SetShader("shaderSkybox")
+	mat.SetSide(material.SideBack)
+	mat.SetDepthMask(false)
+	mat.SetBlending(material.BlendingNone)
+	skybox.AddMaterial(skybox, mat, 0, 0)
+
+	skybox.cube = cube
+	skybox.mvpm.Init("MVP")
+	skybox.uCube.Init("SkyboxCube")
+	return skybox
+}
 
-	for i := 0; i < 6; i++ {
-		tex, err := texture.NewTexture2DFromImage(data.DirAndPrefix + data.Suffixes[i] + "." + data.Extension)
-		if err != nil {
-			return nil, err
-		}
-		matFace := material.NewStandard(math32.NewColor(1, 1, 1))
-		matFace.AddTexture(tex)
-		matFace.SetSide(material.SideBack)
-		matFace.SetUseLights(material.UseLightAmbient)
-		skybox.AddGroupMaterial(skybox, matFace, i)
-	}
+// SetCube sets the cube map texture of this skybox
+func (skybox *Skybox) SetCube(cube *texture.TextureCube) {
 
-	// Creates uniforms
-	skybox.mvm.Init("ModelViewMatrix")
-	skybox.mvpm.Init("MVP")
-	skybox.nm.Init("NormalMatrix")
+	skybox.cube = cube
+}
+
+// Cube returns the cube map texture of this skybox
+func (skybox *Skybox) Cube() *texture.TextureCube {
+
+	return skybox.cube
+}
+
+// Dispose overrides the embedded Graphic Dispose method
+// and also releases the cube map texture
+func (skybox *Skybox) Dispose() {
 
-	return skybox, nil
+	skybox.Graphic.Dispose()
+	if skybox.cube != nil {
+		skybox.cube.Dispose()
+	}
 }
 
 // RenderSetup is called by the engine before drawing the skybox geometry
 // It is responsible to updating the current shader uniforms with
-// the model matrices.
+// the model matrices and binding the cube map texture.
 func (skybox *Skybox) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 
-	// TODO
-	// Disable writes to the depth buffer (call glDepthMask(GL_FALSE)).
-	// This will cause every other object to draw over the skybox, making it always appear "behind" everything else.
-	// Since writes to the depth buffer are off, it doesn't matter how small the skybox is as long as it's larger than the camera's near clip plane.
-
-	var mvm math32.Matrix4
+	// Rotates the box by the view and world rotations ignoring the translations
+	var mvm, rot math32.Matrix4
+	mw := skybox.MatrixWorld()
+	rot.Identity().ExtractRotation(&mw)
 	mvm.Copy(&rinfo.ViewMatrix)
-
-	// Clear translation
 	mvm[12] = 0
 	mvm[13] = 0
 	mvm[14] = 0
-	// mvm.ExtractRotation(&rinfo.ViewMatrix) // TODO <- ExtractRotation does not work as expected?
-	skybox.mvm.SetMatrix4(&mvm)
-	skybox.mvm.Transfer(gs)
+	mvm.Multiply(&rot)
 
 	// Calculates model view projection matrix and updates uniform
 	var mvpm math32.Matrix4
@@ -81,10 +116,10 @@ func (skybox *Skybox) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo) {
 	skybox.mvpm.SetMatrix4(&mvpm)
 	skybox.mvpm.Transfer(gs)
 
-	// Calculates normal matrix and updates uniform
-	var nm math32.Matrix3
-	nm.GetNormalMatrix(&mvm)
-	skybox.nm.SetMatrix3(&nm)
-	skybox.nm.Transfer(gs)
-
+	// The material has no textures so the cube map uses the first unit
+	if skybox.cube != nil {
+		skybox.cube.Bind(gs, 0)
+		skybox.uCube.Set(0)
+		skybox.uCube.Transfer(gs)
+	}
 }
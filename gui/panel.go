@@ -124,6 +124,11 @@ func (p *Panel) Initialize(width, height float32) {
 	// Initialize graphic
 	p.Graphic.Init(geom, gls.TRIANGLES)
 	p.AddMaterial(p, p.mat, 0, 0)
+	// Panels are positioned in screen coordinates and are not
+	// affected by the camera, so they must never be frustum culled
+	// and are rendered after the scene in their original order.
+	p.SetCullable(false)
+	p.SetScreenSpace(true)
 
 	// Creates and adds uniform
 	p.modelMatrixUni.Init("ModelMatrix")
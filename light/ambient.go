@@ -6,15 +6,13 @@ package light
 
 import (
 	"github.com/g3n/engine/core"
-	"github.com/g3n/engine/gls"
 	"github.com/g3n/engine/math32"
 )
 
 type Ambient struct {
-	core.Node               // Embedded node
-	color     math32.Color  // Light color
-	intensity float32       // Light intensity
-	uColor    gls.Uniform3f // Light color uniform (color * intensity)
+	core.Node              // Embedded node
+	color     math32.Color // Light color
+	intensity float32      // Light intensity
 }
 
 // NewAmbient returns a pointer to a new ambient color with the specified
@@ -26,8 +24,6 @@ func NewAmbient(color *math32.Color, intensity float32) *Ambient {
 
 	la.color = *color
 	la.intensity = intensity
-	la.uColor.Init("AmbientLightColor")
-	la.SetColor(color)
 	return la
 }
 
@@ -35,9 +31,6 @@ func NewAmbient(color *math32.Color, intensity float32) *Ambient {
 func (la *Ambient) SetColor(color *math32.Color) {
 
 	la.color = *color
-	tmpColor := la.color
-	tmpColor.MultiplyScalar(la.intensity)
-	la.uColor.SetColor(&tmpColor)
 }
 
 // Color returns the current color of this light
@@ -50,9 +43,6 @@ func (la *Ambient) Color() math32.Color {
 func (la *Ambient) SetIntensity(intensity float32) {
 
 	la.intensity = intensity
-	tmpColor := la.color
-	tmpColor.MultiplyScalar(la.intensity)
-	la.uColor.SetColor(&tmpColor)
 }
 
 // Intensity returns the current intensity of this light
@@ -62,7 +52,9 @@ func (la *Ambient) Intensity() float32 {
 }
 
 // RenderSetup is called by the engine before rendering the scene
-func (la *Ambient) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo, idx int) {
+func (la *Ambient) RenderSetup(block *Block, rinfo *core.RenderInfo, idx int) {
 
-	la.uColor.TransferIdx(gs, idx)
+	color := la.color
+	color.MultiplyScalar(la.intensity)
+	block.setAmbient(idx, &color)
 }
@@ -0,0 +1,139 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// Maximum number of lights of each type in the lights uniform block.
+// They must be equal to the sizes of the arrays of the Lights
+// uniform block declared by the "lights" shader chunk.
+const (
+	MaxAmbientLights = 8
+	MaxDirLights     = 8
+	MaxPointLights   = 16
+	MaxSpotLights    = 16
+)
+
+// Offsets in floats of the arrays of the lights uniform block.
+// All the members are vec4 following the std140 layout.
+const (
+	offsetCounts       = 0
+	offsetAmbientColor = offsetCounts + 4
+	offsetDirColor     = offsetAmbientColor + MaxAmbientLights*4
+	offsetDirPosition  = offsetDirColor + MaxDirLights*4
+	offsetPointColor   = offsetDirPosition + MaxDirLights*4
+	offsetPointPos     = offsetPointColor + MaxPointLights*4
+	offsetPointDecay   = offsetPointPos + MaxPointLights*4
+	offsetSpotColor    = offsetPointDecay + MaxPointLights*4
+	offsetSpotPos      = offsetSpotColor + MaxSpotLights*4
+	offsetSpotDir      = offsetSpotPos + MaxSpotLights*4
+	offsetSpotParams   = offsetSpotDir + MaxSpotLights*4
+	blockSize          = offsetSpotParams + MaxSpotLights*4
+)
+
+// Block contains the data of the lights of a scene laid out as the Lights
+// uniform block of the shaders. It is set by the lights RenderSetup and
+// transferred once for all the programs before rendering the scene.
+// The positions and directions of the lights are in camera coordinates.
+type Block struct {
+	ubo *gls.UBO // Uniform buffer object with the lights data
+}
+
+// NewBlock creates and returns a pointer to a new lights uniform
+// block data for the specified uniform block binding point
+func NewBlock(binding uint32) *Block {
+
+	b := new(Block)
+	b.ubo = gls.NewUBO(binding, blockSize)
+	return b
+}
+
+// SetCounts sets the number of lights of each type set in this block.
+// Counts greater than the maximum number of lights of a type are clamped.
+func (b *Block) SetCounts(ambient, dir, point, spot int) {
+
+	b.ubo.Buffer().Set(offsetCounts,
+		float32(clampCount(ambient, MaxAmbientLights)),
+		float32(clampCount(dir, MaxDirLights)),
+		float32(clampCount(point, MaxPointLights)),
+		float32(clampCount(spot, MaxSpotLights)),
+	)
+	b.ubo.Update()
+}
+
+// Transfer transfers the data of this block to OpenGL and
+// binds it to its uniform block binding point
+func (b *Block) Transfer(gs *gls.GLS) {
+
+	b.ubo.Transfer(gs)
+}
+
+// Dispose releases the OpenGL resources of this block
+func (b *Block) Dispose() {
+
+	b.ubo.Dispose()
+}
+
+// setAmbient sets the data of the ambient light at the specified index
+func (b *Block) setAmbient(idx int, color *math32.Color) {
+
+	if idx >= MaxAmbientLights {
+		return
+	}
+	buf := b.ubo.Buffer()
+	buf.Set(offsetAmbientColor+idx*4, color.R, color.G, color.B, 0)
+	b.ubo.Update()
+}
+
+// setDirectional sets the data of the directional light at the specified index
+func (b *Block) setDirectional(idx int, color *math32.Color, dir *math32.Vector3) {
+
+	if idx >= MaxDirLights {
+		return
+	}
+	buf := b.ubo.Buffer()
+	buf.Set(offsetDirColor+idx*4, color.R, color.G, color.B, 0)
+	buf.Set(offsetDirPosition+idx*4, dir.X, dir.Y, dir.Z, 0)
+	b.ubo.Update()
+}
+
+// setPoint sets the data of the point light at the specified index
+func (b *Block) setPoint(idx int, color *math32.Color, pos *math32.Vector3, linear, quadratic float32) {
+
+	if idx >= MaxPointLights {
+		return
+	}
+	buf := b.ubo.Buffer()
+	buf.Set(offsetPointColor+idx*4, color.R, color.G, color.B, 0)
+	buf.Set(offsetPointPos+idx*4, pos.X, pos.Y, pos.Z, 1)
+	buf.Set(offsetPointDecay+idx*4, linear, quadratic, 0, 0)
+	b.ubo.Update()
+}
+
+// setSpot sets the data of the spot light at the specified index
+func (b *Block) setSpot(idx int, color *math32.Color, pos, dir *math32.Vector3, angularDecay, cutoff, linear, quadratic float32) {
+
+	if idx >= MaxSpotLights {
+		return
+	}
+	buf := b.ubo.Buffer()
+	buf.Set(offsetSpotColor+idx*4, color.R, color.G, color.B, 0)
+	buf.Set(offsetSpotPos+idx*4, pos.X, pos.Y, pos.Z, 1)
+	buf.Set(offsetSpotDir+idx*4, dir.X, dir.Y, dir.Z, 0)
+	buf.Set(offsetSpotParams+idx*4, angularDecay, cutoff, linear, quadratic)
+	b.ubo.Update()
+}
+
+// clampCount returns the specified lights count limited to the specified maximum
+func clampCount(count, max int) int {
+
+	if count > max {
+		return max
+	}
+	return count
+}
@@ -6,16 +6,14 @@ package light
 
 import (
 	"github.com/g3n/engine/core"
-	"github.com/g3n/engine/gls"
 	"github.com/g3n/engine/math32"
 )
 
 type Directional struct {
-	core.Node                // Embedded node
-	color      math32.Color  // Light color
-	intensity  float32       // Light intensity
-	uColor     gls.Uniform3f // Light color uniform (color * intensity)
-	uDirection gls.Uniform3f // Light direction uniform
+	core.Node              // Embedded node
+	color     math32.Color // Light color
+	intensity float32      // Light intensity
+	shadow    Shadow       // Shadow parameters
 }
 
 func NewDirectional(color *math32.Color, intensity float32) *Directional {
@@ -25,9 +23,7 @@ func NewDirectional(color *math32.Color, intensity float32) *Directional {
 
 	ld.color = *color
 	ld.intensity = intensity
-	ld.uColor.Init("DirLightColor")
-	ld.uDirection.Init("DirLightPosition")
-	ld.SetColor(color)
+	ld.shadow.Init()
 	return ld
 }
 
@@ -35,9 +31,6 @@ func NewDirectional(color *math32.Color, intensity float32) *Directional {
 func (ld *Directional) SetColor(color *math32.Color) {
 
 	ld.color = *color
-	tmpColor := ld.color
-	tmpColor.MultiplyScalar(ld.intensity)
-	ld.uColor.SetColor(&tmpColor)
 }
 
 // Color returns the current color of this light
@@ -50,9 +43,6 @@ func (ld *Directional) Color() math32.Color {
 func (ld *Directional) SetIntensity(intensity float32) {
 
 	ld.intensity = intensity
-	tmpColor := ld.color
-	tmpColor.MultiplyScalar(ld.intensity)
-	ld.uColor.SetColor(&tmpColor)
 }
 
 // Intensity returns the current intensity of this light
@@ -61,17 +51,36 @@ func (ld *Directional) Intensity() float32 {
 	return ld.intensity
 }
 
+// Shadow returns a pointer to the shadow parameters of this light
+func (ld *Directional) Shadow() *Shadow {
+
+	return &ld.shadow
+}
+
+// ShadowMatrices sets the specified matrices with the view and the
+// orthographic projection matrices used to render the shadow map of this light.
+// The shadow camera is located at the light world position looking at the origin.
+func (ld *Directional) ShadowMatrices(view, proj *math32.Matrix4) {
+
+	var pos math32.Vector3
+	ld.WorldPosition(&pos)
+	dir := pos
+	dir.Negate()
+	lookAt(view, &pos, &dir)
+	half := ld.shadow.size / 2
+	proj.MakeOrthographic(-half, half, half, -half, ld.shadow.near, ld.shadow.far)
+}
+
 // RenderSetup is called by the engine before rendering the scene
-func (ld *Directional) RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo, idx int) {
+func (ld *Directional) RenderSetup(block *Block, rinfo *core.RenderInfo, idx int) {
 
-	// Sets color
-	ld.uColor.TransferIdx(gs, idx)
+	color := ld.color
+	color.MultiplyScalar(ld.intensity)
 
-	// Calculates and updates light direction uniform in camera coordinates
+	// Calculates the light direction in camera coordinates
 	var pos math32.Vector3
 	ld.WorldPosition(&pos)
 	pos4 := math32.Vector4{pos.X, pos.Y, pos.Z, 0.0}
 	pos4.ApplyMatrix4(&rinfo.ViewMatrix)
-	ld.uDirection.SetVector3(&math32.Vector3{pos4.X, pos4.Y, pos4.Z})
-	ld.uDirection.TransferIdx(gs, idx)
+	block.setDirectional(idx, &color, &math32.Vector3{pos4.X, pos4.Y, pos4.Z})
 }
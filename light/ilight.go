@@ -6,10 +6,11 @@ package light
 
 import (
 	"github.com/g3n/engine/core"
-	"github.com/g3n/engine/gls"
 )
 
 // ILight is the interface that must be implemented for all light types.
+// RenderSetup sets the light data at the specified index of its type
+// in the lights uniform block shared by all the programs.
 type ILight interface {
-	RenderSetup(gs *gls.GLS, rinfo *core.RenderInfo, idx int)
+	RenderSetup(block *Block, rinfo *core.RenderInfo, idx int)
 }
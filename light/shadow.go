@@ -0,0 +1,145 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"github.com/g3n/engine/math32"
+)
+
+// ShadowFilter specifies how the shadow map of a light is sampled
+type ShadowFilter int
+
+// Shadow map filters
+const (
+	ShadowFilterNone    ShadowFilter = 0 // Single hardware compared sample
+	ShadowFilterPCF     ShadowFilter = 1 // Percentage closer filtering with 3x3 samples
+	ShadowFilterPCFSoft ShadowFilter = 2 // Percentage closer filtering with 5x5 samples
+)
+
+// Shadow contains the shadow mapping parameters of a light
+type Shadow struct {
+	castShadow bool         // Cast shadow flag
+	mapWidth   int          // Shadow map width in texels
+	mapHeight  int          // Shadow map height in texels
+	bias       float32      // Depth bias used to avoid shadow acne
+	filter     ShadowFilter // Shadow map filter
+	near       float32      // Near plane distance of the shadow camera
+	far        float32      // Far plane distance of the shadow camera
+	size       float32      // Size of the orthographic shadow camera of directional lights
+}
+
+// IShadowCaster is the interface for lights which can cast shadows
+type IShadowCaster interface {
+	ILight
+	Shadow() *Shadow
+	ShadowMatrices(view, proj *math32.Matrix4)
+}
+
+// Init initializes the shadow parameters with default values
+func (s *Shadow) Init() {
+
+	s.castShadow = false
+	s.mapWidth = 1024
+	s.mapHeight = 1024
+	s.bias = 0.002
+	s.filter = ShadowFilterPCF
+	s.near = 0.5
+	s.far = 500
+	s.size = 10
+}
+
+// SetCastShadow sets if the light casts shadows (default = false)
+func (s *Shadow) SetCastShadow(state bool) {
+
+	s.castShadow = state
+}
+
+// CastShadow returns if the light casts shadows
+func (s *Shadow) CastShadow() bool {
+
+	return s.castShadow
+}
+
+// SetMapSize sets the width and height in texels of the shadow map
+func (s *Shadow) SetMapSize(width, height int) {
+
+	s.mapWidth = width
+	s.mapHeight = height
+}
+
+// MapSize returns the width and height in texels of the shadow map
+func (s *Shadow) MapSize() (int, int) {
+
+	return s.mapWidth, s.mapHeight
+}
+
+// SetBias sets the depth bias subtracted from the fragment depth
+// before comparing it with the shadow map depth
+func (s *Shadow) SetBias(bias float32) {
+
+	s.bias = bias
+}
+
+// Bias returns the current shadow depth bias
+func (s *Shadow) Bias() float32 {
+
+	return s.bias
+}
+
+// SetFilter sets the shadow map filter
+func (s *Shadow) SetFilter(filter ShadowFilter) {
+
+	s.filter = filter
+}
+
+// Filter returns the current shadow map filter
+func (s *Shadow) Filter() ShadowFilter {
+
+	return s.filter
+}
+
+// SetNearFar sets the near and far planes distances of the shadow camera
+func (s *Shadow) SetNearFar(near, far float32) {
+
+	s.near = near
+	s.far = far
+}
+
+// NearFar returns the near and far planes distances of the shadow camera
+func (s *Shadow) NearFar() (float32, float32) {
+
+	return s.near, s.far
+}
+
+// SetSize sets the width and height of the area covered by the
+// orthographic shadow camera of directional lights
+func (s *Shadow) SetSize(size float32) {
+
+	s.size = size
+}
+
+// Size returns the size of the area covered by the orthographic
+// shadow camera of directional lights
+func (s *Shadow) Size() float32 {
+
+	return s.size
+}
+
+// lookAt sets the specified view matrix to look from the eye
+// position along the specified direction.
+func lookAt(m *math32.Matrix4, eye, direction *math32.Vector3) {
+
+	var up math32.Vector3
+	up.Set(0, 1, 0)
+	dir := *direction
+	dir.Normalize()
+	// Chooses another up vector if the direction is parallel to the Y axis
+	if math32.Abs(dir.Y) > 0.999 {
+		up.Set(0, 0, 1)
+	}
+	var target math32.Vector3
+	target.AddVectors(eye, &dir)
+	m.LookAt(eye, &target, &up)
+}
@@ -26,8 +26,9 @@ type AnimationTarget struct {
 
 // A ChannelInstance associates an animation parameter channel to an interpolation sampler
 type ChannelInstance struct {
-	sampler *SamplerInstance
-	action  ActionFunc
+	sampler   *SamplerInstance
+	action    ActionFunc
+	transform bool // Channel of the transform matrix of the target
 }
 
 // SamplerInstance specifies the input key frames, output values for these key frames
@@ -47,7 +48,21 @@ type ActionFunc func(at *AnimationTarget, v float32)
 func (at *AnimationTarget) Reset() {
 
 	at.last = at.start
-	at.target.GetNode().SetMatrix(&at.matrix)
+	at.setMatrix(&at.matrix)
+}
+
+// setMatrix sets the position, rotation and scale of
+// the target node from the specified transform matrix
+func (at *AnimationTarget) setMatrix(m *math32.Matrix4) {
+
+	var position math32.Vector3
+	var quaternion math32.Quaternion
+	var scale math32.Vector3
+	m.Decompose(&position, &quaternion, &scale)
+	node := at.target.GetNode()
+	node.SetPositionVec(&position)
+	node.SetQuaternionQuat(&quaternion)
+	node.SetScaleVec(&scale)
 }
 
 // SetLoop sets the state of the animation loop flag
@@ -83,6 +98,15 @@ func (at *AnimationTarget) Update(delta float32) bool {
 
 	for i := 0; i < len(at.channels); i++ {
 		ch := at.channels[i]
+		// Sets the interpolated transform matrix
+		if ch.transform {
+			var m math32.Matrix4
+			if !ch.sampler.InterpolateMatrix(at.last, &m) {
+				return false
+			}
+			at.setMatrix(&m)
+			continue
+		}
 		// Get interpolated value
 		v, ok := ch.sampler.Interpolate(at.last)
 		if !ok {
@@ -103,9 +127,23 @@ func (d *Decoder) NewAnimationTargets(scene core.INode) (map[string]*AnimationTa
 
 	// Maps target node to its animation target instance
 	targetsMap := make(map[string]*AnimationTarget)
+	if d.dom.LibraryAnimations == nil {
+		return targetsMap, nil
+	}
+
+	// Appends the animations and their children animations to the list
+	animations := make([]*Animation, 0)
+	var addAnimations func(list []*Animation)
+	addAnimations = func(list []*Animation) {
+		for _, ca := range list {
+			animations = append(animations, ca)
+			addAnimations(ca.Animation)
+		}
+	}
+	addAnimations(d.dom.LibraryAnimations.Animation)
 
 	// For each Collada animation element
-	for _, ca := range d.dom.LibraryAnimations.Animation {
+	for _, ca := range animations {
 
 		// For each Collada channel for this animation
 		for _, cc := range ca.Channel {
@@ -142,6 +180,7 @@ func (d *Decoder) NewAnimationTargets(scene core.INode) (map[string]*AnimationTa
 
 			// Sets the action function from the target action
 			var af ActionFunc
+			transform := false
 			switch targetAction {
 			case "location.X":
 				af = actionPositionX
@@ -162,12 +201,17 @@ func (d *Decoder) NewAnimationTargets(scene core.INode) (map[string]*AnimationTa
 			case "scale.Z":
 				af = actionScaleZ
 			default:
-				return nil, fmt.Errorf("Unsupported channel target action:%s", targetAction)
+				// Channels of the transform matrix, such as the ones of the
+				// skeleton joints, have 16 output values for each input.
+				if len(si.Input) == 0 || len(si.Output) != 16*len(si.Input) {
+					return nil, fmt.Errorf("Unsupported channel target action:%s", targetAction)
+				}
+				transform = true
 			}
 
 			// Creates the channel instance for this sampler and target action and adds it
 			// to the current AnimationTarget
-			ci := &ChannelInstance{si, af}
+			ci := &ChannelInstance{si, af, transform}
 			at.channels = append(at.channels, ci)
 		}
 	}
@@ -346,6 +390,56 @@ func (si *SamplerInstance) Interpolate(inp float32) (float32, bool) {
 	return 0, false
 }
 
+// InterpolateMatrix sets the specified matrix to the interpolated transform
+// matrix of this sampler of 4x4 matrices for the specified input and returns
+// its validity. The position, rotation and scale of the key frames matrices
+// are interpolated linearly.
+func (si *SamplerInstance) InterpolateMatrix(inp float32, m *math32.Matrix4) bool {
+
+	// Test limits
+	if len(si.Input) < 2 || len(si.Output) < 16*len(si.Input) {
+		return false
+	}
+	if inp < si.Input[0] || inp > si.Input[len(si.Input)-1] {
+		return false
+	}
+
+	// Find key frame interval
+	var idx int
+	for idx = 0; idx < len(si.Input)-1; idx++ {
+		if inp >= si.Input[idx] && inp < si.Input[idx+1] {
+			break
+		}
+	}
+	// Checks if interval was found
+	if idx >= len(si.Input)-1 {
+		return false
+	}
+
+	// Decomposes the key frames matrices, transposing
+	// them from row major to column major order
+	var p [2]math32.Vector3
+	var q [2]math32.Quaternion
+	var s [2]math32.Vector3
+	for i := 0; i < 2; i++ {
+		var data [16]float32
+		copy(data[:], si.Output[16*(idx+i):])
+		var km math32.Matrix4
+		km.FromArray(data)
+		km.Transpose()
+		km.Decompose(&p[i], &q[i], &s[i])
+	}
+	t := (inp - si.Input[idx]) / (si.Input[idx+1] - si.Input[idx])
+	if len(si.Interp) > idx && si.Interp[idx] == "STEP" {
+		t = 0
+	}
+	p[0].Lerp(&p[1], t)
+	q[0].Slerp(&q[1], t)
+	s[0].Lerp(&s[1], t)
+	m.Compose(&p[0], &q[0], &s[0])
+	return true
+}
+
 func (si *SamplerInstance) linearInterp(inp float32, idx int) float32 {
 
 	k1 := si.Input[idx]
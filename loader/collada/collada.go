@@ -7,6 +7,7 @@ package collada
 import (
 	"encoding/xml"
 	"fmt"
+	"github.com/g3n/engine/core"
 	"github.com/g3n/engine/geometry"
 	"github.com/g3n/engine/material"
 	"github.com/g3n/engine/texture"
@@ -23,11 +24,16 @@ type Decoder struct {
 	geometries map[string]geomInstance       // Instanced geometries by id
 	materials  map[string]material.IMaterial // Instanced materials by id
 	tex2D      map[string]*texture.Texture2D // Instanced textures 2D by id
+	nodes      map[*Node]core.INode          // Nodes created by the last NewScene
+	skins      []skinInstance                // Skinned meshes created by the last NewScene
 }
 
 type geomInstance struct {
-	geom  geometry.IGeometry
-	ptype uint32
+	geom    geometry.IGeometry
+	ptype   uint32
+	vindex  []int     // Index in the Collada mesh of the position of each vertex
+	nindex  []int     // Index in the Collada mesh of the normal of each vertex or -1
+	weights []float32 // Initial weights of the morph targets
 }
 
 // Decode decodes the specified collada file returning a decoder object and an error.
@@ -76,6 +82,7 @@ type Collada struct {
 	LibraryEffects      *LibraryEffects
 	LibraryMaterials    *LibraryMaterials
 	LibraryGeometries   *LibraryGeometries
+	LibraryControllers  *LibraryControllers
 	LibraryVisualScenes *LibraryVisualScenes
 	Scene               *Scene
 }
@@ -94,6 +101,7 @@ func (d *Decoder) Dump(out io.Writer, indent int) {
 	d.dom.LibraryEffects.Dump(out, indent+step)
 	d.dom.LibraryMaterials.Dump(out, indent+step)
 	d.dom.LibraryGeometries.Dump(out, indent+step)
+	d.dom.LibraryControllers.Dump(out, indent+step)
 	d.dom.LibraryVisualScenes.Dump(out, indent+step)
 	d.dom.Scene.Dump(out, indent+step)
 }
@@ -267,6 +275,13 @@ func (d *Decoder) decCollada(dom *Collada) error {
 			}
 			continue
 		}
+		if start.Name.Local == "library_controllers" {
+			err = d.decLibraryControllers(start, dom)
+			if err != nil {
+				break
+			}
+			continue
+		}
 		if start.Name.Local == "library_visual_scenes" {
 			err = d.decLibraryVisualScenes(start, dom)
 			if err != nil {
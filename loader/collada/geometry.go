@@ -26,19 +26,32 @@ func (d *Decoder) GetGeometry(id string) (geometry.IGeometry, uint32, error) {
 	}
 
 	// Creates geometry and saves it associated with its id
-	geom, ptype, err := d.NewGeometry(id)
+	ginst, err := d.newGeometry(id)
 	if err != nil {
 		return nil, 0, err
 	}
-	d.geometries[id] = geomInstance{geom, ptype}
+	d.geometries[id] = ginst
 
-	return geom, ptype, nil
+	return ginst.geom, ginst.ptype, nil
 }
 
 // NewGeometry creates and returns a pointer to a new instance of the geometry
 // with the specified id in the Collada document, its primitive type and and error.
 func (d *Decoder) NewGeometry(id string) (geometry.IGeometry, uint32, error) {
 
+	ginst, err := d.newGeometry(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ginst.geom, ginst.ptype, nil
+}
+
+// newGeometry creates a new instance of the geometry with the specified id
+// in the Collada document and returns it with its primitive type and the
+// index of the Collada position and normal of each of its vertices, used
+// by skins and morphs.
+func (d *Decoder) newGeometry(id string) (geomInstance, error) {
+
 	id = strings.TrimPrefix(id, "#")
 	// Look for geometry with specified id in the dom
 	var geo *Geometry
@@ -49,7 +62,7 @@ func (d *Decoder) NewGeometry(id string) (geometry.IGeometry, uint32, error) {
 		}
 	}
 	if geo == nil {
-		return nil, 0, fmt.Errorf("Geometry:%s not found", id)
+		return geomInstance{}, fmt.Errorf("Geometry:%s not found", id)
 	}
 
 	// Geometry type
@@ -57,18 +70,23 @@ func (d *Decoder) NewGeometry(id string) (geometry.IGeometry, uint32, error) {
 	// Collada mesh category includes points, lines, linestrips, triangles,
 	// triangle fans, triangle strips and polygons.
 	case *Mesh:
-		return newMesh(gt)
+		var ginst geomInstance
+		var err error
+		ginst.geom, ginst.ptype, err = newMesh(gt, &ginst.vindex, &ginst.nindex)
+		return ginst, err
 		// B-Spline
 		// Bezier
 		// NURBS
 		// Patch
 	default:
-		return nil, 0, fmt.Errorf("GeometryElement:%T not supported", gt)
+		return geomInstance{}, fmt.Errorf("GeometryElement:%T not supported", gt)
 	}
-	return nil, 0, nil
 }
 
-func newMesh(m *Mesh) (*geometry.Geometry, uint32, error) {
+// newMesh creates a geometry from the specified Collada mesh and sets the
+// specified slices to the index of the position and normal of each vertex
+// if known.
+func newMesh(m *Mesh, vindex, nindex *[]int) (*geometry.Geometry, uint32, error) {
 
 	// If no primitive elements present, it is a mesh of points
 	if len(m.PrimitiveElements) == 0 {
@@ -91,7 +109,7 @@ func newMesh(m *Mesh) (*geometry.Geometry, uint32, error) {
 	pei := m.PrimitiveElements[0]
 	switch pet := pei.(type) {
 	case *Polylist:
-		return newMeshPolylist(m, m.PrimitiveElements)
+		return newMeshPolylist(m, m.PrimitiveElements, vindex, nindex)
 	case *Triangles:
 		return newMeshTriangles(m, pet)
 	case *Lines:
@@ -107,9 +125,10 @@ func newMesh(m *Mesh) (*geometry.Geometry, uint32, error) {
 	}
 }
 
-// Creates a geometry from a polylist
+// Creates a geometry from a polylist and appends the index of the position
+// and of the normal (or -1) of each vertex to the specified slices.
 // Only triangles are supported
-func newMeshPolylist(m *Mesh, pels []interface{}) (*geometry.Geometry, uint32, error) {
+func newMeshPolylist(m *Mesh, pels []interface{}, vindex, nindex *[]int) (*geometry.Geometry, uint32, error) {
 
 	// Get vertices positions
 	if len(m.Vertices.Input) != 1 {
@@ -215,9 +234,10 @@ func newMeshPolylist(m *Mesh, pels []interface{}) (*geometry.Geometry, uint32, e
 			vx[2] = posArray.Data[posIndex+2]
 
 			// Optional vertex normal
+			normIndex := -3
 			if inpNormal != nil {
 				// Get normal index from P
-				normIndex := pl.P[i+inpNormal.Offset] * 3
+				normIndex = pl.P[i+inpNormal.Offset] * 3
 				// Get normal vector and appends to its buffer
 				vx[3] = normArray.Data[normIndex]
 				vx[4] = normArray.Data[normIndex+1]
@@ -249,6 +269,8 @@ func newMeshPolylist(m *Mesh, pels []interface{}) (*geometry.Geometry, uint32, e
 			if inpTexcoord != nil {
 				uvs.Append(vx[6], vx[7])
 			}
+			*vindex = append(*vindex, posIndex/3)
+			*nindex = append(*nindex, normIndex/3)
 			indices.Append(index)
 			// Save the index to this vertex position and attributes for
 			// future reuse
@@ -105,7 +105,7 @@ func (d *Decoder) decLibraryAnimations(start xml.StartElement, dom *Collada) err
 			return err
 		}
 		if child.Name.Local == "animation" {
-			err := d.decAnimation(child, la)
+			err := d.decAnimation(child, &la.Animation)
 			if err != nil {
 				return err
 			}
@@ -115,10 +115,10 @@ func (d *Decoder) decLibraryAnimations(start xml.StartElement, dom *Collada) err
 	return nil
 }
 
-func (d *Decoder) decAnimation(start xml.StartElement, la *LibraryAnimations) error {
+func (d *Decoder) decAnimation(start xml.StartElement, parent *[]*Animation) error {
 
 	anim := new(Animation)
-	la.Animation = append(la.Animation, anim)
+	*parent = append(*parent, anim)
 	anim.Id = findAttrib(start, "id").Value
 	anim.Name = findAttrib(start, "name").Value
 
@@ -149,6 +149,14 @@ func (d *Decoder) decAnimation(start xml.StartElement, la *LibraryAnimations) er
 			}
 			continue
 		}
+		// Decodes child animation recursively
+		if child.Name.Local == "animation" {
+			err = d.decAnimation(child, &anim.Animation)
+			if err != nil {
+				return err
+			}
+			continue
+		}
 	}
 	return nil
 }
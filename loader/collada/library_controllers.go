@@ -0,0 +1,322 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package collada
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Library Controllers
+type LibraryControllers struct {
+	Asset      *Asset
+	Controller []*Controller
+}
+
+func (lc *LibraryControllers) Dump(out io.Writer, indent int) {
+
+	if lc == nil {
+		return
+	}
+	fmt.Fprintf(out, "%sLibraryControllers:\n", sIndent(indent))
+	ind := indent + step
+	if lc.Asset != nil {
+		lc.Asset.Dump(out, ind)
+	}
+	for _, c := range lc.Controller {
+		c.Dump(out, ind)
+	}
+}
+
+// Controller
+type Controller struct {
+	Id    string // Controller id (optional)
+	Name  string // Controller name (optional)
+	Skin  *Skin  // Skin controller
+	Morph *Morph // Morph controller
+}
+
+func (c *Controller) Dump(out io.Writer, indent int) {
+
+	fmt.Fprintf(out, "%sController id:%s name:%s\n", sIndent(indent), c.Id, c.Name)
+	if c.Skin != nil {
+		c.Skin.Dump(out, indent+step)
+	}
+	if c.Morph != nil {
+		c.Morph.Dump(out, indent+step)
+	}
+}
+
+// Skin
+type Skin struct {
+	Source          string      // URL of the base geometry
+	BindShapeMatrix [16]float32 // Bind shape matrix in row major order
+	Sources         []*Source   // Joints, inverse bind matrices and weights sources
+	Joints          []Input     // JOINT and INV_BIND_MATRIX inputs
+	VertexWeights   VertexWeights
+}
+
+func (s *Skin) Dump(out io.Writer, indent int) {
+
+	fmt.Fprintf(out, "%sSkin source:%s\n", sIndent(indent), s.Source)
+	ind := indent + step
+	fmt.Fprintf(out, "%sBindShapeMatrix:%v\n", sIndent(ind), s.BindShapeMatrix)
+	for _, src := range s.Sources {
+		src.Dump(out, ind)
+	}
+	fmt.Fprintf(out, "%sJoints\n", sIndent(ind))
+	for _, inp := range s.Joints {
+		inp.Dump(out, ind+step)
+	}
+	s.VertexWeights.Dump(out, ind)
+}
+
+// Morph
+type Morph struct {
+	Source  string    // URL of the base geometry
+	Method  string    // NORMALIZED or RELATIVE
+	Sources []*Source // Targets and weights sources
+	Targets []Input   // MORPH_TARGET and MORPH_WEIGHT inputs
+}
+
+func (m *Morph) Dump(out io.Writer, indent int) {
+
+	fmt.Fprintf(out, "%sMorph source:%s method:%s\n", sIndent(indent), m.Source, m.Method)
+	ind := indent + step
+	for _, src := range m.Sources {
+		src.Dump(out, ind)
+	}
+	fmt.Fprintf(out, "%sTargets\n", sIndent(ind))
+	for _, inp := range m.Targets {
+		inp.Dump(out, ind+step)
+	}
+}
+
+// VertexWeights
+type VertexWeights struct {
+	Count  int
+	Input  []InputShared // JOINT and WEIGHT inputs
+	Vcount []int         // Number of joints of each vertex
+	V      []int         // Indices of the joints and weights of each vertex
+}
+
+func (vw *VertexWeights) Dump(out io.Writer, indent int) {
+
+	fmt.Fprintf(out, "%sVertexWeights count:%d\n", sIndent(indent), vw.Count)
+	ind := indent + step
+	for _, is := range vw.Input {
+		is.Dump(out, ind)
+	}
+	fmt.Fprintf(out, "%sVcount(%d):%v\n", sIndent(ind), len(vw.Vcount), intsToString(vw.Vcount, 20))
+	fmt.Fprintf(out, "%sV(%d):%v\n", sIndent(ind), len(vw.V), intsToString(vw.V, 20))
+}
+
+func (d *Decoder) decLibraryControllers(start xml.StartElement, dom *Collada) error {
+
+	lc := new(LibraryControllers)
+	dom.LibraryControllers = lc
+	for {
+		// Get next child element
+		child, _, err := d.decNextChild(start)
+		if err != nil || child.Name.Local == "" {
+			return err
+		}
+		// Decodes controller
+		if child.Name.Local == "controller" {
+			err = d.decController(child, lc)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+	}
+}
+
+func (d *Decoder) decController(start xml.StartElement, lc *LibraryControllers) error {
+
+	c := new(Controller)
+	c.Id = findAttrib(start, "id").Value
+	c.Name = findAttrib(start, "name").Value
+	lc.Controller = append(lc.Controller, c)
+
+	for {
+		// Get next child element
+		child, _, err := d.decNextChild(start)
+		if err != nil || child.Name.Local == "" {
+			return err
+		}
+		if child.Name.Local == "skin" {
+			err = d.decSkin(child, c)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if child.Name.Local == "morph" {
+			err = d.decMorph(child, c)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+	}
+}
+
+func (d *Decoder) decSkin(start xml.StartElement, c *Controller) error {
+
+	skin := new(Skin)
+	skin.Source = findAttrib(start, "source").Value
+	skin.BindShapeMatrix = [16]float32{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+	c.Skin = skin
+
+	for {
+		// Get next child element
+		child, data, err := d.decNextChild(start)
+		if err != nil || child.Name.Local == "" {
+			return err
+		}
+		if child.Name.Local == "bind_shape_matrix" {
+			err = decFloat32Sequence(data, skin.BindShapeMatrix[0:16])
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if child.Name.Local == "source" {
+			source, err := d.decSource(child)
+			if err != nil {
+				return err
+			}
+			skin.Sources = append(skin.Sources, source)
+			continue
+		}
+		if child.Name.Local == "joints" {
+			err = d.decJoints(child, skin)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if child.Name.Local == "vertex_weights" {
+			err = d.decVertexWeights(child, skin)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+	}
+}
+
+func (d *Decoder) decJoints(start xml.StartElement, skin *Skin) error {
+
+	for {
+		// Get next child element
+		child, _, err := d.decNextChild(start)
+		if err != nil || child.Name.Local == "" {
+			return err
+		}
+		if child.Name.Local == "input" {
+			inp, err := d.decInput(child)
+			if err != nil {
+				return err
+			}
+			skin.Joints = append(skin.Joints, inp)
+			continue
+		}
+	}
+}
+
+func (d *Decoder) decMorph(start xml.StartElement, c *Controller) error {
+
+	morph := new(Morph)
+	morph.Source = findAttrib(start, "source").Value
+	morph.Method = findAttrib(start, "method").Value
+	if morph.Method == "" {
+		morph.Method = "NORMALIZED"
+	}
+	c.Morph = morph
+
+	for {
+		// Get next child element
+		child, _, err := d.decNextChild(start)
+		if err != nil || child.Name.Local == "" {
+			return err
+		}
+		if child.Name.Local == "source" {
+			source, err := d.decSource(child)
+			if err != nil {
+				return err
+			}
+			morph.Sources = append(morph.Sources, source)
+			continue
+		}
+		if child.Name.Local == "targets" {
+			err = d.decTargets(child, morph)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+	}
+}
+
+func (d *Decoder) decTargets(start xml.StartElement, morph *Morph) error {
+
+	for {
+		// Get next child element
+		child, _, err := d.decNextChild(start)
+		if err != nil || child.Name.Local == "" {
+			return err
+		}
+		if child.Name.Local == "input" {
+			inp, err := d.decInput(child)
+			if err != nil {
+				return err
+			}
+			morph.Targets = append(morph.Targets, inp)
+			continue
+		}
+	}
+}
+
+func (d *Decoder) decVertexWeights(start xml.StartElement, skin *Skin) error {
+
+	vw := &skin.VertexWeights
+	vw.Count, _ = strconv.Atoi(findAttrib(start, "count").Value)
+
+	for {
+		// Get next child element
+		child, data, err := d.decNextChild(start)
+		if err != nil || child.Name.Local == "" {
+			return err
+		}
+		if child.Name.Local == "input" {
+			inp, err := d.decInputShared(child)
+			if err != nil {
+				return err
+			}
+			vw.Input = append(vw.Input, inp)
+			continue
+		}
+		if child.Name.Local == "vcount" {
+			vc, err := d.decVcount(child, data, vw.Count)
+			if err != nil {
+				return err
+			}
+			vw.Vcount = vc
+			continue
+		}
+		if child.Name.Local == "v" {
+			v, err := d.decPrimitive(child, data)
+			if err != nil {
+				return err
+			}
+			vw.V = v
+			continue
+		}
+	}
+}
@@ -67,6 +67,24 @@ type ProfileCOMMON struct {
 		Sid           string
 		Asset         *Asset
 		ShaderElement interface{} // Blinn|Constant|Lambert|Phong
+		Bump          *Bump       // Bump from the technique extra or nil
+	}
+}
+
+//
+// Bump is the bump or normal map texture which some exporters
+// add to the extra element of the profile COMMON technique
+//
+type Bump struct {
+	Bumptype string // NORMALMAP|HEIGHTFIELD or empty
+	Texture  interface{}
+}
+
+func (b *Bump) Dump(out io.Writer, indent int) {
+
+	fmt.Fprintf(out, "%sBump bumptype:%s\n", sIndent(indent), b.Bumptype)
+	if t, ok := b.Texture.(*Texture); ok {
+		t.Dump(out, indent+step)
 	}
 }
 
@@ -86,6 +104,9 @@ func (pc *ProfileCOMMON) Dump(out io.Writer, indent int) {
 		sh.Dump(out, ind)
 		break
 	}
+	if pc.Technique.Bump != nil {
+		pc.Technique.Bump.Dump(out, ind)
+	}
 }
 
 //
@@ -476,10 +497,39 @@ func (d *Decoder) decProfileCommonTechnique(start xml.StartElement, pc *ProfileC
 			}
 			continue
 		}
+		if child.Name.Local == "extra" {
+			err := d.decTechniqueExtra(child, pc)
+			if err != nil {
+				return err
+			}
+			continue
+		}
 	}
 	return nil
 }
 
+// decTechniqueExtra decodes the bump element from the techniques
+// of any profile of the extra element of the profile COMMON technique
+func (d *Decoder) decTechniqueExtra(start xml.StartElement, pc *ProfileCOMMON) error {
+
+	for {
+		// Returns the descendant elements of <extra>
+		child, _, err := d.decNextChild(start)
+		if err != nil || child.Name.Local == "" {
+			return err
+		}
+		if child.Name.Local == "bump" {
+			b := new(Bump)
+			b.Bumptype = findAttrib(child, "bumptype").Value
+			err := d.decColorOrTexture(child, &b.Texture)
+			if err != nil {
+				return err
+			}
+			pc.Technique.Bump = b
+		}
+	}
+}
+
 func (d *Decoder) decBlinn(start xml.StartElement, pc *ProfileCOMMON) error {
 
 	bl := new(Blinn)
@@ -64,7 +64,7 @@ func (d *Decoder) NewMaterial(id string) (material.IMaterial, error) {
 	case *Lambert:
 		return d.newLambertMaterial(se)
 	case *Phong:
-		return d.newPhongMaterial(se)
+		return d.newPhongMaterial(se, pc.Technique.Bump)
 	default:
 		return nil, fmt.Errorf("Invalid shader element")
 	}
@@ -146,6 +146,19 @@ func (d *Decoder) NewTexture2D(id string) (*texture.Texture2D, error) {
 	return tex, nil
 }
 
+// newMapTexture creates and returns a new texture for a material map from
+// the specified color or texture element of an effect or nil if it is not
+// a texture. The maps of a material must be different texture objects,
+// so the textures are not shared as the ones returned by GetTexture2D.
+func (d *Decoder) newMapTexture(ci interface{}) (*texture.Texture2D, error) {
+
+	tex, ok := ci.(*Texture)
+	if !ok {
+		return nil, nil
+	}
+	return d.NewTexture2D(tex.Texture)
+}
+
 func (d *Decoder) newBlinnMaterial(se *Blinn) (material.IMaterial, error) {
 
 	return nil, fmt.Errorf("Not implemented")
@@ -161,7 +174,7 @@ func (d *Decoder) newLambertMaterial(se *Lambert) (material.IMaterial, error) {
 	return nil, fmt.Errorf("Not implemented")
 }
 
-func (d *Decoder) newPhongMaterial(se *Phong) (material.IMaterial, error) {
+func (d *Decoder) newPhongMaterial(se *Phong, bump *Bump) (material.IMaterial, error) {
 
 	// Creates material with default color
 	m := material.NewPhong(&math32.Color{0.5, 0.5, 0.5})
@@ -182,23 +195,64 @@ func (d *Decoder) newPhongMaterial(se *Phong) (material.IMaterial, error) {
 		if err != nil {
 			return nil, err
 		}
-		// Add texture to this material
-		m.AddTexture(tex2D)
+		// Set texture as the diffuse map of this material
+		m.SetDiffuseMap(tex2D)
 	}
 
+	// If "emission" is Texture sets it as the emissive map
+	// which multiplies the white emissive color
 	emission := getColor(se.Emission)
+	emissiveMap, err := d.newMapTexture(se.Emission)
+	if err != nil {
+		return nil, err
+	}
+	if emissiveMap != nil {
+		emission = math32.Color{R: 1, G: 1, B: 1}
+		m.SetEmissiveMap(emissiveMap)
+	}
 	m.SetEmissiveColor(&emission)
 
 	//ambient := getColor(se.Ambient)
 	//m.SetAmbientColor(&ambient)
 
+	// If "specular" is Texture sets it as the specular map
 	specular := getColor(se.Specular)
+	specularMap, err := d.newMapTexture(se.Specular)
+	if err != nil {
+		return nil, err
+	}
+	if specularMap != nil {
+		specular = math32.Color{R: 1, G: 1, B: 1}
+		m.SetSpecularMap(specularMap)
+	}
 	m.SetSpecularColor(&specular)
 
+	// Sets the bump texture from the technique extra as
+	// the normal map or the bump map if it has heights
+	if bump != nil {
+		bumpMap, err := d.newMapTexture(bump.Texture)
+		if err != nil {
+			return nil, err
+		}
+		if bumpMap != nil && bump.Bumptype == "HEIGHTFIELD" {
+			m.SetBumpMap(bumpMap)
+		} else if bumpMap != nil {
+			m.SetNormalMap(bumpMap)
+		}
+	}
+
 	shininess := getFloatOrParam(se.Shininess)
 	m.SetShininess(shininess)
 
-	//m.SetOpacity(opacity float32) {
+	// If "transparency" is specified and the material is
+	// not fully opaque, sets it as transparent
+	if _, ok := se.Transparency.(*Float); ok {
+		opacity := getFloatOrParam(se.Transparency)
+		if opacity < 1 {
+			m.SetOpacity(opacity)
+			m.SetTransparent(true)
+		}
+	}
 	//m.SetWireframe(true)
 	m.SetSide(material.SideDouble)
 
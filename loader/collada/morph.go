@@ -0,0 +1,171 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package collada
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/g3n/engine/math32"
+)
+
+// addMorphTargets adds to the geometry of the specified instance the morph
+// targets from the specified morph and sets the instance initial weights.
+// The target geometries must have the same primitives as the base geometry,
+// so the vertices use the same indices of their positions and normals.
+func (d *Decoder) addMorphTargets(ginst *geomInstance, morph *Morph) error {
+
+	// Get the ids of the targets geometries and their weights
+	var targets []string
+	var weights []float32
+	for _, inp := range morph.Targets {
+		var err error
+		switch inp.Semantic {
+		case "MORPH_TARGET":
+			targets, err = findMorphNameArray(morph, inp.Source)
+		case "MORPH_WEIGHT":
+			weights, err = findMorphFloatArray(morph, inp.Source)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	geom := ginst.geom.GetGeometry()
+	var basePos, baseNorm math32.ArrayF32
+	basePos = *geom.VBO("VertexPosition").Buffer()
+	if vbo := geom.VBO("VertexNormal"); vbo != nil {
+		baseNorm = *vbo.Buffer()
+	}
+	relative := morph.Method == "RELATIVE"
+
+	for _, target := range targets {
+		m := d.findMesh(target)
+		if m == nil {
+			return fmt.Errorf("Morph target:%s mesh not found", target)
+		}
+		posArray, normArray := morphMeshArrays(m)
+		if posArray == nil {
+			return fmt.Errorf("Morph target:%s positions not found", target)
+		}
+
+		// Get the position and normal of each vertex from the target arrays
+		positions := math32.NewArrayF32(0, len(basePos))
+		var normals math32.ArrayF32
+		if normArray != nil && baseNorm != nil {
+			normals = math32.NewArrayF32(0, len(baseNorm))
+		}
+		for i, pidx := range ginst.vindex {
+			if 3*pidx+2 >= len(posArray.Data) {
+				return fmt.Errorf("Morph target:%s positions missing", target)
+			}
+			positions.Append(posArray.Data[3*pidx : 3*pidx+3]...)
+			if normals == nil {
+				continue
+			}
+			nidx := ginst.nindex[i]
+			if nidx < 0 || 3*nidx+2 >= len(normArray.Data) {
+				return fmt.Errorf("Morph target:%s normals missing", target)
+			}
+			normals.Append(normArray.Data[3*nidx : 3*nidx+3]...)
+		}
+
+		// The relative targets have the offsets from the base geometry
+		if relative {
+			for i := range positions {
+				positions[i] += basePos[i]
+			}
+			for i := range normals {
+				normals[i] += baseNorm[i]
+			}
+		}
+		geom.AddMorphTarget(target, positions, normals)
+	}
+	ginst.weights = weights
+	return nil
+}
+
+// findMesh returns the mesh of the geometry with the specified id or url
+// in the Collada document or nil if not found
+func (d *Decoder) findMesh(id string) *Mesh {
+
+	if d.dom.LibraryGeometries == nil {
+		return nil
+	}
+	id = strings.TrimPrefix(id, "#")
+	for _, g := range d.dom.LibraryGeometries.Geometry {
+		if g.Id == id {
+			m, _ := g.GeometricElement.(*Mesh)
+			return m
+		}
+	}
+	return nil
+}
+
+// morphMeshArrays returns the float arrays with the positions
+// and with the normals of the polylists of the specified mesh
+func morphMeshArrays(m *Mesh) (*FloatArray, *FloatArray) {
+
+	var posArray, normArray *FloatArray
+	for _, inp := range m.Vertices.Input {
+		if inp.Semantic == "POSITION" {
+			if src := getMeshSource(m, inp.Source); src != nil {
+				posArray, _ = src.ArrayElement.(*FloatArray)
+			}
+		}
+	}
+	for _, pel := range m.PrimitiveElements {
+		pl, ok := pel.(*Polylist)
+		if !ok {
+			continue
+		}
+		inp := getInputSemantic(pl.Input, "NORMAL")
+		if inp == nil {
+			continue
+		}
+		if src := getMeshSource(m, inp.Source); src != nil {
+			normArray, _ = src.ArrayElement.(*FloatArray)
+		}
+		break
+	}
+	return posArray, normArray
+}
+
+func findMorphSource(morph *Morph, uri string) *Source {
+
+	id := strings.TrimPrefix(uri, "#")
+	for _, src := range morph.Sources {
+		if src.Id == id {
+			return src
+		}
+	}
+	return nil
+}
+
+func findMorphFloatArray(morph *Morph, uri string) ([]float32, error) {
+
+	src := findMorphSource(morph, uri)
+	if src == nil {
+		return nil, fmt.Errorf("Source:%s not found", uri)
+	}
+	fa, ok := src.ArrayElement.(*FloatArray)
+	if !ok {
+		return nil, fmt.Errorf("Source:%s is not FloatArray", uri)
+	}
+	return fa.Data, nil
+}
+
+func findMorphNameArray(morph *Morph, uri string) ([]string, error) {
+
+	src := findMorphSource(morph, uri)
+	if src == nil {
+		return nil, fmt.Errorf("Source:%s not found", uri)
+	}
+	na, ok := src.ArrayElement.(*NameArray)
+	if !ok {
+		return nil, fmt.Errorf("Source:%s is not NameArray", uri)
+	}
+	return na.Data, nil
+}
@@ -39,6 +39,8 @@ func (d *Decoder) NewScene() (core.INode, error) {
 	}
 
 	// Creates each node and adds it to the scene
+	d.nodes = make(map[*Node]core.INode)
+	d.skins = d.skins[0:0]
 	for _, n := range vs.Node {
 		node, err := d.newNode(n)
 		if err != nil {
@@ -46,6 +48,12 @@ func (d *Decoder) NewScene() (core.INode, error) {
 		}
 		scene.Add(node)
 	}
+
+	// Sets the skeletons of the skinned meshes from the created joints
+	err := d.newSkeletons(vs)
+	if err != nil {
+		return nil, err
+	}
 	return scene, nil
 }
 
@@ -68,21 +76,9 @@ func (d *Decoder) newNode(cnode *Node) (core.INode, error) {
 		switch gtype {
 		case gls.TRIANGLES:
 			mesh := graphic.NewMesh(geomi, nil)
-			geom := geomi.GetGeometry()
-			// Associates the material in <bind_material> with the geometry group material
-			for _, im := range nt.BindMaterial.TechniqueCommon.InstanceMaterial {
-				matid := strings.TrimPrefix(im.Target, "#")
-				for i := 0; i < geom.GroupCount(); i++ {
-					group := geom.GroupAt(i)
-					if group.Matid == matid {
-						mat, err := d.GetMaterial(im.Target)
-						if err != nil {
-							return nil, err
-						}
-						mesh.AddGroupMaterial(mat, i)
-						break
-					}
-				}
+			err := d.addGroupMaterials(mesh, nt.BindMaterial)
+			if err != nil {
+				return nil, err
 			}
 			node = mesh
 
@@ -102,12 +98,20 @@ func (d *Decoder) newNode(cnode *Node) (core.INode, error) {
 		default:
 			return nil, fmt.Errorf("primitive not supported")
 		}
+		// Skinned or morphed mesh
+	case *InstanceController:
+		mesh, err := d.newControllerMesh(nt)
+		if err != nil {
+			return nil, err
+		}
+		node = mesh
 	default:
 		return nil, fmt.Errorf("instance geometry type:%T not supported", nt)
 	}
 
 	n := node.GetNode()
 	n.SetLoaderID(cnode.Id)
+	d.nodes[cnode] = node
 
 	// Apply transformation elements to the node
 	for _, tei := range cnode.TransformationElements {
@@ -156,6 +160,31 @@ func (d *Decoder) newNode(cnode *Node) (core.INode, error) {
 	return node, nil
 }
 
+// addGroupMaterials associates the materials in the specified <bind_material>
+// with the groups of the geometry of the specified mesh
+func (d *Decoder) addGroupMaterials(mesh graphic.IGraphic, bm *BindMaterial) error {
+
+	if bm == nil {
+		return nil
+	}
+	geom := mesh.GetGeometry()
+	for _, im := range bm.TechniqueCommon.InstanceMaterial {
+		matid := strings.TrimPrefix(im.Target, "#")
+		for i := 0; i < geom.GroupCount(); i++ {
+			group := geom.GroupAt(i)
+			if group.Matid == matid {
+				mat, err := d.GetMaterial(im.Target)
+				if err != nil {
+					return err
+				}
+				mesh.GetGraphic().AddGroupMaterial(mesh, mat, i)
+				break
+			}
+		}
+	}
+	return nil
+}
+
 func findVisualScene(dom *Collada, uri string) *VisualScene {
 
 	id := strings.TrimPrefix(uri, "#")
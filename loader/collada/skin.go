@@ -0,0 +1,312 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package collada
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/math32"
+)
+
+// Maximum number of joints which influence each vertex
+const skinJointsMax = 4
+
+// skinInstance is a skinned mesh created from an instance controller
+// whose skeleton is built after all the nodes of the scene are created
+type skinInstance struct {
+	mesh *graphic.SkinnedMesh // Skinned mesh
+	ic   *InstanceController  // Instance controller of the mesh
+	skin *Skin                // Skin of the instanced controller
+}
+
+// newControllerMesh creates and returns a mesh from the controller referenced
+// by the specified instance controller. The mesh is a skinned mesh whose
+// skeleton is set by newSkeletons after the creation of the joints nodes
+// if the controller is a skin, or a mesh with morph targets otherwise.
+func (d *Decoder) newControllerMesh(ic *InstanceController) (core.INode, error) {
+
+	c := d.findController(ic.Url)
+	if c == nil {
+		return nil, fmt.Errorf("Controller:%s not found", ic.Url)
+	}
+
+	// The geometry of the controller has the joints and weights attributes
+	// or the morph targets, so it is not shared with the base geometry.
+	ginst, ok := d.geometries[ic.Url]
+	if !ok {
+		var err error
+		ginst, err = d.newControllerGeometry(ic.Url)
+		if err != nil {
+			return nil, err
+		}
+		d.geometries[ic.Url] = ginst
+	}
+
+	if c.Skin == nil {
+		mesh := graphic.NewMesh(ginst.geom, nil)
+		mesh.SetMorphWeights(ginst.weights)
+		err := d.addGroupMaterials(mesh, ic.BindMaterial)
+		if err != nil {
+			return nil, err
+		}
+		return mesh, nil
+	}
+
+	mesh := graphic.NewSkinnedMesh(ginst.geom, nil)
+	mesh.SetMorphWeights(ginst.weights)
+	err := d.addGroupMaterials(mesh, ic.BindMaterial)
+	if err != nil {
+		return nil, err
+	}
+	var bindMatrix math32.Matrix4
+	bindMatrix.FromArray(c.Skin.BindShapeMatrix)
+	bindMatrix.Transpose()
+	mesh.SetBindMatrix(&bindMatrix)
+	d.skins = append(d.skins, skinInstance{mesh, ic, c.Skin})
+	return mesh, nil
+}
+
+// newControllerGeometry creates a new instance of the geometry of the
+// controller with the specified url or of the geometry with the specified
+// url if it is not a controller. The source of a skin may be a morph.
+func (d *Decoder) newControllerGeometry(url string) (geomInstance, error) {
+
+	c := d.findController(url)
+	if c == nil {
+		return d.newGeometry(url)
+	}
+	switch {
+	case c.Skin != nil:
+		ginst, err := d.newControllerGeometry(c.Skin.Source)
+		if err != nil {
+			return ginst, err
+		}
+		if ginst.ptype != gls.TRIANGLES || len(ginst.vindex) == 0 {
+			return ginst, fmt.Errorf("Skin:%s geometry not supported", c.Id)
+		}
+		return ginst, addSkinVBOs(ginst, c.Skin)
+	case c.Morph != nil:
+		ginst, err := d.newGeometry(c.Morph.Source)
+		if err != nil {
+			return ginst, err
+		}
+		if ginst.ptype != gls.TRIANGLES || len(ginst.vindex) == 0 {
+			return ginst, fmt.Errorf("Morph:%s geometry not supported", c.Id)
+		}
+		return ginst, d.addMorphTargets(&ginst, c.Morph)
+	default:
+		return geomInstance{}, fmt.Errorf("Controller:%s has no skin or morph", c.Id)
+	}
+}
+
+// findController returns the controller with the specified url or nil if not found
+func (d *Decoder) findController(url string) *Controller {
+
+	if d.dom.LibraryControllers == nil {
+		return nil
+	}
+	id := strings.TrimPrefix(url, "#")
+	for _, c := range d.dom.LibraryControllers.Controller {
+		if c.Id == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// addSkinVBOs adds to the geometry of the specified instance the
+// VertexJoints and VertexWeights attributes from the specified skin.
+// Only the joints with the largest weights of each vertex are used.
+func addSkinVBOs(ginst geomInstance, skin *Skin) error {
+
+	vw := &skin.VertexWeights
+	inpJoint := getInputSemantic(vw.Input, "JOINT")
+	inpWeight := getInputSemantic(vw.Input, "WEIGHT")
+	if inpJoint == nil || inpWeight == nil {
+		return fmt.Errorf("Skin vertex weights inputs not found")
+	}
+	weights, err := findSkinFloatArray(skin, inpWeight.Source)
+	if err != nil {
+		return err
+	}
+
+	// Joints indices and weights of each Collada position
+	joints := make([][2 * skinJointsMax]float32, len(vw.Vcount))
+	inputCount := len(vw.Input)
+	pos := 0
+	for i := 0; i < len(vw.Vcount); i++ {
+		var jw [skinJointsMax]struct {
+			joint  float32
+			weight float32
+		}
+		for j := 0; j < vw.Vcount[i]; j++ {
+			if pos+inputCount > len(vw.V) {
+				return fmt.Errorf("Skin vertex weights indices missing")
+			}
+			joint := vw.V[pos+inpJoint.Offset]
+			widx := vw.V[pos+inpWeight.Offset]
+			pos += inputCount
+			// Index -1 refers to the bind shape
+			if joint < 0 || widx >= len(weights) {
+				continue
+			}
+			w := weights[widx]
+			// Inserts the joint keeping the largest weights sorted
+			for k := 0; k < skinJointsMax; k++ {
+				if w > jw[k].weight {
+					copy(jw[k+1:], jw[k:skinJointsMax-1])
+					jw[k].joint = float32(joint)
+					jw[k].weight = w
+					break
+				}
+			}
+		}
+		// Normalizes the weights of the used joints
+		var sum float32
+		for k := 0; k < skinJointsMax; k++ {
+			sum += jw[k].weight
+		}
+		for k := 0; k < skinJointsMax; k++ {
+			joints[i][k] = jw[k].joint
+			if sum > 0 {
+				joints[i][skinJointsMax+k] = jw[k].weight / sum
+			}
+		}
+	}
+
+	// Appends the joints and weights of the position of each vertex
+	vjoints := math32.NewArrayF32(0, skinJointsMax*len(ginst.vindex))
+	vweights := math32.NewArrayF32(0, skinJointsMax*len(ginst.vindex))
+	for _, pidx := range ginst.vindex {
+		if pidx >= len(joints) {
+			return fmt.Errorf("Skin vertex weights count less than positions")
+		}
+		vjoints.Append(joints[pidx][:skinJointsMax]...)
+		vweights.Append(joints[pidx][skinJointsMax:]...)
+	}
+	geom := ginst.geom.GetGeometry()
+	geom.AddVBO(gls.NewVBO().AddAttrib("VertexJoints", skinJointsMax).SetBuffer(vjoints))
+	geom.AddVBO(gls.NewVBO().AddAttrib("VertexWeights", skinJointsMax).SetBuffer(vweights))
+	return nil
+}
+
+// newSkeletons creates and sets the skeletons of the skinned meshes
+// created by the last NewScene from the joints nodes of the scene
+func (d *Decoder) newSkeletons(vs *VisualScene) error {
+
+	for _, si := range d.skins {
+		// Get the names of the joints and their inverse bind matrices
+		var names []string
+		var invBind []float32
+		for _, inp := range si.skin.Joints {
+			var err error
+			switch inp.Semantic {
+			case "JOINT":
+				names, err = findSkinNameArray(si.skin, inp.Source)
+			case "INV_BIND_MATRIX":
+				invBind, err = findSkinFloatArray(si.skin, inp.Source)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if len(names) > graphic.MaxBones {
+			return fmt.Errorf("Skin joints count:%d greater than maximum:%d", len(names), graphic.MaxBones)
+		}
+		if len(invBind) < 16*len(names) {
+			return fmt.Errorf("Skin inverse bind matrices missing")
+		}
+
+		// Get the nodes where to start the search for the joints
+		roots := vs.Node
+		if len(si.ic.Skeleton) > 0 {
+			roots = make([]*Node, 0)
+			for _, url := range si.ic.Skeleton {
+				root := findNode(vs.Node, strings.TrimPrefix(url, "#"), "")
+				if root == nil {
+					return fmt.Errorf("Skeleton node:%s not found", url)
+				}
+				roots = append(roots, root)
+			}
+		}
+
+		sk := graphic.NewSkeleton()
+		for i, name := range names {
+			// Joints are referenced by their sid or by their id
+			cnode := findNode(roots, "", name)
+			if cnode == nil {
+				cnode = findNode(roots, name, "")
+			}
+			if cnode == nil {
+				return fmt.Errorf("Joint:%s not found", name)
+			}
+			var data [16]float32
+			copy(data[:], invBind[16*i:])
+			var m math32.Matrix4
+			m.FromArray(data)
+			m.Transpose()
+			sk.AddBone(d.nodes[cnode], &m)
+		}
+		si.mesh.SetSkeleton(sk)
+	}
+	return nil
+}
+
+// findNode returns the first node of the specified nodes trees with
+// the specified id or sid, if not empty, or nil if not found
+func findNode(nodes []*Node, id, sid string) *Node {
+
+	for _, n := range nodes {
+		if (id != "" && n.Id == id) || (sid != "" && n.Sid == sid) {
+			return n
+		}
+		found := findNode(n.Node, id, sid)
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func findSkinSource(skin *Skin, uri string) *Source {
+
+	id := strings.TrimPrefix(uri, "#")
+	for _, src := range skin.Sources {
+		if src.Id == id {
+			return src
+		}
+	}
+	return nil
+}
+
+func findSkinFloatArray(skin *Skin, uri string) ([]float32, error) {
+
+	src := findSkinSource(skin, uri)
+	if src == nil {
+		return nil, fmt.Errorf("Source:%s not found", uri)
+	}
+	fa, ok := src.ArrayElement.(*FloatArray)
+	if !ok {
+		return nil, fmt.Errorf("Source:%s is not FloatArray", uri)
+	}
+	return fa.Data, nil
+}
+
+func findSkinNameArray(skin *Skin, uri string) ([]string, error) {
+
+	src := findSkinSource(skin, uri)
+	if src == nil {
+		return nil, fmt.Errorf("Source:%s not found", uri)
+	}
+	na, ok := src.ArrayElement.(*NameArray)
+	if !ok {
+		return nil, fmt.Errorf("Source:%s is not NameArray", uri)
+	}
+	return na.Data, nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/g3n/engine/graphic"
 	"github.com/g3n/engine/material"
 	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
 	"io"
 	"math"
 	"os"
@@ -35,6 +36,7 @@ type Decoder struct {
 	objCurrent    *Object              // current object
 	matCurrent    *Material            // current material
 	smoothCurrent bool                 // current smooth state
+	mtlDir        string               // directory of the mtl file
 }
 
 // Object contains all information about one decoded object
@@ -65,6 +67,12 @@ type Material struct {
 	Specular   math32.Color // Specular color reflectivity
 	Emissive   math32.Color // Emissive color
 	MapKd      string       // Texture file linked to diffuse color
+	MapKs      string       // Texture file linked to specular color
+	MapKe      string       // Texture file linked to emissive color
+	MapD       string       // Texture file linked to opacity
+	MapBump    string       // Texture file with the bump heights
+	MapNorm    string       // Texture file with the tangent space normals
+	BumpScale  float32      // Bump or normal map scale (-bm option)
 }
 
 // Local constants
@@ -101,11 +109,17 @@ func Decode(objpath string, mtlpath string) (*Decoder, error) {
 	}
 	defer fmtl.Close()
 
-	return DecodeReader(fobj, fmtl)
+	dec, err := DecodeReader(fobj, fmtl)
+	if err != nil {
+		return nil, err
+	}
+	dec.mtlDir = filepath.Dir(mtlpath)
+	return dec, nil
 }
 
 // DecodeReader decodes the specified obj and mtl readers returning a decoder
 // object and an error.
+// The texture files of the materials are relative to the current directory.
 func DecodeReader(objreader, mtlreader io.Reader) (*Decoder, error) {
 
 	dec := new(Decoder)
@@ -163,11 +177,7 @@ func (dec *Decoder) NewMesh(obj *Object) (*graphic.Mesh, error) {
 		matName := obj.materials[0]
 		matDesc := dec.Materials[matName]
 		// Creates material
-		mat := material.NewPhong(&matDesc.Diffuse)
-		ambientColor := mat.AmbientColor()
-		mat.SetAmbientColor(ambientColor.Multiply(&matDesc.Ambient))
-		mat.SetSpecularColor(&matDesc.Specular)
-		mat.SetShininess(matDesc.Shininess)
+		mat := dec.newPhong(matDesc)
 		return graphic.NewMesh(geom, mat), nil
 	}
 
@@ -178,16 +188,70 @@ func (dec *Decoder) NewMesh(obj *Object) (*graphic.Mesh, error) {
 		matName := obj.materials[group.Matindex]
 		matDesc := dec.Materials[matName]
 		// Creates material
-		matGroup := material.NewPhong(&matDesc.Diffuse)
-		ambientColor := matGroup.AmbientColor()
-		matGroup.SetAmbientColor(ambientColor.Multiply(&matDesc.Ambient))
-		matGroup.SetSpecularColor(&matDesc.Specular)
-		matGroup.SetShininess(matDesc.Shininess)
+		matGroup := dec.newPhong(matDesc)
 		mesh.AddGroupMaterial(matGroup, idx)
 	}
 	return mesh, nil
 }
 
+// newPhong creates and returns a phong material
+// from the specified decoded material description
+func (dec *Decoder) newPhong(matDesc *Material) *material.Phong {
+
+	mat := material.NewPhong(&matDesc.Diffuse)
+	ambientColor := mat.AmbientColor()
+	mat.SetAmbientColor(ambientColor.Multiply(&matDesc.Ambient))
+	mat.SetSpecularColor(&matDesc.Specular)
+	mat.SetShininess(matDesc.Shininess)
+	// Materials not fully opaque must be rendered as transparent
+	if matDesc.Opacity < 1 {
+		mat.SetOpacity(matDesc.Opacity)
+		mat.SetTransparent(true)
+	}
+	// Loads the texture maps of the material
+	if tex := dec.loadMap(matDesc.MapKd); tex != nil {
+		mat.SetDiffuseMap(tex)
+	}
+	if tex := dec.loadMap(matDesc.MapKs); tex != nil {
+		mat.SetSpecularMap(tex)
+	}
+	if tex := dec.loadMap(matDesc.MapKe); tex != nil {
+		mat.SetEmissiveMap(tex)
+	}
+	if tex := dec.loadMap(matDesc.MapD); tex != nil {
+		mat.SetAlphaMap(tex)
+		mat.SetTransparent(true)
+	}
+	if tex := dec.loadMap(matDesc.MapNorm); tex != nil {
+		mat.SetNormalMap(tex)
+		mat.SetNormalScale(matDesc.BumpScale)
+	}
+	if tex := dec.loadMap(matDesc.MapBump); tex != nil {
+		mat.SetBumpMap(tex)
+		mat.SetBumpScale(matDesc.BumpScale)
+	}
+	return mat
+}
+
+// loadMap loads and returns the texture from the specified file, relative
+// to the directory of the mtl file, or nil if the file name is empty.
+// If the texture could not be loaded a warning is appended and nil returned.
+func (dec *Decoder) loadMap(fname string) *texture.Texture2D {
+
+	if fname == "" {
+		return nil
+	}
+	if !filepath.IsAbs(fname) {
+		fname = filepath.Join(dec.mtlDir, fname)
+	}
+	tex, err := texture.NewTexture2DFromImage(fname)
+	if err != nil {
+		dec.appendWarn(mtlType, "texture not loaded: "+err.Error())
+		return nil
+	}
+	return tex
+}
+
 // NewGeometry generates and returns a geometry from the specified object
 func (dec *Decoder) NewGeometry(obj *Object) (*geometry.Geometry, error) {
 
@@ -467,6 +531,8 @@ func (dec *Decoder) parseUsemtl(fields []string) error {
 	if mat == nil {
 		mat = new(Material)
 		mat.Name = name
+		mat.Opacity = 1
+		mat.BumpScale = 1
 		dec.Materials[name] = mat
 	}
 	dec.objCurrent.materials = append(dec.objCurrent.materials, name)
@@ -531,7 +597,17 @@ func (dec *Decoder) parseMtlLine(line string) error {
 	case "illum":
 		return dec.parseIllum(fields[1:])
 	case "map_Kd":
-		return dec.parseMapKd(fields[1:])
+		return dec.parseMap(fields[1:], &dec.matCurrent.MapKd)
+	case "map_Ks":
+		return dec.parseMap(fields[1:], &dec.matCurrent.MapKs)
+	case "map_Ke":
+		return dec.parseMap(fields[1:], &dec.matCurrent.MapKe)
+	case "map_d":
+		return dec.parseMap(fields[1:], &dec.matCurrent.MapD)
+	case "map_Bump", "map_bump", "bump":
+		return dec.parseMap(fields[1:], &dec.matCurrent.MapBump)
+	case "norm":
+		return dec.parseMap(fields[1:], &dec.matCurrent.MapNorm)
 	default:
 		dec.appendWarn(mtlType, "field not supported: "+ltype)
 	}
@@ -552,6 +628,8 @@ func (dec *Decoder) parseNewmtl(fields []string) error {
 	if mat == nil {
 		mat = new(Material)
 		mat.Name = name
+		mat.Opacity = 1
+		mat.BumpScale = 1
 		dec.Materials[name] = mat
 	}
 	dec.matCurrent = mat
@@ -694,14 +772,26 @@ func (dec *Decoder) parseIllum(fields []string) error {
 	return nil
 }
 
-// Parses color texture linked to the diffuse reflectivity of the material
+// Parses texture map statements, such as the color texture linked to the
+// diffuse reflectivity of the material, and stores the file name.
+// The bump multiplier option sets the material bump scale.
 // map_Kd [-options] <filename>
-func (dec *Decoder) parseMapKd(fields []string) error {
+func (dec *Decoder) parseMap(fields []string, fname *string) error {
 
 	if len(fields) < 1 {
 		return dec.formatError("No fields")
 	}
-	dec.matCurrent.MapKd = fields[0]
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] != "-bm" {
+			continue
+		}
+		val, err := strconv.ParseFloat(fields[i+1], 32)
+		if err != nil {
+			return dec.formatError("'-bm' parse float error")
+		}
+		dec.matCurrent.BumpScale = float32(val)
+	}
+	*fname = fields[len(fields)-1]
 	return nil
 }
 
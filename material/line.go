@@ -0,0 +1,202 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// LineCap specifies the shape of the ends of lines
+type LineCap int
+
+const (
+	LineCapButt   LineCap = 0 // Squared off at the endpoints
+	LineCapSquare LineCap = 1 // Squared off half the width beyond the endpoints
+	LineCapRound  LineCap = 2 // Rounded with half the width beyond the endpoints
+)
+
+// LineJoin specifies the shape of the joins between consecutive segments
+type LineJoin int
+
+const (
+	LineJoinMiter LineJoin = 0 // Sharp corner limited by the miter limit
+	LineJoinBevel LineJoin = 1 // Corner cut off at the outer ends of the segments
+	LineJoinRound LineJoin = 2 // Rounded corner
+)
+
+// Line is a material for Lines and LineStrip graphics which draws their
+// segments as quads expanded in screen space, so they have a width in
+// pixels which is not limited by the OpenGL line width.
+// The colors of the vertices are multiplied by the material color.
+// Segments of line strips are joined by the join shape and the ends
+// of the lines have the cap shape. The quads are rebuilt when the VBOs
+// of the lines geometry are updated or its indices are set.
+type Line struct {
+	Material                 // Embedded material
+	color      gls.Uniform3f // Line color uniform
+	opacity    gls.Uniform1f // Line opacity uniform
+	width      gls.Uniform1f // Line width in pixels uniform
+	lineCap    gls.Uniform1i // Line cap uniform
+	join       gls.Uniform1i // Line join uniform
+	miterLimit gls.Uniform1f // Miter limit uniform
+	dash       gls.Uniform3f // Dash size, gap size and dash offset uniform
+}
+
+// NewLine creates and returns a pointer to a new line material
+// with the specified color and a width of 1 pixel
+func NewLine(color *math32.Color) *Line {
+
+	lm := new(Line)
+	lm.Init(color)
+	return lm
+}
+
+// Init initializes a Line material embedded in another type
+func (lm *Line) Init(color *math32.Color) *Line {
+
+	lm.Material.Init()
+	lm.SetShader("shaderLine")
+	// The orientation of the quads depends on the direction of the segments
+	lm.SetSide(SideDouble)
+
+	lm.color.Init("MatDiffuseColor")
+	lm.color.SetColor(color)
+	lm.opacity.Init("MatOpacity")
+	lm.opacity.Set(1)
+	lm.width.Init("LineWidth")
+	lm.width.Set(1)
+	lm.lineCap.Init("LineCap")
+	lm.lineCap.Set(int32(LineCapButt))
+	lm.join.Init("LineJoin")
+	lm.join.Set(int32(LineJoinMiter))
+	lm.miterLimit.Init("LineMiterLimit")
+	lm.miterLimit.Set(4)
+	lm.dash.Init("LineDash")
+	lm.dash.Set(0, 0, 0)
+	return lm
+}
+
+// SetColor sets the color of the lines
+func (lm *Line) SetColor(color *math32.Color) {
+
+	lm.color.SetColor(color)
+}
+
+// Color returns the color of the lines
+func (lm *Line) Color() math32.Color {
+
+	return lm.color.GetColor()
+}
+
+// SetOpacity sets the opacity of the lines.
+// Lines which are not opaque should also be set transparent.
+func (lm *Line) SetOpacity(opacity float32) {
+
+	lm.opacity.Set(opacity)
+}
+
+// Opacity returns the opacity of the lines
+func (lm *Line) Opacity() float32 {
+
+	return lm.opacity.Get()
+}
+
+// SetWidth sets the width of the lines in pixels (default = 1)
+func (lm *Line) SetWidth(width float32) {
+
+	lm.width.Set(width)
+}
+
+// Width returns the width of the lines in pixels
+func (lm *Line) Width() float32 {
+
+	return lm.width.Get()
+}
+
+// SetCap sets the shape of the ends of the lines (default = LineCapButt)
+func (lm *Line) SetCap(lineCap LineCap) {
+
+	lm.lineCap.Set(int32(lineCap))
+}
+
+// Cap returns the shape of the ends of the lines
+func (lm *Line) Cap() LineCap {
+
+	return LineCap(lm.lineCap.Get())
+}
+
+// SetJoin sets the shape of the joins between the consecutive
+// segments of line strips (default = LineJoinMiter)
+func (lm *Line) SetJoin(join LineJoin) {
+
+	lm.join.Set(int32(join))
+}
+
+// Join returns the shape of the joins between consecutive segments
+func (lm *Line) Join() LineJoin {
+
+	return LineJoin(lm.join.Get())
+}
+
+// SetMiterLimit sets the maximum ratio between the length of the miter
+// and the width of the lines (default = 4). Miter joins which would be
+// longer are beveled.
+func (lm *Line) SetMiterLimit(limit float32) {
+
+	lm.miterLimit.Set(limit)
+}
+
+// MiterLimit returns the maximum ratio between the length
+// of the miter and the width of the lines
+func (lm *Line) MiterLimit() float32 {
+
+	return lm.miterLimit.Get()
+}
+
+// SetDash sets the dash pattern of the lines with the lengths of the dashes
+// and of the gaps between them, in the units of the vertices distances.
+// The distances are the lengths along the lines from their first vertices,
+// unless the geometry has the VertexDistance attribute.
+// Lines are solid if any of the lengths is zero (the default).
+func (lm *Line) SetDash(dash, gap float32) {
+
+	_, _, offset := lm.dash.Get()
+	lm.dash.Set(dash, gap, offset)
+}
+
+// Dash returns the lengths of the dashes and of the gaps between them
+func (lm *Line) Dash() (float32, float32) {
+
+	dash, gap, _ := lm.dash.Get()
+	return dash, gap
+}
+
+// SetDashOffset sets the distance along the lines where the dash pattern starts
+func (lm *Line) SetDashOffset(offset float32) {
+
+	dash, gap, _ := lm.dash.Get()
+	lm.dash.Set(dash, gap, offset)
+}
+
+// DashOffset returns the distance along the lines where the dash pattern starts
+func (lm *Line) DashOffset() float32 {
+
+	_, _, offset := lm.dash.Get()
+	return offset
+}
+
+// RenderSetup is called by the renderer before drawing objects with this material
+func (lm *Line) RenderSetup(gs *gls.GLS) {
+
+	lm.Material.RenderSetup(gs)
+	lm.color.Transfer(gs)
+	lm.opacity.Transfer(gs)
+	lm.width.Transfer(gs)
+	lm.lineCap.Transfer(gs)
+	lm.join.Transfer(gs)
+	lm.miterLimit.Transfer(gs)
+	lm.dash.Transfer(gs)
+}
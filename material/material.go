@@ -56,9 +56,12 @@ type IMaterial interface {
 type Material struct {
 	refcount         int                  // Current number of references
 	shader           string               // Shader name
+	deferredShader   string               // Shader name for the deferred G-buffer pass
+	forward          bool                 // always rendered by the forward path
 	uselights        UseLights            // Use lights bit mask
 	sidevis          Side                 // sides visible
 	wireframe        bool                 // show as wirefrme
+	transparent      bool                 // rendered in the transparent queue
 	depthMask        bool                 // Enable writing into the depth buffer
 	depthTest        bool                 // Enable depth buffer test
 	depthFunc        uint32               // Actvie depth test function
@@ -73,6 +76,10 @@ type Material struct {
 	polyOffsetFactor float32              // polygon offset factor
 	polyOffsetUnits  float32              // polygon offset units
 	textures         []*texture.Texture2D // List of textures
+	maps             []*texture.Texture2D // List of textures bound to named samplers
+	texUnits         int                  // Number of texture units used besides the textures list
+	defines          gls.ShaderDefines    // Preprocessor symbols defined in the shaders
+	extras           gls.ShaderDefines    // Values available to the shaders templates
 }
 
 // NewMaterial returns a pointer to a new material
@@ -88,14 +95,21 @@ func (mat *Material) Init() *Material {
 	mat.uselights = UseLightAll
 	mat.sidevis = SideFront
 	mat.wireframe = false
+	mat.transparent = false
 	mat.depthMask = true
 	mat.depthFunc = gls.LEQUAL
 	mat.depthTest = true
+	mat.forward = false
+	mat.deferredShader = ""
 	mat.blending = BlendingNormal
 	mat.lineWidth = 1.0
 	mat.polyOffsetFactor = 0
 	mat.polyOffsetUnits = 0
 	mat.textures = make([]*texture.Texture2D, 0)
+	mat.maps = make([]*texture.Texture2D, 0)
+	mat.texUnits = 0
+	mat.defines = gls.NewShaderDefines()
+	mat.extras = gls.NewShaderDefines()
 
 	return mat
 }
@@ -128,6 +142,9 @@ func (mat *Material) Dispose() {
     for i := 0; i < len(mat.textures); i++ {
         mat.textures[i].Dispose()
     }
+	for i := 0; i < len(mat.maps); i++ {
+		mat.maps[i].Dispose()
+	}
 	mat.Init()
 }
 
@@ -143,6 +160,53 @@ func (mat *Material) Shader() string {
 	return mat.shader
 }
 
+// SetDeferredShader sets the name of the shader program used to render this
+// material into the G-buffer when the renderer deferred path is enabled.
+// The program is generated with the GBuffer shader specs.
+// If empty (the default) the material is always rendered by the forward path.
+func (mat *Material) SetDeferredShader(sname string) {
+
+	mat.deferredShader = sname
+}
+
+// DeferredShader returns the name of the shader program used to
+// render this material into the G-buffer or an empty string.
+func (mat *Material) DeferredShader() string {
+
+	return mat.deferredShader
+}
+
+// SetForward sets if this material is always rendered by the forward path
+// even when the renderer deferred path is enabled (default = false).
+// Transparent materials and materials with blending other than
+// BlendingNone or BlendingNormal are always rendered forward.
+func (mat *Material) SetForward(state bool) {
+
+	mat.forward = state
+}
+
+// Forward returns if this material is always rendered by the forward path
+func (mat *Material) Forward() bool {
+
+	return mat.forward
+}
+
+// ShaderDefines returns the map of preprocessor symbols which are defined
+// in the shaders used to render this material. Programs are generated
+// for each distinct set of symbols.
+func (mat *Material) ShaderDefines() gls.ShaderDefines {
+
+	return mat.defines
+}
+
+// ShaderExtras returns the map of values available to the templates of the
+// shaders used to render this material as {{.Extras.NAME}}. Programs are
+// generated for each distinct set of values.
+func (mat *Material) ShaderExtras() gls.ShaderDefines {
+
+	return mat.extras
+}
+
 // SetUseLights sets the material use lights bit mask specifying which
 // light types will be used when rendering the material
 func (mat *Material) SetUseLights(lights UseLights) {
@@ -172,6 +236,20 @@ func (mat *Material) SetWireframe(state bool) {
 	mat.wireframe = state
 }
 
+// SetTransparent sets the transparent state of this material (default = false).
+// Transparent materials are rendered after all opaque materials and
+// are sorted from back to front.
+func (mat *Material) SetTransparent(state bool) {
+
+	mat.transparent = state
+}
+
+// Transparent returns the current transparent state of this material
+func (mat *Material) Transparent() bool {
+
+	return mat.transparent
+}
+
 func (mat *Material) SetDepthMask(state bool) {
 
 	mat.depthMask = state
@@ -187,6 +265,15 @@ func (mat *Material) SetBlending(blending Blending) {
 	mat.blending = blending
 }
 
+// Blending returns the current blending mode of this material
+func (mat *Material) Blending() Blending {
+
+	return mat.blending
+}
+
+// SetLineWidth sets the OpenGL width of lines and of mesh wireframes.
+// Core profile drivers may only support the width of 1 pixel,
+// so Lines and LineStrip graphics should use the Line material instead.
 func (mat *Material) SetLineWidth(width float32) {
 
 	mat.lineWidth = width
@@ -263,10 +350,13 @@ func (mat *Material) RenderSetup(gs *gls.GLS) {
 		panic("Invalid blending")
 	}
 
-	// Render textures
+	// Render textures followed by the textures of the named maps
 	for idx, tex := range mat.textures {
 		tex.RenderSetup(gs, idx)
 	}
+	for idx, tex := range mat.maps {
+		tex.RenderSetup(gs, len(mat.textures)+idx)
+	}
 }
 
 // AddTexture adds the specified Texture2d to the material
@@ -301,7 +391,41 @@ func (mat *Material) HasTexture(tex *texture.Texture2D) bool {
 }
 
 // TextureCount returns the current number of textures
+// added by AddTexture, not including the named maps
 func (mat *Material) TextureCount() int {
 
 	return len(mat.textures)
 }
+
+// TextureUnits returns the number of texture units used by this material.
+// Besides its textures it includes the units used by its named maps and
+// by the textures of derived materials such as environment maps.
+func (mat *Material) TextureUnits() int {
+
+	return len(mat.textures) + len(mat.maps) + mat.texUnits
+}
+
+// setMap replaces the texture in the specified map slot, whose uniforms
+// have the specified name, and updates the shader define of the map.
+// The textures of the maps are bound after the textures list.
+func (mat *Material) setMap(slot **texture.Texture2D, tex *texture.Texture2D, uniform, define string) {
+
+	if *slot != nil {
+		for pos, curr := range mat.maps {
+			if curr == *slot {
+				copy(mat.maps[pos:], mat.maps[pos+1:])
+				mat.maps[len(mat.maps)-1] = nil
+				mat.maps = mat.maps[:len(mat.maps)-1]
+				break
+			}
+		}
+	}
+	*slot = tex
+	if tex == nil {
+		mat.defines.Unset(define)
+		return
+	}
+	tex.SetUniformNames(uniform)
+	mat.maps = append(mat.maps, tex)
+	mat.defines.Set(define, "")
+}
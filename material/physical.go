@@ -0,0 +1,257 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
+)
+
+// Physical is a physically based material using the metallic-roughness
+// model and the Cook-Torrance BRDF. Its texture maps follow the glTF
+// conventions and it may be lit by an environment cube map.
+// The resulting colors are linear and may be tone mapped by post-processing.
+type Physical struct {
+	Material                              // Embedded material
+	uBaseColor       gls.Uniform4f        // Base color and opacity uniform
+	uMetallic        gls.Uniform1f        // Metallic factor uniform
+	uRoughness       gls.Uniform1f        // Roughness factor uniform
+	uOcclusion       gls.Uniform1f        // Occlusion strength uniform
+	uEmissive        gls.Uniform3f        // Emissive color uniform
+	uEnvIntensity    gls.Uniform1f        // Environment lighting intensity uniform
+	baseColorMap     *texture.Texture2D   // Base color and opacity texture
+	metallicRoughMap *texture.Texture2D   // Metallic (blue) and roughness (green) texture
+	occlusionMap     *texture.Texture2D   // Ambient occlusion (red) texture
+	emissiveMap      *texture.Texture2D   // Emissive color texture
+	envMap           *texture.TextureCube // Environment cube map
+}
+
+// NewPhysical creates and returns a pointer to a new physical material
+// with a white non metallic base color.
+func NewPhysical() *Physical {
+
+	pm := new(Physical)
+	pm.Init()
+	return pm
+}
+
+// Init initializes a Physical material embedded in another type
+func (pm *Physical) Init() *Physical {
+
+	pm.Material.Init()
+	pm.SetShader("shaderPhysical")
+	pm.SetDeferredShader("shaderPhysical")
+
+	pm.uBaseColor.Init("MatBaseColor")
+	pm.uMetallic.Init("MatMetallic")
+	pm.uRoughness.Init("MatRoughness")
+	pm.uOcclusion.Init("MatOcclusionStrength")
+	pm.uEmissive.Init("MatEmissiveColor")
+	pm.uEnvIntensity.Init("MatEnvIntensity")
+
+	pm.uBaseColor.Set(1, 1, 1, 1)
+	pm.uMetallic.Set(0)
+	pm.uRoughness.Set(1)
+	pm.uOcclusion.Set(1)
+	pm.uEmissive.Set(0, 0, 0)
+	pm.uEnvIntensity.Set(1)
+
+	pm.baseColorMap = nil
+	pm.metallicRoughMap = nil
+	pm.occlusionMap = nil
+	pm.emissiveMap = nil
+	pm.envMap = nil
+	return pm
+}
+
+// SetBaseColor sets the base color and opacity of the material.
+// It is multiplied by the base color map if set. The default is opaque white.
+func (pm *Physical) SetBaseColor(color *math32.Color4) {
+
+	pm.uBaseColor.SetColor4(color)
+}
+
+// BaseColor returns the current base color and opacity
+func (pm *Physical) BaseColor() math32.Color4 {
+
+	return pm.uBaseColor.GetColor4()
+}
+
+// SetMetallic sets the metallic factor from 0.0 (dielectric) to 1.0 (metal).
+// It is multiplied by the blue channel of the metallic-roughness map if set.
+// The default is 0.0.
+func (pm *Physical) SetMetallic(metallic float32) {
+
+	pm.uMetallic.Set(metallic)
+}
+
+// Metallic returns the current metallic factor
+func (pm *Physical) Metallic() float32 {
+
+	return pm.uMetallic.Get()
+}
+
+// SetRoughness sets the roughness factor from 0.0 (smooth) to 1.0 (rough).
+// It is multiplied by the green channel of the metallic-roughness map if set.
+// The default is 1.0.
+func (pm *Physical) SetRoughness(roughness float32) {
+
+	pm.uRoughness.Set(roughness)
+}
+
+// Roughness returns the current roughness factor
+func (pm *Physical) Roughness() float32 {
+
+	return pm.uRoughness.Get()
+}
+
+// SetOcclusionStrength sets how much the occlusion map darkens
+// the ambient and environment lighting, from 0.0 to 1.0 (default).
+func (pm *Physical) SetOcclusionStrength(strength float32) {
+
+	pm.uOcclusion.Set(strength)
+}
+
+// OcclusionStrength returns the current occlusion strength
+func (pm *Physical) OcclusionStrength() float32 {
+
+	return pm.uOcclusion.Get()
+}
+
+// SetEmissiveColor sets the color emitted by the material.
+// It is multiplied by the emissive map if set. The default is black.
+func (pm *Physical) SetEmissiveColor(color *math32.Color) {
+
+	pm.uEmissive.SetColor(color)
+}
+
+// EmissiveColor returns the current emissive color
+func (pm *Physical) EmissiveColor() math32.Color {
+
+	return pm.uEmissive.GetColor()
+}
+
+// SetBaseColorMap sets the texture with the base color and opacity
+// or removes it if nil.
+// The maps of the material must be different textures objects, as
+// each one has the names of the uniforms of its role.
+func (pm *Physical) SetBaseColorMap(tex *texture.Texture2D) {
+
+	pm.setMap(&pm.baseColorMap, tex, "MatBaseColorMap", "HAS_BASECOLORMAP")
+}
+
+// BaseColorMap returns the current base color texture or nil
+func (pm *Physical) BaseColorMap() *texture.Texture2D {
+
+	return pm.baseColorMap
+}
+
+// SetMetallicRoughnessMap sets the texture with the metallic factor
+// in the blue channel and the roughness factor in the green channel
+// or removes it if nil.
+func (pm *Physical) SetMetallicRoughnessMap(tex *texture.Texture2D) {
+
+	pm.setMap(&pm.metallicRoughMap, tex, "MatMetallicRoughnessMap", "HAS_METALLICROUGHNESSMAP")
+}
+
+// MetallicRoughnessMap returns the current metallic-roughness texture or nil
+func (pm *Physical) MetallicRoughnessMap() *texture.Texture2D {
+
+	return pm.metallicRoughMap
+}
+
+// SetOcclusionMap sets the texture with the ambient occlusion
+// in the red channel or removes it if nil.
+func (pm *Physical) SetOcclusionMap(tex *texture.Texture2D) {
+
+	pm.setMap(&pm.occlusionMap, tex, "MatOcclusionMap", "HAS_OCCLUSIONMAP")
+}
+
+// OcclusionMap returns the current occlusion texture or nil
+func (pm *Physical) OcclusionMap() *texture.Texture2D {
+
+	return pm.occlusionMap
+}
+
+// SetEmissiveMap sets the texture with the emissive color or removes it if nil.
+func (pm *Physical) SetEmissiveMap(tex *texture.Texture2D) {
+
+	pm.setMap(&pm.emissiveMap, tex, "MatEmissiveMap", "HAS_EMISSIVEMAP")
+}
+
+// EmissiveMap returns the current emissive texture or nil
+func (pm *Physical) EmissiveMap() *texture.Texture2D {
+
+	return pm.emissiveMap
+}
+
+// SetEnvMap sets the cube map used for the image based lighting of the
+// material or removes it if nil. The diffuse lighting is sampled from the
+// lowest mipmap level and the specular reflections from the level
+// corresponding to the roughness, so the cube map should have mipmaps.
+func (pm *Physical) SetEnvMap(tex *texture.TextureCube) {
+
+	pm.envMap = tex
+	if tex == nil {
+		pm.texUnits = 0
+		pm.defines.Unset("HAS_ENVMAP")
+		return
+	}
+	pm.texUnits = 1
+	pm.defines.Set("HAS_ENVMAP", "")
+}
+
+// EnvMap returns the current environment cube map or nil
+func (pm *Physical) EnvMap() *texture.TextureCube {
+
+	return pm.envMap
+}
+
+// SetEnvIntensity sets the factor which multiplies the
+// environment lighting (default = 1.0)
+func (pm *Physical) SetEnvIntensity(intensity float32) {
+
+	pm.uEnvIntensity.Set(intensity)
+}
+
+// EnvIntensity returns the current environment lighting factor
+func (pm *Physical) EnvIntensity() float32 {
+
+	return pm.uEnvIntensity.Get()
+}
+
+// RenderSetup is called by the renderer before drawing objects with this material
+func (pm *Physical) RenderSetup(gs *gls.GLS) {
+
+	pm.Material.RenderSetup(gs)
+
+	pm.uBaseColor.Transfer(gs)
+	pm.uMetallic.Transfer(gs)
+	pm.uRoughness.Transfer(gs)
+	pm.uOcclusion.Transfer(gs)
+	pm.uEmissive.Transfer(gs)
+
+	// The environment map uses the unit after the material textures and maps
+	if pm.envMap != nil {
+		pm.envMap.RenderSetup(gs, len(pm.textures)+len(pm.maps))
+		pm.uEnvIntensity.Transfer(gs)
+	}
+}
+
+// Dispose decrements this material reference count and if necessary
+// releases its textures including the environment map.
+func (pm *Physical) Dispose() {
+
+	if pm.refcount > 1 {
+		pm.Material.Dispose()
+		return
+	}
+	if pm.envMap != nil {
+		pm.envMap.Dispose()
+	}
+	pm.Material.Dispose()
+	pm.Init()
+}
@@ -0,0 +1,394 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
+)
+
+// IShader is the interface of the materials rendered by a program generated
+// from their own vertex and fragment shaders templates, such as Shader.
+// The renderer adds the program of these materials when first rendered.
+type IShader interface {
+	IMaterial
+	Shaders() (string, string)
+}
+
+// Shader is a material rendered by a program generated from the specified
+// vertex and fragment shaders templates, which were added to the renderer
+// and may use its chunks. The values of its uniforms are set by name and
+// transferred when the material is rendered, so the templates may declare
+// their own uniforms. The extras of the material are available to the
+// templates as {{.Extras.NAME}} and its defines as preprocessor symbols.
+type Shader struct {
+	Material                           // Embedded material
+	vertex   string                    // Vertex shader template name
+	frag     string                    // Fragment shader template name
+	uniforms map[string]shaderUniform  // Maps name to uniform
+	samplers map[string]*shaderSampler // Maps name to sampler uniform and its texture
+	names    []string                  // Sorted samplers names in the order of their texture units
+}
+
+// shaderUniform is the interface of the uniforms of the Shader material
+type shaderUniform interface {
+	Transfer(gs *gls.GLS)
+}
+
+// shaderSampler is a sampler uniform of the Shader material and its texture
+type shaderSampler struct {
+	uni  gls.Uniform1i        // Texture unit uniform
+	tex  *texture.Texture2D   // 2D texture or nil
+	cube *texture.TextureCube // Cube map texture or nil
+}
+
+// NewShader creates and returns a pointer to a new shader material rendered
+// by the specified vertex and fragment shaders templates with the specified
+// uniforms values, which may be nil. See SetUniform for the types of values.
+func NewShader(vertex, frag string, uniforms map[string]interface{}) (*Shader, error) {
+
+	ms := new(Shader)
+	ms.Init(vertex, frag)
+	for name, value := range uniforms {
+		err := ms.SetUniform(name, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ms, nil
+}
+
+// Init initializes a Shader material embedded in another type
+func (ms *Shader) Init(vertex, frag string) *Shader {
+
+	ms.Material.Init()
+	ms.SetShaders(vertex, frag)
+	ms.uniforms = make(map[string]shaderUniform)
+	ms.samplers = make(map[string]*shaderSampler)
+	ms.names = nil
+	return ms
+}
+
+// SetShaders sets the names of the vertex and fragment shaders templates
+// of the program of this material. The program name is derived from them.
+func (ms *Shader) SetShaders(vertex, frag string) {
+
+	ms.vertex = vertex
+	ms.frag = frag
+	ms.SetShader(vertex + "+" + frag)
+}
+
+// Shaders satisfies the IShader interface and returns the names
+// of the vertex and fragment shaders templates of this material
+func (ms *Shader) Shaders() (string, string) {
+
+	return ms.vertex, ms.frag
+}
+
+// SetExtra sets the specified value of the template extras, available
+// to the shaders templates as {{.Extras.NAME}}. Programs are generated
+// for each distinct set of extras.
+func (ms *Shader) SetExtra(name, value string) {
+
+	ms.extras.Set(name, value)
+}
+
+// UnsetExtra removes the specified value of the template extras
+func (ms *Shader) UnsetExtra(name string) {
+
+	ms.extras.Unset(name)
+}
+
+// SetDefine defines the specified preprocessor symbol in the
+// shaders of this material with the specified value
+func (ms *Shader) SetDefine(name, value string) {
+
+	ms.defines.Set(name, value)
+}
+
+// UnsetDefine removes the definition of the specified preprocessor symbol
+func (ms *Shader) UnsetDefine(name string) {
+
+	ms.defines.Unset(name)
+}
+
+// SetUniform sets the value of the uniform with the specified name.
+// The supported values are: float32, float64, int, int32, bool,
+// math32.Vector2, Vector3, Vector4, Color, Color4, Matrix3 and Matrix4
+// or pointers to them, *texture.Texture2D and *texture.TextureCube.
+func (ms *Shader) SetUniform(name string, value interface{}) error {
+
+	switch v := value.(type) {
+	case float32:
+		ms.SetFloat(name, v)
+	case float64:
+		ms.SetFloat(name, float32(v))
+	case int:
+		ms.SetInt(name, int32(v))
+	case int32:
+		ms.SetInt(name, v)
+	case bool:
+		var i int32
+		if v {
+			i = 1
+		}
+		ms.SetInt(name, i)
+	case math32.Vector2:
+		ms.SetVector2(name, &v)
+	case *math32.Vector2:
+		ms.SetVector2(name, v)
+	case math32.Vector3:
+		ms.SetVector3(name, &v)
+	case *math32.Vector3:
+		ms.SetVector3(name, v)
+	case math32.Vector4:
+		ms.SetVector4(name, &v)
+	case *math32.Vector4:
+		ms.SetVector4(name, v)
+	case math32.Color:
+		ms.SetColor(name, &v)
+	case *math32.Color:
+		ms.SetColor(name, v)
+	case math32.Color4:
+		ms.SetColor4(name, &v)
+	case *math32.Color4:
+		ms.SetColor4(name, v)
+	case math32.Matrix3:
+		ms.SetMatrix3(name, &v)
+	case *math32.Matrix3:
+		ms.SetMatrix3(name, v)
+	case math32.Matrix4:
+		ms.SetMatrix4(name, &v)
+	case *math32.Matrix4:
+		ms.SetMatrix4(name, v)
+	case *texture.Texture2D:
+		ms.SetTexture(name, v)
+	case *texture.TextureCube:
+		ms.SetTextureCube(name, v)
+	default:
+		return fmt.Errorf("Uniform:%s has unsupported type %T", name, value)
+	}
+	return nil
+}
+
+// SetFloat sets the value of the float uniform with the specified name
+func (ms *Shader) SetFloat(name string, v float32) {
+
+	uni, ok := ms.uniforms[name].(*gls.Uniform1f)
+	if !ok {
+		uni = gls.NewUniform1f(name)
+		ms.addUniform(name, uni)
+	}
+	uni.Set(v)
+}
+
+// SetInt sets the value of the int or bool uniform with the specified name
+func (ms *Shader) SetInt(name string, v int32) {
+
+	uni, ok := ms.uniforms[name].(*gls.Uniform1i)
+	if !ok {
+		uni = gls.NewUniform1i(name)
+		ms.addUniform(name, uni)
+	}
+	uni.Set(v)
+}
+
+// SetVector2 sets the value of the vec2 uniform with the specified name
+func (ms *Shader) SetVector2(name string, v *math32.Vector2) {
+
+	uni, ok := ms.uniforms[name].(*gls.Uniform2f)
+	if !ok {
+		uni = gls.NewUniform2f(name)
+		ms.addUniform(name, uni)
+	}
+	uni.SetVector2(v)
+}
+
+// SetVector3 sets the value of the vec3 uniform with the specified name
+func (ms *Shader) SetVector3(name string, v *math32.Vector3) {
+
+	uni, ok := ms.uniforms[name].(*gls.Uniform3f)
+	if !ok {
+		uni = gls.NewUniform3f(name)
+		ms.addUniform(name, uni)
+	}
+	uni.SetVector3(v)
+}
+
+// SetVector4 sets the value of the vec4 uniform with the specified name
+func (ms *Shader) SetVector4(name string, v *math32.Vector4) {
+
+	uni, ok := ms.uniforms[name].(*gls.Uniform4f)
+	if !ok {
+		uni = gls.NewUniform4f(name)
+		ms.addUniform(name, uni)
+	}
+	uni.SetVector4(v)
+}
+
+// SetColor sets the value of the vec3 uniform with the specified name
+func (ms *Shader) SetColor(name string, color *math32.Color) {
+
+	uni, ok := ms.uniforms[name].(*gls.Uniform3f)
+	if !ok {
+		uni = gls.NewUniform3f(name)
+		ms.addUniform(name, uni)
+	}
+	uni.SetColor(color)
+}
+
+// SetColor4 sets the value of the vec4 uniform with the specified name
+func (ms *Shader) SetColor4(name string, color *math32.Color4) {
+
+	uni, ok := ms.uniforms[name].(*gls.Uniform4f)
+	if !ok {
+		uni = gls.NewUniform4f(name)
+		ms.addUniform(name, uni)
+	}
+	uni.SetColor4(color)
+}
+
+// SetMatrix3 sets the value of the mat3 uniform with the specified name
+func (ms *Shader) SetMatrix3(name string, m *math32.Matrix3) {
+
+	uni, ok := ms.uniforms[name].(*gls.UniformMatrix3f)
+	if !ok {
+		uni = gls.NewUniformMatrix3f(name)
+		ms.addUniform(name, uni)
+	}
+	uni.SetMatrix3(m)
+}
+
+// SetMatrix4 sets the value of the mat4 uniform with the specified name
+func (ms *Shader) SetMatrix4(name string, m *math32.Matrix4) {
+
+	uni, ok := ms.uniforms[name].(*gls.UniformMatrix4f)
+	if !ok {
+		uni = gls.NewUniformMatrix4f(name)
+		ms.addUniform(name, uni)
+	}
+	uni.SetMatrix4(m)
+}
+
+// SetTexture sets the texture of the sampler2D uniform with the specified name.
+// The textures of the samplers are bound after the textures and maps of the
+// material in the order of their names and are released by Dispose.
+func (ms *Shader) SetTexture(name string, tex *texture.Texture2D) {
+
+	s := ms.sampler(name)
+	s.tex = tex
+	s.cube = nil
+}
+
+// SetTextureCube sets the texture of the samplerCube uniform with the specified name
+func (ms *Shader) SetTextureCube(name string, cube *texture.TextureCube) {
+
+	s := ms.sampler(name)
+	s.tex = nil
+	s.cube = cube
+}
+
+// RemoveUniform removes the uniform with the specified name
+// from this material, which is not transferred anymore
+func (ms *Shader) RemoveUniform(name string) {
+
+	delete(ms.uniforms, name)
+	if _, ok := ms.samplers[name]; !ok {
+		return
+	}
+	delete(ms.samplers, name)
+	for pos, curr := range ms.names {
+		if curr == name {
+			ms.names = append(ms.names[:pos], ms.names[pos+1:]...)
+			break
+		}
+	}
+	ms.texUnits = len(ms.names)
+}
+
+// HasUniform returns if this material has the uniform with the specified name
+func (ms *Shader) HasUniform(name string) bool {
+
+	_, ok := ms.uniforms[name]
+	if !ok {
+		_, ok = ms.samplers[name]
+	}
+	return ok
+}
+
+// addUniform adds the specified uniform replacing
+// any previous uniform with the same name
+func (ms *Shader) addUniform(name string, uni shaderUniform) {
+
+	ms.RemoveUniform(name)
+	ms.uniforms[name] = uni
+}
+
+// sampler returns the sampler with the specified name,
+// replacing any other uniform with the same name
+func (ms *Shader) sampler(name string) *shaderSampler {
+
+	s, ok := ms.samplers[name]
+	if ok {
+		return s
+	}
+	ms.RemoveUniform(name)
+	s = new(shaderSampler)
+	s.uni.Init(name)
+	ms.samplers[name] = s
+	ms.names = append(ms.names, name)
+	sort.Strings(ms.names)
+	ms.texUnits = len(ms.names)
+	return s
+}
+
+// RenderSetup is called by the renderer before drawing objects with this material
+func (ms *Shader) RenderSetup(gs *gls.GLS) {
+
+	ms.Material.RenderSetup(gs)
+
+	for _, uni := range ms.uniforms {
+		uni.Transfer(gs)
+	}
+
+	// The samplers use the units after the material textures and maps
+	unit := len(ms.textures) + len(ms.maps)
+	for _, name := range ms.names {
+		s := ms.samplers[name]
+		if s.tex != nil {
+			s.tex.Bind(gs, unit)
+		} else if s.cube != nil {
+			s.cube.Bind(gs, unit)
+		}
+		s.uni.Set(int32(unit))
+		s.uni.Transfer(gs)
+		unit++
+	}
+}
+
+// Dispose decrements this material reference count and if necessary
+// releases its textures including the textures of its samplers.
+func (ms *Shader) Dispose() {
+
+	if ms.refcount > 1 {
+		ms.Material.Dispose()
+		return
+	}
+	for _, s := range ms.samplers {
+		if s.tex != nil {
+			s.tex.Dispose()
+		}
+		if s.cube != nil {
+			s.cube.Dispose()
+		}
+	}
+	vertex, frag := ms.vertex, ms.frag
+	ms.Material.Dispose()
+	ms.Init(vertex, frag)
+}
@@ -7,16 +7,48 @@ package material
 import (
 	"github.com/g3n/engine/gls"
 	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
 )
 
+// EnvMapMode specifies how the environment map of a material is sampled
+type EnvMapMode int
+
+const (
+	EnvMapReflection EnvMapMode = 0 // The environment is reflected by the surface
+	EnvMapRefraction EnvMapMode = 1 // The environment is refracted through the surface
+)
+
+// Standard is a material lit by the Phong reflection model, computed for
+// each vertex by the standard shader and for each fragment by the Phong
+// shader. Besides the textures list, whose colors are combined and multiply
+// the ambient and diffuse colors, it may have texture maps with specific
+// roles. The normal, bump, specular, emissive and occlusion maps change
+// the lighting of each fragment, so they are only applied by the Phong
+// shader, which is also used to render standard materials into the
+// deferred G-buffer. The colors of an environment cube map reflected or
+// refracted by the surface may replace part of its lit ambient and diffuse
+// colors.
 type Standard struct {
-	Material                 // Embedded material
-	emissive  *gls.Uniform3f // Emissive color uniform
-	ambient   *gls.Uniform3f // Ambient color uniform
-	diffuse   *gls.Uniform3f // Diffuse color uniform
-	specular  *gls.Uniform3f // Specular color uniform
-	shininess *gls.Uniform1f // Shininess exponent uniform
-	opacity   *gls.Uniform1f // Opacity (alpha)uniform
+	Material                          // Embedded material
+	emissive     *gls.Uniform3f       // Emissive color uniform
+	ambient      *gls.Uniform3f       // Ambient color uniform
+	diffuse      *gls.Uniform3f       // Diffuse color uniform
+	specular     *gls.Uniform3f       // Specular color uniform
+	shininess    *gls.Uniform1f       // Shininess exponent uniform
+	opacity      *gls.Uniform1f       // Opacity (alpha)uniform
+	normalScale  *gls.Uniform1f       // Normal map scale uniform
+	bumpScale    *gls.Uniform1f       // Bump map scale uniform
+	diffuseMap   *texture.Texture2D   // Diffuse color and opacity texture
+	normalMap    *texture.Texture2D   // Tangent space normals texture
+	bumpMap      *texture.Texture2D   // Height (red) texture
+	specularMap  *texture.Texture2D   // Specular color texture
+	emissiveMap  *texture.Texture2D   // Emissive color texture
+	occlusionMap *texture.Texture2D   // Ambient occlusion (red) texture
+	alphaMap     *texture.Texture2D   // Opacity (red) texture
+	reflectivity *gls.Uniform1f       // Environment map reflectivity uniform
+	refraction   *gls.Uniform1f       // Environment map refraction ratio uniform
+	envMap       *texture.TextureCube // Environment cube map
+	envMode      EnvMapMode           // Environment map mode
 }
 
 // NewStandard creates and returns a pointer to a new standard material
@@ -31,6 +63,7 @@ func (ms *Standard) Init(shader string, color *math32.Color) {
 
 	ms.Material.Init()
 	ms.SetShader(shader)
+	ms.SetDeferredShader("shaderPhong")
 
 	// Creates uniforms and adds to material
 	ms.emissive = gls.NewUniform3f("MatEmissiveColor")
@@ -39,6 +72,10 @@ func (ms *Standard) Init(shader string, color *math32.Color) {
 	ms.specular = gls.NewUniform3f("MatSpecularColor")
 	ms.shininess = gls.NewUniform1f("MatShininess")
 	ms.opacity = gls.NewUniform1f("MatOpacity")
+	ms.normalScale = gls.NewUniform1f("MatNormalScale")
+	ms.bumpScale = gls.NewUniform1f("MatBumpScale")
+	ms.reflectivity = gls.NewUniform1f("MatReflectivity")
+	ms.refraction = gls.NewUniform1f("MatRefractionRatio")
 
 	// Set initial values
 	ms.emissive.Set(0, 0, 0)
@@ -47,6 +84,20 @@ func (ms *Standard) Init(shader string, color *math32.Color) {
 	ms.specular.Set(0.5, 0.5, 0.5)
 	ms.shininess.Set(30.0)
 	ms.opacity.Set(1.0)
+	ms.normalScale.Set(1.0)
+	ms.bumpScale.Set(1.0)
+	ms.reflectivity.Set(1.0)
+	ms.refraction.Set(0.98)
+
+	ms.diffuseMap = nil
+	ms.normalMap = nil
+	ms.bumpMap = nil
+	ms.specularMap = nil
+	ms.emissiveMap = nil
+	ms.occlusionMap = nil
+	ms.alphaMap = nil
+	ms.envMap = nil
+	ms.envMode = EnvMapReflection
 }
 
 // AmbientColor returns the material ambient color reflectivity.
@@ -102,6 +153,193 @@ func (ms *Standard) SetOpacity(opacity float32) {
 	ms.opacity.Set(opacity)
 }
 
+// SetDiffuseMap sets the texture with the diffuse color and opacity, which
+// multiply the ambient and diffuse colors and the opacity, or removes it if nil.
+// The maps of the material must be different textures objects, as
+// each one has the names of the uniforms of its role.
+func (ms *Standard) SetDiffuseMap(tex *texture.Texture2D) {
+
+	ms.setMap(&ms.diffuseMap, tex, "MatDiffuseMap", "HAS_DIFFUSEMAP")
+}
+
+// DiffuseMap returns the current diffuse texture or nil
+func (ms *Standard) DiffuseMap() *texture.Texture2D {
+
+	return ms.diffuseMap
+}
+
+// SetNormalMap sets the texture with the normals in tangent space or
+// removes it if nil. The tangents of the geometries without the
+// VertexTangent attribute are computed from their texture coordinates
+// when rendered with this material.
+func (ms *Standard) SetNormalMap(tex *texture.Texture2D) {
+
+	ms.setMap(&ms.normalMap, tex, "MatNormalMap", "HAS_NORMALMAP")
+}
+
+// NormalMap returns the current normal texture or nil
+func (ms *Standard) NormalMap() *texture.Texture2D {
+
+	return ms.normalMap
+}
+
+// SetNormalScale sets the factor which multiplies the X and Y
+// components of the normals of the normal map (default = 1.0)
+func (ms *Standard) SetNormalScale(scale float32) {
+
+	ms.normalScale.Set(scale)
+}
+
+// NormalScale returns the current normal map scale
+func (ms *Standard) NormalScale() float32 {
+
+	return ms.normalScale.Get()
+}
+
+// SetBumpMap sets the texture with the heights of the surface in the red
+// channel, which perturb the normals, or removes it if nil.
+func (ms *Standard) SetBumpMap(tex *texture.Texture2D) {
+
+	ms.setMap(&ms.bumpMap, tex, "MatBumpMap", "HAS_BUMPMAP")
+}
+
+// BumpMap returns the current bump texture or nil
+func (ms *Standard) BumpMap() *texture.Texture2D {
+
+	return ms.bumpMap
+}
+
+// SetBumpScale sets the factor which multiplies the heights of the bump map (default = 1.0)
+func (ms *Standard) SetBumpScale(scale float32) {
+
+	ms.bumpScale.Set(scale)
+}
+
+// BumpScale returns the current bump map scale
+func (ms *Standard) BumpScale() float32 {
+
+	return ms.bumpScale.Get()
+}
+
+// SetSpecularMap sets the texture with the specular color, which
+// multiplies the material specular color, or removes it if nil.
+func (ms *Standard) SetSpecularMap(tex *texture.Texture2D) {
+
+	ms.setMap(&ms.specularMap, tex, "MatSpecularMap", "HAS_SPECULARMAP")
+}
+
+// SpecularMap returns the current specular texture or nil
+func (ms *Standard) SpecularMap() *texture.Texture2D {
+
+	return ms.specularMap
+}
+
+// SetEmissiveMap sets the texture with the emissive color, which
+// multiplies the material emissive color, or removes it if nil.
+func (ms *Standard) SetEmissiveMap(tex *texture.Texture2D) {
+
+	ms.setMap(&ms.emissiveMap, tex, "MatEmissiveMap", "HAS_EMISSIVEMAP")
+}
+
+// EmissiveMap returns the current emissive texture or nil
+func (ms *Standard) EmissiveMap() *texture.Texture2D {
+
+	return ms.emissiveMap
+}
+
+// SetOcclusionMap sets the texture with the ambient occlusion in the red
+// channel, which multiplies the ambient lighting, or removes it if nil.
+func (ms *Standard) SetOcclusionMap(tex *texture.Texture2D) {
+
+	ms.setMap(&ms.occlusionMap, tex, "MatOcclusionMap", "HAS_OCCLUSIONMAP")
+}
+
+// OcclusionMap returns the current occlusion texture or nil
+func (ms *Standard) OcclusionMap() *texture.Texture2D {
+
+	return ms.occlusionMap
+}
+
+// SetAlphaMap sets the texture with the opacity in the red channel,
+// which multiplies the material opacity, or removes it if nil.
+func (ms *Standard) SetAlphaMap(tex *texture.Texture2D) {
+
+	ms.setMap(&ms.alphaMap, tex, "MatAlphaMap", "HAS_ALPHAMAP")
+}
+
+// AlphaMap returns the current opacity texture or nil
+func (ms *Standard) AlphaMap() *texture.Texture2D {
+
+	return ms.alphaMap
+}
+
+// SetEnvMap sets the cube map with the environment reflected or refracted
+// by the surface of the material or removes it if nil. The cube map is
+// sampled in world coordinates, as the background of the environment.
+func (ms *Standard) SetEnvMap(tex *texture.TextureCube) {
+
+	ms.envMap = tex
+	if tex == nil {
+		ms.texUnits = 0
+		ms.defines.Unset("HAS_ENVMAP")
+		return
+	}
+	ms.texUnits = 1
+	ms.defines.Set("HAS_ENVMAP", "")
+}
+
+// EnvMap returns the current environment cube map or nil
+func (ms *Standard) EnvMap() *texture.TextureCube {
+
+	return ms.envMap
+}
+
+// SetEnvMapMode sets if the environment map is reflected
+// or refracted by the surface (default = EnvMapReflection)
+func (ms *Standard) SetEnvMapMode(mode EnvMapMode) {
+
+	ms.envMode = mode
+	if mode == EnvMapRefraction {
+		ms.defines.Set("ENVMAP_REFRACTION", "")
+	} else {
+		ms.defines.Unset("ENVMAP_REFRACTION")
+	}
+}
+
+// EnvMapMode returns the current environment map mode
+func (ms *Standard) EnvMapMode() EnvMapMode {
+
+	return ms.envMode
+}
+
+// SetReflectivity sets the fraction of the lit ambient and diffuse colors
+// replaced by the color of the environment map, from 0.0 to 1.0 (default).
+func (ms *Standard) SetReflectivity(reflectivity float32) {
+
+	ms.reflectivity.Set(reflectivity)
+}
+
+// Reflectivity returns the current environment map reflectivity
+func (ms *Standard) Reflectivity() float32 {
+
+	return ms.reflectivity.Get()
+}
+
+// SetRefractionRatio sets the ratio between the indices of refraction of
+// the medium outside and inside the surface used when the environment map
+// is refracted (default = 0.98)
+func (ms *Standard) SetRefractionRatio(ratio float32) {
+
+	ms.refraction.Set(ratio)
+}
+
+// RefractionRatio returns the current refraction ratio
+func (ms *Standard) RefractionRatio() float32 {
+
+	return ms.refraction.Get()
+}
+
+// RenderSetup is called by the renderer before drawing objects with this material
 func (ms *Standard) RenderSetup(gs *gls.GLS) {
 
 	ms.Material.RenderSetup(gs)
@@ -112,4 +350,32 @@ func (ms *Standard) RenderSetup(gs *gls.GLS) {
 	ms.specular.Transfer(gs)
 	ms.shininess.Transfer(gs)
 	ms.opacity.Transfer(gs)
+	if ms.normalMap != nil {
+		ms.normalScale.Transfer(gs)
+	}
+	if ms.bumpMap != nil {
+		ms.bumpScale.Transfer(gs)
+	}
+
+	// The environment map uses the unit after the material textures and maps
+	if ms.envMap != nil {
+		ms.envMap.RenderSetup(gs, len(ms.textures)+len(ms.maps))
+		ms.reflectivity.Transfer(gs)
+		ms.refraction.Transfer(gs)
+	}
+}
+
+// Dispose decrements this material reference count and if necessary
+// releases its textures including the environment map.
+func (ms *Standard) Dispose() {
+
+	if ms.refcount > 1 {
+		ms.Material.Dispose()
+		return
+	}
+	if ms.envMap != nil {
+		ms.envMap.Dispose()
+		ms.SetEnvMap(nil)
+	}
+	ms.Material.Dispose()
 }
@@ -94,14 +94,21 @@ func (this *Frustum) SetFromMatrix(m *Matrix4) *Frustum {
 	return this
 }
 
-/**
-SHOULD NOT DEPEND on core package (Move to core ?)
-func (this *Frustum) IntersectsObject(geometry *core.Geometry) bool {
-
-
-    return false
+// IntersectsObject checks if an object with the specified bounding sphere
+// and bounding box, both in model coordinates, intersects this frustum
+// after being transformed by the specified world matrix.
+// The bounding sphere is checked first as it is cheaper to test.
+func (this *Frustum) IntersectsObject(sphere *Sphere, box *Box3, matrixWorld *Matrix4) bool {
+
+	wsphere := *sphere
+	wsphere.ApplyMatrix4(matrixWorld)
+	if !this.IntersectsSphere(&wsphere) {
+		return false
+	}
+	wbox := *box
+	wbox.ApplyMatrix4(matrixWorld)
+	return this.IntersectsBox(&wbox)
 }
-*/
 
 func (this *Frustum) IntersectsSphere(sphere *Sphere) bool {
 
@@ -127,32 +134,23 @@ func (this *Frustum) IntersectsBox(box *Box3) bool {
 		plane := &this.planes[i]
 		if plane.normal.X > 0 {
 			p1.X = box.Min.X
-		} else {
-			p1.X = box.Max.X
-		}
-		if plane.normal.X > 0 {
 			p2.X = box.Max.X
 		} else {
+			p1.X = box.Max.X
 			p2.X = box.Min.X
 		}
 		if plane.normal.Y > 0 {
-			p2.Y = box.Min.Y
-		} else {
-			p2.Y = box.Max.Y
-		}
-		if plane.normal.Y > 0 {
+			p1.Y = box.Min.Y
 			p2.Y = box.Max.Y
 		} else {
+			p1.Y = box.Max.Y
 			p2.Y = box.Min.Y
 		}
 		if plane.normal.Z > 0 {
 			p1.Z = box.Min.Z
+			p2.Z = box.Max.Z
 		} else {
 			p1.Z = box.Max.Z
-		}
-		if plane.normal.Z > 0 {
-			p1.Z = box.Max.Z
-		} else {
 			p2.Z = box.Min.Z
 		}
 
@@ -0,0 +1,150 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postprocess
+
+import (
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/renderer"
+)
+
+// BloomPass adds a glow around the bright areas of the image.
+// The bright areas are extracted and blurred at half resolution
+// and then added to the image.
+type BloomPass struct {
+	Pass                              // Embedded pass
+	bright     *ShaderPass            // Bright areas extraction pass
+	blurH      *ShaderPass            // Horizontal blur pass
+	blurV      *ShaderPass            // Vertical blur pass
+	composite  *ShaderPass            // Composite pass
+	targetA    *renderer.RenderTarget // Half resolution target
+	targetB    *renderer.RenderTarget // Half resolution target
+	uThreshold gls.Uniform1f          // Luminance threshold uniform
+	uDirH      gls.Uniform2f          // Horizontal blur direction uniform
+	uDirV      gls.Uniform2f          // Vertical blur direction uniform
+	uBloom     gls.Uniform1i          // Blurred texture unit uniform
+	uStrength  gls.Uniform1f          // Bloom strength uniform
+}
+
+// NewBloomPass creates and returns a pointer to a new bloom pass
+// with the specified luminance threshold, strength and blur radius in texels.
+func NewBloomPass(threshold, strength, radius float32) *BloomPass {
+
+	p := new(BloomPass)
+	p.Pass.Init(true)
+
+	p.uThreshold.Init("BloomThreshold")
+	p.uThreshold.Set(threshold)
+	p.bright = NewShaderPass("postBloomBright")
+	p.bright.AddUniform(&p.uThreshold)
+
+	p.uDirH.Init("BlurDirection")
+	p.uDirV.Init("BlurDirection")
+	p.blurH = NewShaderPass("postBlur")
+	p.blurH.AddUniform(&p.uDirH)
+	p.blurV = NewShaderPass("postBlur")
+	p.blurV.AddUniform(&p.uDirV)
+	p.SetRadius(radius)
+
+	p.uBloom.Init("BloomTexture")
+	p.uBloom.Set(1)
+	p.uStrength.Init("BloomStrength")
+	p.uStrength.Set(strength)
+	p.composite = NewShaderPass("postBloomComposite")
+	p.composite.AddUniform(&p.uBloom)
+	p.composite.AddUniform(&p.uStrength)
+
+	p.targetA = newTarget(1, 1)
+	p.targetB = newTarget(1, 1)
+	return p
+}
+
+// SetThreshold sets the luminance above which the image is bloomed
+func (p *BloomPass) SetThreshold(threshold float32) {
+
+	p.uThreshold.Set(threshold)
+}
+
+// Threshold returns the current luminance threshold
+func (p *BloomPass) Threshold() float32 {
+
+	return p.uThreshold.Get()
+}
+
+// SetStrength sets the factor which multiplies the blurred bright areas
+func (p *BloomPass) SetStrength(strength float32) {
+
+	p.uStrength.Set(strength)
+}
+
+// Strength returns the current bloom strength
+func (p *BloomPass) Strength() float32 {
+
+	return p.uStrength.Get()
+}
+
+// SetRadius sets the distance in texels between the blur samples
+func (p *BloomPass) SetRadius(radius float32) {
+
+	p.uDirH.Set(radius, 0)
+	p.uDirV.Set(0, radius)
+}
+
+// Radius returns the current distance in texels between the blur samples
+func (p *BloomPass) Radius() float32 {
+
+	radius, _ := p.uDirH.Get()
+	return radius
+}
+
+// SetSize satisfies the IPass interface and resizes the half resolution targets
+func (p *BloomPass) SetSize(width, height int) {
+
+	width = width / 2
+	if width < 1 {
+		width = 1
+	}
+	height = height / 2
+	if height < 1 {
+		height = 1
+	}
+	p.targetA.SetSize(width, height)
+	p.targetB.SetSize(width, height)
+	p.bright.SetSize(width, height)
+	p.blurH.SetSize(width, height)
+	p.blurV.SetSize(width, height)
+}
+
+// Render satisfies the IPass interface
+func (p *BloomPass) Render(c *EffectComposer, read, write *renderer.RenderTarget) error {
+
+	// Extracts and blurs the bright areas
+	err := p.bright.Render(c, read, p.targetA)
+	if err != nil {
+		return err
+	}
+	err = p.blurH.Render(c, p.targetA, p.targetB)
+	if err != nil {
+		return err
+	}
+	err = p.blurV.Render(c, p.targetB, p.targetA)
+	if err != nil {
+		return err
+	}
+
+	// Adds the blurred bright areas to the image
+	err = p.composite.setup(c, read)
+	if err != nil {
+		return err
+	}
+	p.targetA.ColorTexture(0).Bind(c.gs, 1)
+	return c.RenderQuad(write)
+}
+
+// Dispose satisfies the IPass interface and releases the render targets
+func (p *BloomPass) Dispose() {
+
+	p.targetA.Dispose()
+	p.targetB.Dispose()
+}
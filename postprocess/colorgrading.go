@@ -0,0 +1,89 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postprocess
+
+import (
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// ColorGradingPass adjusts the colors of the image
+type ColorGradingPass struct {
+	ShaderPass                 // Embedded shader pass
+	uColorFilter gls.Uniform3f // Color filter uniform
+	uBrightness  gls.Uniform1f // Brightness uniform
+	uContrast    gls.Uniform1f // Contrast uniform
+	uSaturation  gls.Uniform1f // Saturation uniform
+}
+
+// NewColorGradingPass creates and returns a pointer to a new
+// color grading pass which initially does not change the image.
+func NewColorGradingPass() *ColorGradingPass {
+
+	p := new(ColorGradingPass)
+	p.ShaderPass.Init("postColorGrading")
+	p.uColorFilter.Init("GradingColorFilter")
+	p.uBrightness.Init("GradingBrightness")
+	p.uContrast.Init("GradingContrast")
+	p.uSaturation.Init("GradingSaturation")
+	p.uColorFilter.Set(1, 1, 1)
+	p.uBrightness.Set(0)
+	p.uContrast.Set(1)
+	p.uSaturation.Set(1)
+	p.AddUniform(&p.uColorFilter)
+	p.AddUniform(&p.uBrightness)
+	p.AddUniform(&p.uContrast)
+	p.AddUniform(&p.uSaturation)
+	return p
+}
+
+// SetColorFilter sets the color which multiplies the image colors (default = white)
+func (p *ColorGradingPass) SetColorFilter(color *math32.Color) {
+
+	p.uColorFilter.SetColor(color)
+}
+
+// ColorFilter returns the current color filter
+func (p *ColorGradingPass) ColorFilter() math32.Color {
+
+	return p.uColorFilter.GetColor()
+}
+
+// SetBrightness sets the value added to the image colors (default = 0)
+func (p *ColorGradingPass) SetBrightness(brightness float32) {
+
+	p.uBrightness.Set(brightness)
+}
+
+// Brightness returns the current brightness
+func (p *ColorGradingPass) Brightness() float32 {
+
+	return p.uBrightness.Get()
+}
+
+// SetContrast sets the contrast factor (default = 1)
+func (p *ColorGradingPass) SetContrast(contrast float32) {
+
+	p.uContrast.Set(contrast)
+}
+
+// Contrast returns the current contrast factor
+func (p *ColorGradingPass) Contrast() float32 {
+
+	return p.uContrast.Get()
+}
+
+// SetSaturation sets the saturation factor. Zero converts
+// the image to grayscale (default = 1)
+func (p *ColorGradingPass) SetSaturation(saturation float32) {
+
+	p.uSaturation.Set(saturation)
+}
+
+// Saturation returns the current saturation factor
+func (p *ColorGradingPass) Saturation() float32 {
+
+	return p.uSaturation.Get()
+}
@@ -0,0 +1,254 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postprocess
+
+import (
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/renderer"
+)
+
+// EffectComposer renders a chain of passes alternating between
+// two render targets. Each pass reads the result of the previous pass
+// and the last enabled pass renders to the default framebuffer.
+type EffectComposer struct {
+	gs       *gls.GLS               // OpenGL state
+	renderer *renderer.Renderer     // Renderer used by the scene passes
+	shaman   *renderer.Shaman       // Shader manager of the renderer
+	read     *renderer.RenderTarget // Render target read by the next pass
+	write    *renderer.RenderTarget // Render target written by the next pass
+	passes   []IPass                // List of passes
+	quad     *geometry.Geometry     // Full screen quad geometry
+	width    int                    // Current width in pixels
+	height   int                    // Current height in pixels
+	viewport [4]int32               // Viewport used to render to the default framebuffer
+}
+
+// NewEffectComposer creates and returns a pointer to a new effect composer
+// with the specified size, which uses the specified renderer for scene passes.
+// The default shaders must be already added to the renderer.
+func NewEffectComposer(gs *gls.GLS, r *renderer.Renderer, width, height int) (*EffectComposer, error) {
+
+	c := new(EffectComposer)
+	c.gs = gs
+	c.renderer = r
+	c.shaman = r.Shaman()
+	c.passes = make([]IPass, 0)
+	c.width = width
+	c.height = height
+	c.read = newTarget(width, height)
+	c.write = newTarget(width, height)
+
+	// Creates full screen quad in normalized device coordinates
+	positions := math32.NewArrayF32(0, 12)
+	positions.Append(-1, -1, 0, 1, -1, 0, 1, 1, 0, -1, 1, 0)
+	uvs := math32.NewArrayF32(0, 8)
+	uvs.Append(0, 0, 1, 0, 1, 1, 0, 1)
+	indices := math32.NewArrayU32(0, 6)
+	indices.Append(0, 1, 2, 0, 2, 3)
+	c.quad = geometry.NewGeometry()
+	c.quad.SetIndices(indices)
+	c.quad.AddVBO(gls.NewVBO().AddAttrib("VertexPosition", 3).SetBuffer(positions))
+	c.quad.AddVBO(gls.NewVBO().AddAttrib("VertexTexcoord", 2).SetBuffer(uvs))
+
+	// Adds the chunks and shaders of the built-in passes
+	for name, source := range chunks {
+		err := c.shaman.AddChunk(name, source)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for name, source := range shaders {
+		err := c.shaman.AddShader(name, source)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for name, pinfo := range programs {
+		err := c.shaman.AddProgram(name, pinfo.Vertex, pinfo.Frag)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// newTarget creates a render target with a floating point color texture
+// and no depth texture, as used by the composer passes.
+func newTarget(width, height int) *renderer.RenderTarget {
+
+	rt := renderer.NewRenderTarget(width, height)
+	rt.SetColorFormat(0, gls.RGBA, gls.FLOAT, gls.RGBA16F)
+	return rt
+}
+
+// AddChunk adds a shader chunk which can be used by the shaders of custom passes.
+// The "pass_inputs" chunk declares the inputs and output of pass fragment shaders.
+func (c *EffectComposer) AddChunk(name, source string) error {
+
+	return c.shaman.AddChunk(name, source)
+}
+
+// AddShader adds a shader template which can be used by custom passes
+func (c *EffectComposer) AddShader(name, source string) error {
+
+	return c.shaman.AddShader(name, source)
+}
+
+// AddProgram adds a program for custom passes with the specified vertex
+// and fragment shaders names. The vertex shader "postVertex" renders
+// the full screen quad and outputs its texture coordinates in FragTexcoord.
+func (c *EffectComposer) AddProgram(name, vertex, frag string) error {
+
+	return c.shaman.AddProgram(name, vertex, frag)
+}
+
+// AddPass appends the specified pass to the chain of passes
+func (c *EffectComposer) AddPass(pass IPass) {
+
+	pass.SetSize(c.width, c.height)
+	c.passes = append(c.passes, pass)
+}
+
+// InsertPass inserts the specified pass at the specified position
+func (c *EffectComposer) InsertPass(pass IPass, pos int) {
+
+	pass.SetSize(c.width, c.height)
+	c.passes = append(c.passes, nil)
+	copy(c.passes[pos+1:], c.passes[pos:])
+	c.passes[pos] = pass
+}
+
+// RemovePass removes the specified pass and returns if it was found
+func (c *EffectComposer) RemovePass(pass IPass) bool {
+
+	for pos, current := range c.passes {
+		if current == pass {
+			copy(c.passes[pos:], c.passes[pos+1:])
+			c.passes[len(c.passes)-1] = nil
+			c.passes = c.passes[:len(c.passes)-1]
+			return true
+		}
+	}
+	return false
+}
+
+// Passes returns the list of passes of this composer
+func (c *EffectComposer) Passes() []IPass {
+
+	return c.passes
+}
+
+// SetSize sets the size of the composer render targets and of all its passes.
+// It should be called when the window is resized.
+func (c *EffectComposer) SetSize(width, height int) {
+
+	c.width = width
+	c.height = height
+	c.read.SetSize(width, height)
+	c.write.SetSize(width, height)
+	for _, pass := range c.passes {
+		pass.SetSize(width, height)
+	}
+}
+
+// Size returns the current width and height of the composer
+func (c *EffectComposer) Size() (int, int) {
+
+	return c.width, c.height
+}
+
+// Renderer returns the renderer used by this composer
+func (c *EffectComposer) Renderer() *renderer.Renderer {
+
+	return c.renderer
+}
+
+// Render renders all the enabled passes. The last enabled pass
+// renders to the default framebuffer.
+func (c *EffectComposer) Render() error {
+
+	// Finds the last enabled pass
+	last := -1
+	for i, pass := range c.passes {
+		if pass.Enabled() {
+			last = i
+		}
+	}
+
+	// Saves the current viewport which is used to render to the screen
+	vx, vy, vwidth, vheight := c.gs.GetViewport()
+	c.viewport = [4]int32{vx, vy, vwidth, vheight}
+	defer c.gs.Viewport(vx, vy, vwidth, vheight)
+
+	for i := 0; i <= last; i++ {
+		pass := c.passes[i]
+		if !pass.Enabled() {
+			continue
+		}
+		write := c.write
+		if i == last {
+			write = nil
+			c.gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+			c.gs.Viewport(vx, vy, vwidth, vheight)
+		}
+		err := pass.Render(c, c.read, write)
+		if err != nil {
+			return err
+		}
+		if pass.NeedsSwap() {
+			c.read, c.write = c.write, c.read
+		}
+	}
+	c.gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+	return nil
+}
+
+// SetProgram sets the current program for a full screen pass and the
+// OpenGL states used to render the full screen quad.
+func (c *EffectComposer) SetProgram(specs *renderer.ShaderSpecs) error {
+
+	_, err := c.shaman.SetProgram(specs)
+	if err != nil {
+		return err
+	}
+	c.gs.Disable(gls.DEPTH_TEST)
+	c.gs.DepthMask(false)
+	c.gs.Disable(gls.BLEND)
+	c.gs.Disable(gls.CULL_FACE)
+	c.gs.PolygonMode(gls.FRONT_AND_BACK, gls.FILL)
+	return nil
+}
+
+// RenderQuad renders the full screen quad with the current program
+// to the specified render target or to the default framebuffer if nil.
+func (c *EffectComposer) RenderQuad(target *renderer.RenderTarget) error {
+
+	if target != nil {
+		err := target.Bind(c.gs)
+		if err != nil {
+			return err
+		}
+	} else {
+		c.gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+		c.gs.Viewport(c.viewport[0], c.viewport[1], c.viewport[2], c.viewport[3])
+	}
+	c.quad.RenderSetup(c.gs)
+	c.gs.DrawElements(gls.TRIANGLES, 6, gls.UNSIGNED_INT, 0)
+	return nil
+}
+
+// Dispose releases the OpenGL resources of this composer
+// and of the render targets of its passes.
+func (c *EffectComposer) Dispose() {
+
+	c.read.Dispose()
+	c.write.Dispose()
+	c.quad.Dispose()
+	for _, pass := range c.passes {
+		pass.Dispose()
+	}
+}
@@ -0,0 +1,8 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package postprocess implements a composer of full screen passes
+// which are applied to the rendered scene, such as bloom, antialiasing,
+// vignette, color grading and tone mapping.
+package postprocess
@@ -0,0 +1,18 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postprocess
+
+// FXAAPass smooths the edges of the image using fast approximate antialiasing
+type FXAAPass struct {
+	ShaderPass // Embedded shader pass
+}
+
+// NewFXAAPass creates and returns a pointer to a new FXAA pass
+func NewFXAAPass() *FXAAPass {
+
+	p := new(FXAAPass)
+	p.ShaderPass.Init("postFXAA")
+	return p
+}
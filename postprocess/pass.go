@@ -0,0 +1,139 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postprocess
+
+import (
+	"github.com/g3n/engine/camera"
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/renderer"
+)
+
+// IPass is the interface for all the passes of an EffectComposer
+type IPass interface {
+	GetPass() *Pass
+	Enabled() bool
+	SetEnabled(bool)
+	NeedsSwap() bool
+	SetSize(width, height int)
+	Render(c *EffectComposer, read, write *renderer.RenderTarget) error
+	Dispose()
+}
+
+// Pass is the base type embedded in all passes
+type Pass struct {
+	enabled   bool // Enabled flag
+	needsSwap bool // Swap read and write targets after rendering flag
+}
+
+// Init initializes a Pass embedded in another type.
+// needsSwap indicates if the pass writes its result to the
+// write target, which then becomes the read target of the next pass.
+func (p *Pass) Init(needsSwap bool) {
+
+	p.enabled = true
+	p.needsSwap = needsSwap
+}
+
+// GetPass satisfies the IPass interface and
+// returns a pointer to the base Pass
+func (p *Pass) GetPass() *Pass {
+
+	return p
+}
+
+// SetEnabled satisfies the IPass interface and
+// sets the enabled state of this pass (default = true)
+func (p *Pass) SetEnabled(state bool) {
+
+	p.enabled = state
+}
+
+// Enabled satisfies the IPass interface and
+// returns the enabled state of this pass
+func (p *Pass) Enabled() bool {
+
+	return p.enabled
+}
+
+// NeedsSwap satisfies the IPass interface and returns if the
+// read and write targets are swapped after rendering this pass
+func (p *Pass) NeedsSwap() bool {
+
+	return p.needsSwap
+}
+
+// SetSize satisfies the IPass interface and is called by the
+// composer when its size changes. The base implementation does nothing.
+func (p *Pass) SetSize(width, height int) {
+}
+
+// Dispose satisfies the IPass interface and releases the
+// resources of this pass. The base implementation does nothing.
+func (p *Pass) Dispose() {
+}
+
+// RenderPass renders a scene with a camera
+type RenderPass struct {
+	Pass                      // Embedded pass
+	scene      core.INode     // Scene to render
+	cam        camera.ICamera // Camera used to render the scene
+	clearColor math32.Color4  // Color used to clear the target before rendering
+}
+
+// NewRenderPass creates and returns a pointer to a pass
+// which renders the specified scene with the specified camera.
+// It is normally the first pass of a composer.
+func NewRenderPass(scene core.INode, cam camera.ICamera) *RenderPass {
+
+	p := new(RenderPass)
+	p.Pass.Init(false)
+	p.scene = scene
+	p.cam = cam
+	p.clearColor.Set(0, 0, 0, 1)
+	return p
+}
+
+// SetScene sets the scene rendered by this pass
+func (p *RenderPass) SetScene(scene core.INode) {
+
+	p.scene = scene
+}
+
+// SetCamera sets the camera used to render the scene
+func (p *RenderPass) SetCamera(cam camera.ICamera) {
+
+	p.cam = cam
+}
+
+// SetClearColor sets the color used to clear the render target before
+// rendering the scene. It is not used when rendering to the default framebuffer.
+func (p *RenderPass) SetClearColor(color *math32.Color4) {
+
+	p.clearColor = *color
+}
+
+// ClearColor returns the current clear color
+func (p *RenderPass) ClearColor() math32.Color4 {
+
+	return p.clearColor
+}
+
+// Render satisfies the IPass interface and renders the scene into
+// the read target, or to the default framebuffer if it is the last pass.
+func (p *RenderPass) Render(c *EffectComposer, read, write *renderer.RenderTarget) error {
+
+	r := c.Renderer()
+	prev := r.RenderTarget()
+	if write == nil {
+		r.SetRenderTarget(nil)
+	} else {
+		read.SetClearColor(&p.clearColor)
+		r.SetRenderTarget(read)
+	}
+	err := r.Render(p.scene, p.cam)
+	r.SetRenderTarget(prev)
+	return err
+}
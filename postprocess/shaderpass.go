@@ -0,0 +1,96 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postprocess
+
+import (
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/renderer"
+)
+
+// IUniform is the interface for the uniforms of a ShaderPass.
+// It is satisfied by all the gls uniform types.
+type IUniform interface {
+	Transfer(gs *gls.GLS)
+}
+
+// ShaderPass renders a full screen quad with a program whose
+// fragment shader reads the result of the previous pass from
+// the "PassTexture" sampler at the "FragTexcoord" coordinates.
+// The "PassResolution" uniform contains the size in pixels of the pass.
+type ShaderPass struct {
+	Pass                             // Embedded pass
+	specs       renderer.ShaderSpecs // Program specs
+	uniforms    []IUniform           // User uniforms
+	uTexture    gls.Uniform1i        // Read texture unit uniform
+	uResolution gls.Uniform2f        // Resolution uniform
+}
+
+// NewShaderPass creates and returns a pointer to a new pass which
+// renders with the specified program, previously added to the composer.
+func NewShaderPass(program string) *ShaderPass {
+
+	p := new(ShaderPass)
+	p.Init(program)
+	return p
+}
+
+// Init initializes a ShaderPass embedded in another type
+func (p *ShaderPass) Init(program string) {
+
+	p.Pass.Init(true)
+	p.specs.Name = program
+	p.uniforms = make([]IUniform, 0)
+	p.uTexture.Init("PassTexture")
+	p.uResolution.Init("PassResolution")
+}
+
+// AddUniform adds a uniform which is transferred
+// each time this pass is rendered
+func (p *ShaderPass) AddUniform(uni IUniform) {
+
+	p.uniforms = append(p.uniforms, uni)
+}
+
+// Specs returns a pointer to the shader specs used to generate
+// the program of this pass
+func (p *ShaderPass) Specs() *renderer.ShaderSpecs {
+
+	return &p.specs
+}
+
+// SetSize satisfies the IPass interface and sets the resolution uniform
+func (p *ShaderPass) SetSize(width, height int) {
+
+	p.uResolution.Set(float32(width), float32(height))
+}
+
+// Render satisfies the IPass interface and renders the full screen
+// quad reading the read target and writing to the write target.
+func (p *ShaderPass) Render(c *EffectComposer, read, write *renderer.RenderTarget) error {
+
+	err := p.setup(c, read)
+	if err != nil {
+		return err
+	}
+	return c.RenderQuad(write)
+}
+
+// setup sets the program of this pass, binds the read
+// target texture and transfer the pass uniforms.
+func (p *ShaderPass) setup(c *EffectComposer, read *renderer.RenderTarget) error {
+
+	err := c.SetProgram(&p.specs)
+	if err != nil {
+		return err
+	}
+	read.ColorTexture(0).Bind(c.gs, 0)
+	p.uTexture.Set(0)
+	p.uTexture.Transfer(c.gs)
+	p.uResolution.Transfer(c.gs)
+	for _, uni := range p.uniforms {
+		uni.Transfer(c.gs)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postprocess
+
+import (
+	"github.com/g3n/engine/renderer/shader"
+)
+
+// Chunks, shaders and programs of the built-in passes,
+// added to the renderer shader manager by the composer.
+var chunks = map[string]string{
+	"pass_inputs": chunkPassInputs,
+}
+
+var shaders = map[string]string{
+	"postVertex":             postVertex,
+	"postCopyFrag":           postCopyFrag,
+	"postFXAAFrag":           postFXAAFrag,
+	"postVignetteFrag":       postVignetteFrag,
+	"postColorGradingFrag":   postColorGradingFrag,
+	"postToneMappingFrag":    postToneMappingFrag,
+	"postBloomBrightFrag":    postBloomBrightFrag,
+	"postBlurFrag":           postBlurFrag,
+	"postBloomCompositeFrag": postBloomCompositeFrag,
+}
+
+var programs = map[string]shader.ProgramInfo{
+	"postCopy":           {Vertex: "postVertex", Frag: "postCopyFrag"},
+	"postFXAA":           {Vertex: "postVertex", Frag: "postFXAAFrag"},
+	"postVignette":       {Vertex: "postVertex", Frag: "postVignetteFrag"},
+	"postColorGrading":   {Vertex: "postVertex", Frag: "postColorGradingFrag"},
+	"postToneMapping":    {Vertex: "postVertex", Frag: "postToneMappingFrag"},
+	"postBloomBright":    {Vertex: "postVertex", Frag: "postBloomBrightFrag"},
+	"postBlur":           {Vertex: "postVertex", Frag: "postBlurFrag"},
+	"postBloomComposite": {Vertex: "postVertex", Frag: "postBloomCompositeFrag"},
+}
+
+// Inputs of all the pass fragment shaders
+const chunkPassInputs = `
+// Result of the previous pass and its size in pixels
+uniform sampler2D PassTexture;
+uniform vec2 PassResolution;
+
+// Input from the vertex shader
+in vec2 FragTexcoord;
+
+// Output
+out vec4 FragColor;
+`
+
+// Vertex shader for the full screen quad
+const postVertex = `
+#version {{.Version}}
+
+layout(location = 0) in vec3 VertexPosition;
+layout(location = 3) in vec2 VertexTexcoord;
+
+// Output for the fragment shader
+out vec2 FragTexcoord;
+
+void main() {
+
+    FragTexcoord = VertexTexcoord;
+    gl_Position = vec4(VertexPosition.xy, 0.0, 1.0);
+}
+`
+
+// Copies the previous pass
+const postCopyFrag = `
+#version {{.Version}}
+
+{{template "pass_inputs" .}}
+
+void main() {
+
+    FragColor = texture(PassTexture, FragTexcoord);
+}
+`
+
+// Fast approximate antialiasing
+const postFXAAFrag = `
+#version {{.Version}}
+
+{{template "pass_inputs" .}}
+
+#define FXAA_REDUCE_MIN (1.0/128.0)
+#define FXAA_REDUCE_MUL (1.0/8.0)
+#define FXAA_SPAN_MAX   8.0
+
+void main() {
+
+    vec2 texel = 1.0 / PassResolution;
+    vec3 rgbNW = texture(PassTexture, FragTexcoord + vec2(-1.0, -1.0) * texel).rgb;
+    vec3 rgbNE = texture(PassTexture, FragTexcoord + vec2(1.0, -1.0) * texel).rgb;
+    vec3 rgbSW = texture(PassTexture, FragTexcoord + vec2(-1.0, 1.0) * texel).rgb;
+    vec3 rgbSE = texture(PassTexture, FragTexcoord + vec2(1.0, 1.0) * texel).rgb;
+    vec4 rgbaM = texture(PassTexture, FragTexcoord);
+
+    // Luminance of the samples
+    vec3 luma = vec3(0.299, 0.587, 0.114);
+    float lumaNW = dot(rgbNW, luma);
+    float lumaNE = dot(rgbNE, luma);
+    float lumaSW = dot(rgbSW, luma);
+    float lumaSE = dot(rgbSE, luma);
+    float lumaM  = dot(rgbaM.rgb, luma);
+    float lumaMin = min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)));
+    float lumaMax = max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)));
+
+    // Calculates the blur direction along the edge
+    vec2 dir;
+    dir.x = -((lumaNW + lumaNE) - (lumaSW + lumaSE));
+    dir.y =  ((lumaNW + lumaSW) - (lumaNE + lumaSE));
+    float dirReduce = max((lumaNW + lumaNE + lumaSW + lumaSE) * (0.25 * FXAA_REDUCE_MUL), FXAA_REDUCE_MIN);
+    float rcpDirMin = 1.0 / (min(abs(dir.x), abs(dir.y)) + dirReduce);
+    dir = min(vec2(FXAA_SPAN_MAX), max(vec2(-FXAA_SPAN_MAX), dir * rcpDirMin)) * texel;
+
+    vec3 rgbA = 0.5 * (
+        texture(PassTexture, FragTexcoord + dir * (1.0/3.0 - 0.5)).rgb +
+        texture(PassTexture, FragTexcoord + dir * (2.0/3.0 - 0.5)).rgb);
+    vec3 rgbB = rgbA * 0.5 + 0.25 * (
+        texture(PassTexture, FragTexcoord + dir * -0.5).rgb +
+        texture(PassTexture, FragTexcoord + dir * 0.5).rgb);
+    float lumaB = dot(rgbB, luma);
+    if ((lumaB < lumaMin) || (lumaB > lumaMax)) {
+        FragColor = vec4(rgbA, rgbaM.a);
+    } else {
+        FragColor = vec4(rgbB, rgbaM.a);
+    }
+}
+`
+
+// Darkens the borders of the image
+const postVignetteFrag = `
+#version {{.Version}}
+
+{{template "pass_inputs" .}}
+
+uniform float VignetteOffset;
+uniform float VignetteDarkness;
+
+void main() {
+
+    vec4 texel = texture(PassTexture, FragTexcoord);
+    vec2 uv = (FragTexcoord - vec2(0.5)) * vec2(VignetteOffset);
+    FragColor = vec4(mix(texel.rgb, vec3(1.0 - VignetteDarkness), dot(uv, uv)), texel.a);
+}
+`
+
+// Adjusts the color filter, brightness, contrast and saturation of the image
+const postColorGradingFrag = `
+#version {{.Version}}
+
+{{template "pass_inputs" .}}
+
+uniform vec3  GradingColorFilter;
+uniform float GradingBrightness;
+uniform float GradingContrast;
+uniform float GradingSaturation;
+
+void main() {
+
+    vec4 texel = texture(PassTexture, FragTexcoord);
+    vec3 color = texel.rgb * GradingColorFilter + vec3(GradingBrightness);
+    color = (color - vec3(0.5)) * GradingContrast + vec3(0.5);
+    float luma = dot(color, vec3(0.2126, 0.7152, 0.0722));
+    color = mix(vec3(luma), color, GradingSaturation);
+    FragColor = vec4(max(color, vec3(0.0)), texel.a);
+}
+`
+
+// Maps high dynamic range colors to the displayable range
+const postToneMappingFrag = `
+#version {{.Version}}
+
+{{template "pass_inputs" .}}
+
+uniform int   ToneOperator;
+uniform float ToneExposure;
+uniform float ToneGamma;
+
+void main() {
+
+    vec4 texel = texture(PassTexture, FragTexcoord);
+    vec3 color = texel.rgb * ToneExposure;
+    if (ToneOperator == 1) {
+        // Reinhard
+        color = color / (color + vec3(1.0));
+    } else if (ToneOperator == 2) {
+        // ACES filmic curve approximation
+        color = (color * (2.51 * color + 0.03)) / (color * (2.43 * color + 0.59) + 0.14);
+    }
+    color = pow(clamp(color, 0.0, 1.0), vec3(1.0 / ToneGamma));
+    FragColor = vec4(color, texel.a);
+}
+`
+
+// Extracts the bright areas of the image for the bloom pass
+const postBloomBrightFrag = `
+#version {{.Version}}
+
+{{template "pass_inputs" .}}
+
+uniform float BloomThreshold;
+
+void main() {
+
+    vec3 color = texture(PassTexture, FragTexcoord).rgb;
+    float luma = dot(color, vec3(0.2126, 0.7152, 0.0722));
+    float factor = max(luma - BloomThreshold, 0.0) / max(luma, 0.0001);
+    FragColor = vec4(color * factor, 1.0);
+}
+`
+
+// Separable gaussian blur along BlurDirection in texels
+const postBlurFrag = `
+#version {{.Version}}
+
+{{template "pass_inputs" .}}
+
+uniform vec2 BlurDirection;
+
+const float weights[5] = float[](0.2270270270, 0.1945945946, 0.1216216216, 0.0540540541, 0.0162162162);
+
+void main() {
+
+    vec2 offset = BlurDirection / PassResolution;
+    vec3 color = texture(PassTexture, FragTexcoord).rgb * weights[0];
+    for (int i = 1; i < 5; i++) {
+        color += texture(PassTexture, FragTexcoord + offset * float(i)).rgb * weights[i];
+        color += texture(PassTexture, FragTexcoord - offset * float(i)).rgb * weights[i];
+    }
+    FragColor = vec4(color, 1.0);
+}
+`
+
+// Adds the blurred bright areas to the image
+const postBloomCompositeFrag = `
+#version {{.Version}}
+
+{{template "pass_inputs" .}}
+
+uniform sampler2D BloomTexture;
+uniform float BloomStrength;
+
+void main() {
+
+    vec4 texel = texture(PassTexture, FragTexcoord);
+    vec3 bloom = texture(BloomTexture, FragTexcoord).rgb;
+    FragColor = vec4(texel.rgb + bloom * BloomStrength, texel.a);
+}
+`
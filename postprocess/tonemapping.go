@@ -0,0 +1,83 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postprocess
+
+import (
+	"github.com/g3n/engine/gls"
+)
+
+// ToneOperator specifies how high dynamic range colors are mapped
+type ToneOperator int
+
+// Tone mapping operators
+const (
+	ToneLinear   ToneOperator = 0 // Colors are only clamped
+	ToneReinhard ToneOperator = 1 // Reinhard operator
+	ToneACES     ToneOperator = 2 // ACES filmic curve approximation
+)
+
+// ToneMappingPass maps the high dynamic range colors
+// rendered by the previous passes to the displayable range
+type ToneMappingPass struct {
+	ShaderPass               // Embedded shader pass
+	uOperator  gls.Uniform1i // Tone operator uniform
+	uExposure  gls.Uniform1f // Exposure uniform
+	uGamma     gls.Uniform1f // Gamma uniform
+}
+
+// NewToneMappingPass creates and returns a pointer to a new
+// tone mapping pass with the specified operator
+func NewToneMappingPass(op ToneOperator) *ToneMappingPass {
+
+	p := new(ToneMappingPass)
+	p.ShaderPass.Init("postToneMapping")
+	p.uOperator.Init("ToneOperator")
+	p.uExposure.Init("ToneExposure")
+	p.uGamma.Init("ToneGamma")
+	p.uOperator.Set(int32(op))
+	p.uExposure.Set(1)
+	p.uGamma.Set(1)
+	p.AddUniform(&p.uOperator)
+	p.AddUniform(&p.uExposure)
+	p.AddUniform(&p.uGamma)
+	return p
+}
+
+// SetOperator sets the tone mapping operator
+func (p *ToneMappingPass) SetOperator(op ToneOperator) {
+
+	p.uOperator.Set(int32(op))
+}
+
+// Operator returns the current tone mapping operator
+func (p *ToneMappingPass) Operator() ToneOperator {
+
+	return ToneOperator(p.uOperator.Get())
+}
+
+// SetExposure sets the factor which multiplies the colors
+// before they are mapped (default = 1)
+func (p *ToneMappingPass) SetExposure(exposure float32) {
+
+	p.uExposure.Set(exposure)
+}
+
+// Exposure returns the current exposure
+func (p *ToneMappingPass) Exposure() float32 {
+
+	return p.uExposure.Get()
+}
+
+// SetGamma sets the gamma used to encode the mapped colors (default = 1)
+func (p *ToneMappingPass) SetGamma(gamma float32) {
+
+	p.uGamma.Set(gamma)
+}
+
+// Gamma returns the current gamma
+func (p *ToneMappingPass) Gamma() float32 {
+
+	return p.uGamma.Get()
+}
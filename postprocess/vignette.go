@@ -0,0 +1,55 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postprocess
+
+import (
+	"github.com/g3n/engine/gls"
+)
+
+// VignettePass darkens the borders of the image
+type VignettePass struct {
+	ShaderPass               // Embedded shader pass
+	uOffset    gls.Uniform1f // Vignette offset uniform
+	uDarkness  gls.Uniform1f // Vignette darkness uniform
+}
+
+// NewVignettePass creates and returns a pointer to a new vignette pass
+func NewVignettePass() *VignettePass {
+
+	p := new(VignettePass)
+	p.ShaderPass.Init("postVignette")
+	p.uOffset.Init("VignetteOffset")
+	p.uDarkness.Init("VignetteDarkness")
+	p.uOffset.Set(1.0)
+	p.uDarkness.Set(1.0)
+	p.AddUniform(&p.uOffset)
+	p.AddUniform(&p.uDarkness)
+	return p
+}
+
+// SetOffset sets the distance from the center where the vignette
+// starts. Larger values darken a larger area (default = 1.0)
+func (p *VignettePass) SetOffset(offset float32) {
+
+	p.uOffset.Set(offset)
+}
+
+// Offset returns the current vignette offset
+func (p *VignettePass) Offset() float32 {
+
+	return p.uOffset.Get()
+}
+
+// SetDarkness sets the darkness of the vignette from 0 to 1 (default = 1.0)
+func (p *VignettePass) SetDarkness(darkness float32) {
+
+	p.uDarkness.Set(darkness)
+}
+
+// Darkness returns the current vignette darkness
+func (p *VignettePass) Darkness() float32 {
+
+	return p.uDarkness.Get()
+}
@@ -0,0 +1,157 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/g3n/engine/gls"
+)
+
+// Screenshot returns the pixels of the current viewport
+// of the current framebuffer as a new RGBA image.
+// It is normally called after Render and before swapping
+// the window buffers.
+func (r *Renderer) Screenshot() *image.RGBA {
+
+	vx, vy, vwidth, vheight := r.gs.GetViewport()
+	r.gs.BindFramebuffer(gls.READ_FRAMEBUFFER, r.gs.Framebuffer())
+	return r.gs.ReadImage(vx, vy, vwidth, vheight)
+}
+
+// Image returns the pixels of the color texture with the specified index
+// of this render target as a new RGBA image. Floating point colors are
+// clamped to the range 0 to 1. The render target must have been rendered.
+func (rt *RenderTarget) Image(idx int) (*image.RGBA, error) {
+
+	if rt.gs == nil {
+		return nil, fmt.Errorf("Render target not rendered")
+	}
+	if idx < 0 || idx >= len(rt.colors) {
+		return nil, fmt.Errorf("Invalid render target color texture index:%d", idx)
+	}
+	gs := rt.gs
+	gs.BindFramebuffer(gls.READ_FRAMEBUFFER, rt.fbo)
+	gs.ReadBuffer(uint32(gls.COLOR_ATTACHMENT0 + idx))
+	img := gs.ReadImage(0, 0, int32(rt.width), int32(rt.height))
+	gs.ReadBuffer(gls.COLOR_ATTACHMENT0)
+	gs.BindFramebuffer(gls.READ_FRAMEBUFFER, gs.Framebuffer())
+	return img, nil
+}
+
+// SavePNG encodes the specified image as PNG into the specified file
+func SavePNG(img image.Image, filename string) error {
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(file, img)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Recorder saves the frames rendered by a renderer as a sequence of
+// numbered PNG files. Its time advances by a fixed timestep for each
+// captured frame, so animations driven by it produce the same frames
+// regardless of the time taken to render and save them.
+type Recorder struct {
+	r        *Renderer     // Renderer of the captured frames
+	source   *RenderTarget // Render target captured instead of the current framebuffer
+	dir      string        // Directory of the frames files
+	prefix   string        // Prefix of the frames files names
+	timestep float32       // Time between frames in seconds
+	frame    int           // Number of the next frame
+}
+
+// NewRecorder creates and returns a pointer to a new recorder which
+// saves the frames of the specified renderer into the specified directory
+// with the specified number of frames per second.
+func NewRecorder(r *Renderer, dir string, fps float32) *Recorder {
+
+	rec := new(Recorder)
+	rec.r = r
+	rec.dir = dir
+	rec.prefix = "frame"
+	rec.timestep = 1 / fps
+	return rec
+}
+
+// SetPrefix sets the prefix of the names of the frames files (default = "frame").
+// The files are named with the prefix followed by the frame number
+// with five digits and the .png extension.
+func (rec *Recorder) SetPrefix(prefix string) {
+
+	rec.prefix = prefix
+}
+
+// SetSource sets the render target whose first color texture is captured
+// instead of the current viewport of the current framebuffer.
+// A nil render target restores the default.
+func (rec *Recorder) SetSource(rt *RenderTarget) {
+
+	rec.source = rt
+}
+
+// Timestep returns the time in seconds between frames
+func (rec *Recorder) Timestep() float32 {
+
+	return rec.timestep
+}
+
+// Frame returns the number of the next frame to capture
+func (rec *Recorder) Frame() int {
+
+	return rec.frame
+}
+
+// Time returns the time in seconds of the next frame to capture,
+// which should be used to update the scene before rendering it.
+func (rec *Recorder) Time() float32 {
+
+	return float32(rec.frame) * rec.timestep
+}
+
+// Reset restarts the frames numbers and the time from zero
+func (rec *Recorder) Reset() {
+
+	rec.frame = 0
+}
+
+// Capture saves the last rendered frame into the next numbered file
+// of the recorder directory, creating it if necessary, and advances
+// the time by the timestep. It is normally called after Render and
+// before swapping the window buffers.
+func (rec *Recorder) Capture() error {
+
+	var img *image.RGBA
+	if rec.source != nil {
+		var err error
+		img, err = rec.source.Image(0)
+		if err != nil {
+			return err
+		}
+	} else {
+		img = rec.r.Screenshot()
+	}
+	err := os.MkdirAll(rec.dir, 0755)
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(rec.dir, fmt.Sprintf("%s%05d.png", rec.prefix, rec.frame))
+	err = SavePNG(img, filename)
+	if err != nil {
+		return err
+	}
+	rec.frame++
+	return nil
+}
@@ -0,0 +1,372 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"math"
+
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// Indices of the color textures of the G-buffer
+const (
+	GBufferAlbedo   = 0 // Albedo (rgb) and lighting model (a)
+	GBufferNormal   = 1 // Normal in camera coordinates (xyz) and Phong shininess (w)
+	GBufferParams   = 2 // Phong specular color or metallic and roughness (rgb) and receive shadows (a)
+	GBufferEmissive = 3 // Ambient, emissive and environment light (rgb)
+)
+
+// gbufferTextures is the number of texture units used by the G-buffer
+// textures in the light passes, including the depth texture.
+const gbufferTextures = 5
+
+// Point lights contributing less than this fraction of their color
+// are ignored, which limits the radius of their light volumes.
+const lightThreshold = 1.0 / 256
+
+// maxLightRadius is the radius of the light volumes of point lights
+// whose light does not decay with the distance.
+const maxLightRadius = 10000
+
+// deferredPath contains the objects used by the deferred render path.
+// The surfaces of the opaque graphic materials are rendered into the
+// G-buffer and the lights are then applied once per lit pixel:
+// the directional and spot lights by a pass which covers the viewport
+// and the point lights by drawing a sphere which encloses the volume
+// lit by each light.
+type deferredPath struct {
+	enabled   bool                           // Deferred path enabled flag
+	queue     renderQueue                    // Queue of graphic materials rendered into the G-buffer
+	gbuffer   *RenderTarget                  // G-buffer render target
+	sphere    *geometry.Sphere               // Light volumes geometry
+	lights    *gls.VBO                       // Point lights instances attributes
+	uShadows  gls.Uniform1f                  // Receive shadows flag uniform
+	uViewport gls.Uniform4f                  // G-buffer viewport uniform
+	uInvProj  gls.UniformMatrix4f            // Inverse projection matrix uniform
+	uTextures [gbufferTextures]gls.Uniform1i // G-buffer textures units uniforms
+}
+
+// init initializes the uniforms of the deferred path
+func (d *deferredPath) init() {
+
+	d.queue = make(renderQueue, 0)
+	d.uShadows.Init("GBufferShadows")
+	d.uViewport.Init("GBufferViewport")
+	d.uInvProj.Init("InvProjMatrix")
+	names := [gbufferTextures]string{"GBufferAlbedo", "GBufferNormal", "GBufferParams", "GBufferEmissive", "GBufferDepth"}
+	for i, name := range names {
+		d.uTextures[i].Init(name)
+		d.uTextures[i].Set(int32(i))
+	}
+}
+
+// SetDeferred enables or disables the deferred render path (default = false).
+// When enabled, the opaque graphic materials with a deferred shader are
+// rendered into the G-buffer and lit by passes which only process the pixels
+// reached by each light, so scenes may have hundreds of point lights.
+// The other materials, including the transparent ones, are rendered by the
+// forward path after the lights passes. Disabling the deferred path
+// releases the G-buffer.
+func (r *Renderer) SetDeferred(state bool) {
+
+	if !state {
+		r.disposeDeferred()
+	}
+	r.deferred.enabled = state
+}
+
+// Deferred returns the current state of the deferred render path
+func (r *Renderer) Deferred() bool {
+
+	return r.deferred.enabled
+}
+
+// GBuffer returns the render target with the G-buffer of the last
+// render which used the deferred path or nil. Its color textures are
+// indexed by the GBuffer constants and it has a depth texture.
+func (r *Renderer) GBuffer() *RenderTarget {
+
+	return r.deferred.gbuffer
+}
+
+// deferrable returns if the specified material is rendered
+// into the G-buffer by the deferred path
+func (r *Renderer) deferrable(mat *material.Material) bool {
+
+	if !r.deferred.enabled || mat.Forward() || mat.Transparent() || mat.DeferredShader() == "" {
+		return false
+	}
+	blending := mat.Blending()
+	return blending == material.BlendingNone || blending == material.BlendingNormal
+}
+
+// renderDeferred renders the queued graphic materials into the G-buffer
+// and then their lights into the current framebuffer and viewport.
+func (r *Renderer) renderDeferred() error {
+
+	d := &r.deferred
+	if len(d.queue) == 0 {
+		return nil
+	}
+
+	// Saves the current framebuffer, viewport and scissor test
+	// state to restore them after the G-buffer pass
+	fbo := r.gs.Framebuffer()
+	vx, vy, vwidth, vheight := r.gs.GetViewport()
+	scissor := r.gs.IsEnabled(gls.SCISSOR_TEST)
+	r.gs.Disable(gls.SCISSOR_TEST)
+
+	err := r.renderGBuffer(int(vwidth), int(vheight))
+	r.gs.BindFramebuffer(gls.FRAMEBUFFER, fbo)
+	r.gs.Viewport(vx, vy, vwidth, vheight)
+	if scissor {
+		r.gs.Enable(gls.SCISSOR_TEST)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Sets the uniforms used to reconstruct the surfaces positions
+	var invProj math32.Matrix4
+	invProj.GetInverse(&r.rinfo.ProjMatrix, false)
+	d.uInvProj.SetMatrix4(&invProj)
+	d.uViewport.Set(float32(vx), float32(vy), float32(vwidth), float32(vheight))
+
+	err = r.renderLights()
+	if err != nil {
+		return err
+	}
+	return r.renderPointLights()
+}
+
+// renderGBuffer renders the queued graphic materials into the
+// G-buffer, creating or resizing it to the specified size.
+func (r *Renderer) renderGBuffer(width, height int) error {
+
+	d := &r.deferred
+	if d.gbuffer == nil {
+		d.gbuffer = newGBuffer(width, height)
+	} else {
+		d.gbuffer.SetSize(width, height)
+	}
+	err := d.gbuffer.Bind(r.gs)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(d.queue); i++ {
+		err := r.renderGBufferMaterial(d.queue[i].grmat, d.queue[i].fade)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newGBuffer creates and returns a render target with the G-buffer
+// textures of the specified size
+func newGBuffer(width, height int) *RenderTarget {
+
+	rt := NewRenderTarget(width, height)
+	rt.AddColorTexture(gls.RGBA, gls.FLOAT, gls.RGBA16F)
+	rt.AddColorTexture(gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8)
+	rt.AddColorTexture(gls.RGBA, gls.FLOAT, gls.RGBA16F)
+	rt.SetDepthTexture(true)
+	rt.SetClearColor(&math32.Color4{R: 0, G: 0, B: 0, A: 0})
+	// The textures are sampled at the pixels centers
+	for i := 0; i < rt.ColorTextureCount(); i++ {
+		tex := rt.ColorTexture(i)
+		tex.SetMinFilter(gls.NEAREST)
+		tex.SetMagFilter(gls.NEAREST)
+	}
+	return rt
+}
+
+// renderGBufferMaterial sets the G-buffer program for the specified
+// graphic material and renders its surface into the G-buffer.
+func (r *Renderer) renderGBufferMaterial(grmat *graphic.GraphicMaterial, fade float32) error {
+
+	d := &r.deferred
+	mat := grmat.GetMaterial().GetMaterial()
+
+	r.specs.Name = mat.DeferredShader()
+	r.specs.UseLights = mat.UseLights()
+	r.specs.MatTexturesMax = mat.TextureCount()
+	r.specs.Defines = mat.ShaderDefines()
+	r.specs.Extras = mat.ShaderExtras()
+	setGraphicSpecs(&r.specs, grmat.IGraphic().GetGraphic())
+	r.specs.GBuffer = true
+	r.specs.DirShadowsMax = 0
+	r.specs.SpotShadowsMax = 0
+	_, err := r.shaman.SetProgram(&r.specs)
+	if err != nil {
+		return err
+	}
+
+	// Sets the material states and uniforms but the G-buffer is never blended
+	grmat.GetMaterial().RenderSetup(r.gs)
+	r.gs.Disable(gls.BLEND)
+	if grmat.IGraphic().ReceiveShadow() {
+		d.uShadows.Set(1)
+	} else {
+		d.uShadows.Set(0)
+	}
+	d.uShadows.Transfer(r.gs)
+	r.uLODFade.Set(fade)
+	r.uLODFade.Transfer(r.gs)
+
+	r.rinfo.TextureUnit = mat.TextureUnits()
+	grmat.RenderDepth(r.gs, &r.rinfo)
+	r.stats.GraphicMat++
+	r.stats.Deferred++
+	return nil
+}
+
+// setupGBuffer binds the G-buffer textures to the first texture
+// units and transfers the uniforms used by the light passes.
+func (r *Renderer) setupGBuffer() {
+
+	d := &r.deferred
+	for i := 0; i < d.gbuffer.ColorTextureCount(); i++ {
+		d.gbuffer.ColorTexture(i).Bind(r.gs, i)
+	}
+	d.gbuffer.DepthTexture().Bind(r.gs, d.gbuffer.ColorTextureCount())
+	for i := range d.uTextures {
+		d.uTextures[i].Transfer(r.gs)
+	}
+	d.uViewport.Transfer(r.gs)
+	d.uInvProj.Transfer(r.gs)
+}
+
+// renderLights renders the G-buffer surfaces lit by the ambient, directional
+// and spot lights over the whole viewport and writes their depth, so the
+// forward rendered graphics are hidden by them.
+func (r *Renderer) renderLights() error {
+
+	var specs ShaderSpecs
+	specs.Name = "shaderDeferredLight"
+	specs.DirShadowsMax = len(r.dirShadows)
+	specs.SpotShadowsMax = len(r.spotShadows)
+	_, err := r.shaman.SetProgram(&specs)
+	if err != nil {
+		return err
+	}
+	r.setupGBuffer()
+	r.setupShadows(gbufferTextures)
+
+	r.gs.Disable(gls.BLEND)
+	r.gs.Disable(gls.CULL_FACE)
+	r.gs.PolygonMode(gls.FRONT_AND_BACK, gls.FILL)
+	r.gs.Enable(gls.DEPTH_TEST)
+	r.gs.DepthMask(true)
+	r.gs.DepthFunc(gls.ALWAYS)
+
+	r.drawViewport()
+	return nil
+}
+
+// renderPointLights adds the light of the point lights to the G-buffer
+// surfaces drawing the back faces of their light volumes.
+func (r *Renderer) renderPointLights() error {
+
+	d := &r.deferred
+	if d.sphere == nil {
+		d.sphere = geometry.NewSphere(1, 16, 12, 0, 2*math.Pi, 0, math.Pi)
+		d.lights = gls.NewVBO().
+			AddAttrib("LightPosition", 4).
+			AddAttrib("LightColor", 3).
+			AddAttrib("LightDecay", 2).
+			SetDivisor(1).
+			SetBuffer(math32.NewArrayF32(0, 0))
+		d.lights.SetUsage(gls.DYNAMIC_DRAW)
+		d.sphere.AddVBO(d.lights)
+	}
+
+	// Sets the instances attributes of the lights which reach any surface
+	buf := d.lights.Buffer()
+	*buf = (*buf)[0:0]
+	count := 0
+	for _, l := range r.pointLights {
+		color := l.Color()
+		color.MultiplyScalar(l.Intensity())
+		radius := pointLightRadius(&color, l.LinearDecay(), l.QuadraticDecay())
+		if radius <= 0 {
+			continue
+		}
+		var pos math32.Vector3
+		l.WorldPosition(&pos)
+		pos.ApplyMatrix4(&r.rinfo.ViewMatrix)
+		buf.Append(pos.X, pos.Y, pos.Z, radius, color.R, color.G, color.B, l.LinearDecay(), l.QuadraticDecay())
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+	d.lights.Update()
+
+	var specs ShaderSpecs
+	specs.Name = "shaderDeferredPoint"
+	_, err := r.shaman.SetProgram(&specs)
+	if err != nil {
+		return err
+	}
+	r.setupGBuffer()
+
+	// The light of each volume is added once to the pixels it covers.
+	// Only the back faces are drawn so the volumes which contain the
+	// camera are also rendered and they are not clipped by the far plane.
+	r.gs.Enable(gls.BLEND)
+	r.gs.BlendEquation(gls.FUNC_ADD)
+	r.gs.BlendFunc(gls.ONE, gls.ONE)
+	r.gs.Disable(gls.DEPTH_TEST)
+	r.gs.DepthMask(false)
+	r.gs.Enable(gls.CULL_FACE)
+	r.gs.FrontFace(gls.CW)
+	r.gs.Enable(gls.DEPTH_CLAMP)
+
+	d.sphere.RenderSetup(r.gs)
+	indices := d.sphere.Indices()
+	r.gs.DrawElementsInstanced(gls.TRIANGLES, int32(indices.Size()), gls.UNSIGNED_INT, 0, int32(count))
+	r.gs.Disable(gls.DEPTH_CLAMP)
+	r.stats.LightVolumes += count
+	return nil
+}
+
+// pointLightRadius returns the distance from a point light with the specified
+// color and decay factors at which its light falls below the threshold.
+func pointLightRadius(color *math32.Color, linear, quadratic float32) float32 {
+
+	// Solves 1 + linear*d + quadratic*d^2 = maxColor/threshold
+	k := math32.Max(color.R, math32.Max(color.G, color.B)) / lightThreshold
+	if k <= 1 {
+		return 0
+	}
+	if quadratic > 0 {
+		return (-linear + math32.Sqrt(linear*linear+4*quadratic*(k-1))) / (2 * quadratic)
+	}
+	if linear > 0 {
+		return math32.Min((k-1)/linear, maxLightRadius)
+	}
+	return maxLightRadius
+}
+
+// disposeDeferred releases the OpenGL objects of the deferred path
+func (r *Renderer) disposeDeferred() {
+
+	d := &r.deferred
+	if d.gbuffer != nil {
+		d.gbuffer.Dispose()
+		d.gbuffer = nil
+	}
+	if d.sphere != nil {
+		d.lights.Dispose()
+		d.sphere.Dispose()
+		d.sphere = nil
+		d.lights = nil
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"github.com/g3n/engine/environment"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// backgroundUniforms contains the uniforms of the background program
+type backgroundUniforms struct {
+	uMatrix gls.UniformMatrix4f // Far plane to world directions matrix uniform
+	uTop    gls.Uniform3f       // Gradient top color uniform
+	uBottom gls.Uniform3f       // Gradient bottom color uniform
+	uCube   gls.Uniform1i       // Cube map texture unit uniform
+	defines gls.ShaderDefines   // Preprocessor symbols of the cube map program
+}
+
+// init initializes the background uniforms
+func (bu *backgroundUniforms) init() {
+
+	bu.uMatrix.Init("BackgroundMatrix")
+	bu.uTop.Init("BackgroundTop")
+	bu.uBottom.Init("BackgroundBottom")
+	bu.uCube.Init("BackgroundCube")
+	bu.defines = gls.NewShaderDefines()
+	bu.defines.Set("BACKGROUND_CUBE", "")
+}
+
+// Environment returns the environment used by the last render or nil
+func (r *Renderer) Environment() *environment.Environment {
+
+	return r.env
+}
+
+// setupFog sets the data of the fog uniform block
+// from the environment of the current render
+func (r *Renderer) setupFog() {
+
+	buf := r.fog.Buffer()
+	if r.env == nil || r.env.Fog() == environment.FogNone {
+		buf.Set(0, 0, 0, 0, 0)
+	} else {
+		color := r.env.FogColor()
+		near, far := r.env.FogRange()
+		buf.Set(0, color.R, color.G, color.B, float32(r.env.Fog()))
+		buf.Set(4, near, far, r.env.FogDensity(), 0)
+	}
+	// Elements of the projection matrix used to calculate the fragments depth
+	pm := &r.rinfo.ProjMatrix
+	buf.Set(8, pm[10], pm[14], pm[11], pm[15])
+	r.fog.Update()
+	r.fog.Transfer(r.gs)
+}
+
+// renderBackground draws the background of the environment
+// of the current render into the current viewport
+func (r *Renderer) renderBackground() error {
+
+	if r.env == nil || r.env.Background() == environment.BackgroundNone {
+		return nil
+	}
+	// The solid color is also drawn instead of cleared, as some drivers
+	// ignore ClearBufferfv for the default framebuffer of offscreen contexts.
+	return r.renderBackgroundPass()
+}
+
+// renderBackgroundPass draws the solid color, gradient or cube map
+// background over the current viewport without writing the depth buffer
+func (r *Renderer) renderBackgroundPass() error {
+
+	bu := &r.background
+	cube := r.env.BackgroundCube()
+	var specs ShaderSpecs
+	specs.Name = "shaderBackground"
+	if r.env.Background() == environment.BackgroundCube {
+		if cube == nil {
+			return nil
+		}
+		specs.Defines = bu.defines
+	}
+	_, err := r.shaman.SetProgram(&specs)
+	if err != nil {
+		return err
+	}
+
+	// Transforms the far plane to world directions ignoring the camera position
+	var invProj, invView, matrix math32.Matrix4
+	invProj.GetInverse(&r.rinfo.ProjMatrix, false)
+	view := r.rinfo.ViewMatrix
+	view[12] = 0
+	view[13] = 0
+	view[14] = 0
+	invView.GetInverse(&view, false)
+	matrix.MultiplyMatrices(&invView, &invProj)
+	bu.uMatrix.SetMatrix4(&matrix)
+	bu.uMatrix.Transfer(r.gs)
+	if cube != nil && specs.Defines != nil {
+		cube.Bind(r.gs, 0)
+		bu.uCube.Set(0)
+		bu.uCube.Transfer(r.gs)
+	} else {
+		top, bottom := r.env.BackgroundGradient()
+		if r.env.Background() == environment.BackgroundColor {
+			bottom = top
+		}
+		bu.uTop.SetColor(&top)
+		bu.uBottom.SetColor(&bottom)
+		bu.uTop.Transfer(r.gs)
+		bu.uBottom.Transfer(r.gs)
+	}
+
+	r.gs.Disable(gls.BLEND)
+	r.gs.Disable(gls.CULL_FACE)
+	r.gs.Disable(gls.DEPTH_TEST)
+	r.gs.DepthMask(false)
+	r.gs.PolygonMode(gls.FRONT_AND_BACK, gls.FILL)
+	r.drawViewport()
+	return nil
+}
@@ -0,0 +1,271 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"github.com/g3n/engine/camera"
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+)
+
+// PickResult describes the graphic found by a Picker at a position
+type PickResult struct {
+	Object    core.INode     // Picked node
+	Material  int            // Index of the picked material group in the graphic materials
+	Primitive int            // Index of the picked triangle, line or point in its material group
+	Instance  int            // Index of the picked instance of instanced graphics
+	Point     math32.Vector3 // Picked point in world coordinates
+	Distance  float32        // Distance from the camera to the picked point
+}
+
+// pickItem is a graphic material rendered by the picker
+type pickItem struct {
+	grmat *graphic.GraphicMaterial // Graphic material
+	index int                      // Index of the material in its graphic materials
+}
+
+// Picker finds the graphics at positions of the screen by rendering the
+// identifiers of their graphic materials into an offscreen integer render
+// target and reading back its pixels. It is an alternative to the
+// camera raycaster which also considers the shapes of the points and the
+// lines and the exact triangles drawn by the GPU. A single render may be
+// used to pick several positions.
+type Picker struct {
+	r          *Renderer       // Renderer used to render the identifiers
+	target     *RenderTarget   // Identifiers render target
+	items      []pickItem      // Graphic materials of the last render
+	layerMask  uint32          // Camera layers of the last render
+	cam        *camera.Camera  // Camera of the last render
+	rinfo      core.RenderInfo // Camera matrices of the last render
+	frustum    *math32.Frustum // Camera frustum of the last render
+	invViewPrj math32.Matrix4  // Inverse of the view projection matrix of the last render
+	camPos     math32.Vector3  // Camera position of the last render
+	uObject    gls.Uniform1i   // Graphic material identifier uniform
+	specs      ShaderSpecs     // Specs of the picking program
+}
+
+// NewPicker creates and returns a pointer to a new picker which renders
+// with the specified renderer into a target with the specified size,
+// which normally is the size of the window or of the camera viewport.
+func NewPicker(r *Renderer, width, height int) *Picker {
+
+	p := new(Picker)
+	p.r = r
+	p.target = NewRenderTarget(width, height)
+	p.target.SetColorFormat(0, gls.RGBA_INTEGER, gls.UNSIGNED_INT, gls.RGBA32UI)
+	tex := p.target.ColorTexture(0)
+	tex.SetMinFilter(gls.NEAREST)
+	tex.SetMagFilter(gls.NEAREST)
+	// Integer color buffers are cleared by the picker
+	p.target.SetClear(false)
+	p.items = make([]pickItem, 0)
+	p.frustum = math32.NewFrustum(nil, nil, nil, nil, nil, nil)
+	p.uObject.Init("PickObject")
+	p.specs.Name = "shaderPick"
+	return p
+}
+
+// SetSize sets the size in pixels of the picker render target
+func (p *Picker) SetSize(width, height int) {
+
+	p.target.SetSize(width, height)
+}
+
+// Size returns the size in pixels of the picker render target
+func (p *Picker) Size() (int, int) {
+
+	return p.target.Size()
+}
+
+// Pick renders the specified scene with the specified camera and returns
+// the graphic at the specified normalized device coordinates (-1 to 1),
+// as used by Camera.SetRaycaster. Returns false if there is no graphic
+// at this position.
+func (p *Picker) Pick(scene core.INode, cam camera.ICamera, x, y float32) (PickResult, bool, error) {
+
+	err := p.Render(scene, cam)
+	if err != nil {
+		return PickResult{}, false, err
+	}
+	res, ok := p.Read(x, y)
+	return res, ok, nil
+}
+
+// Render renders the identifiers of the visible graphics of the specified
+// scene viewed by the specified camera into the picker render target.
+// Only the graphics in the layers of the camera layer mask are rendered.
+// The current framebuffer, viewport and scissor test are preserved.
+func (p *Picker) Render(scene core.INode, cam camera.ICamera) error {
+
+	gs := p.r.gs
+	scene.UpdateMatrixWorld()
+	cam.ViewMatrix(&p.rinfo.ViewMatrix)
+	cam.ProjMatrix(&p.rinfo.ProjMatrix)
+	var vpm, invView math32.Matrix4
+	vpm.MultiplyMatrices(&p.rinfo.ProjMatrix, &p.rinfo.ViewMatrix)
+	p.frustum.SetFromMatrix(&vpm)
+	p.invViewPrj.GetInverse(&vpm, false)
+	invView.GetInverse(&p.rinfo.ViewMatrix, false)
+	p.camPos.SetFromMatrixPosition(&invView)
+
+	p.layerMask = cam.GetCamera().LayerMask()
+	p.cam = cam.GetCamera()
+	p.items = p.items[0:0]
+	p.collect(scene)
+
+	// Saves the current framebuffer, viewport and scissor test
+	// state to restore them after rendering
+	fbo := gs.Framebuffer()
+	vx, vy, vwidth, vheight := gs.GetViewport()
+	scissor := gs.IsEnabled(gls.SCISSOR_TEST)
+	gs.Disable(gls.SCISSOR_TEST)
+
+	err := p.render()
+	gs.BindFramebuffer(gls.FRAMEBUFFER, fbo)
+	gs.Viewport(vx, vy, vwidth, vheight)
+	if scissor {
+		gs.Enable(gls.SCISSOR_TEST)
+	}
+	return err
+}
+
+// collect appends the graphic materials of the specified node and of
+// its children which may be visible to the items to render
+func (p *Picker) collect(inode core.INode) {
+
+	node := inode.GetNode()
+	if !node.Visible() {
+		return
+	}
+	// Only the current level of detail is picked, even when cross-fading,
+	// without changing the level selected by the renderer for the camera
+	if lod, ok := inode.(*graphic.LOD); ok {
+		cur, _, _ := lod.Peek(p.cam, &p.camPos)
+		if cur >= 0 {
+			level, _ := lod.Level(cur)
+			p.collect(level)
+		}
+	}
+	igr, ok := inode.(graphic.IGraphic)
+	if ok && igr.Renderable() && node.InLayers(p.layerMask) {
+		// Screen space graphics, such as GUI panels, are picked by
+		// the GUI manager and the skybox is behind all the other graphics
+		_, isSkybox := inode.(*graphic.Skybox)
+		if !igr.GetGraphic().ScreenSpace() && !isSkybox && p.inFrustum(igr) {
+			materials := igr.GetGraphic().Materials()
+			for i := 0; i < len(materials); i++ {
+				p.items = append(p.items, pickItem{&materials[i], i})
+			}
+		}
+	}
+	for _, ichild := range node.Children() {
+		p.collect(ichild)
+	}
+}
+
+// inFrustum checks if the specified graphic may be visible
+// by the camera of the current render
+func (p *Picker) inFrustum(igr graphic.IGraphic) bool {
+
+	if !igr.Cullable() {
+		return true
+	}
+	geom := igr.GetGeometry()
+	sphere := geom.BoundingSphere()
+	bbox := geom.BoundingBox()
+	matrixWorld := igr.GetNode().MatrixWorld()
+	return p.frustum.IntersectsObject(&sphere, &bbox, &matrixWorld)
+}
+
+// render clears the render target and renders the collected items
+func (p *Picker) render() error {
+
+	gs := p.r.gs
+	err := p.target.Bind(gs)
+	if err != nil {
+		return err
+	}
+	gs.ClearBufferuiv(gls.COLOR, 0, []uint32{0, 0, 0, 0})
+	gs.DepthMask(true)
+	gs.ClearBufferfv(gls.DEPTH, 0, []float32{1})
+
+	for i := 0; i < len(p.items); i++ {
+		grmat := p.items[i].grmat
+		// Lines with Line materials are picked within their width
+		p.specs.Name = "shaderPick"
+		if _, ok := grmat.GetMaterial().(*material.Line); ok {
+			p.specs.Name = "shaderLinePick"
+		}
+		setGraphicSpecs(&p.specs, grmat.IGraphic().GetGraphic())
+		_, err := p.r.shaman.SetProgram(&p.specs)
+		if err != nil {
+			return err
+		}
+		// Sets the material states, such as its visible sides and
+		// depth test, but integer color buffers can not be blended.
+		grmat.GetMaterial().RenderSetup(gs)
+		gs.Disable(gls.BLEND)
+		// Zero is the identifier of the background
+		p.uObject.Set(int32(i + 1))
+		p.uObject.Transfer(gs)
+		p.rinfo.TextureUnit = grmat.GetMaterial().GetMaterial().TextureUnits()
+		grmat.RenderDepth(gs, &p.rinfo)
+	}
+	return nil
+}
+
+// Read returns the graphic found by the last render at the specified
+// normalized device coordinates (-1 to 1) of the render target.
+// Returns false if there is no graphic at this position.
+func (p *Picker) Read(x, y float32) (PickResult, bool) {
+
+	var res PickResult
+	if p.target.gs == nil || x < -1 || x > 1 || y < -1 || y > 1 {
+		return res, false
+	}
+	width, height := p.target.Size()
+	px := int32((x + 1) / 2 * float32(width))
+	py := int32((y + 1) / 2 * float32(height))
+	if px >= int32(width) {
+		px = int32(width) - 1
+	}
+	if py >= int32(height) {
+		py = int32(height) - 1
+	}
+
+	gs := p.r.gs
+	var pixel [4]uint32
+	var depth float32
+	gs.BindFramebuffer(gls.READ_FRAMEBUFFER, p.target.fbo)
+	gs.ReadPixels(px, py, 1, 1, gls.RGBA_INTEGER, gls.UNSIGNED_INT, &pixel[0])
+	gs.ReadPixels(px, py, 1, 1, gls.DEPTH_COMPONENT, gls.FLOAT, &depth)
+	gs.BindFramebuffer(gls.READ_FRAMEBUFFER, gs.Framebuffer())
+
+	id := int(pixel[0])
+	if id == 0 || id > len(p.items) {
+		return res, false
+	}
+	item := p.items[id-1]
+	res.Object = item.grmat.IGraphic()
+	res.Material = item.index
+	res.Primitive = int(pixel[1])
+	res.Instance = int(pixel[2])
+
+	// Transforms the position and depth of the pixel to world coordinates
+	res.Point.Set(x, y, depth*2-1)
+	res.Point.ApplyProjection(&p.invViewPrj)
+	res.Distance = res.Point.DistanceTo(&p.camPos)
+	return res, true
+}
+
+// Dispose releases the render target of this picker
+func (p *Picker) Dispose() {
+
+	p.target.Dispose()
+	p.items = p.items[0:0]
+}
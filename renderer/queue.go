@@ -0,0 +1,69 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"github.com/g3n/engine/graphic"
+)
+
+// renderItem describes a graphic material queued for rendering
+// and the keys used to sort it.
+type renderItem struct {
+	grmat *graphic.GraphicMaterial // Graphic material to render
+	order int                      // Render order of the graphic node
+	depth float32                  // Distance from the camera along its view direction
+	prog  string                   // Shader program name
+	matid int                      // Material identifier for the current render
+	fade  float32                  // Level of detail cross-fade factor
+}
+
+// renderQueue is a list of graphic materials to render
+type renderQueue []renderItem
+
+func (q renderQueue) Len() int      { return len(q) }
+func (q renderQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+// orderQueue sorts a render queue only by the nodes render order
+type orderQueue struct{ renderQueue }
+
+func (q orderQueue) Less(i, j int) bool {
+
+	return q.renderQueue[i].order < q.renderQueue[j].order
+}
+
+// opaqueQueue sorts a render queue by the nodes render order, then
+// front to back and then by shader program and material to
+// minimize state changes.
+type opaqueQueue struct{ renderQueue }
+
+func (q opaqueQueue) Less(i, j int) bool {
+
+	a := &q.renderQueue[i]
+	b := &q.renderQueue[j]
+	if a.order != b.order {
+		return a.order < b.order
+	}
+	if a.depth != b.depth {
+		return a.depth < b.depth
+	}
+	if a.prog != b.prog {
+		return a.prog < b.prog
+	}
+	return a.matid < b.matid
+}
+
+// transparentQueue sorts a render queue by the nodes render order
+// and then back to front.
+type transparentQueue struct{ renderQueue }
+
+func (q transparentQueue) Less(i, j int) bool {
+
+	a := &q.renderQueue[i]
+	b := &q.renderQueue[j]
+	if a.order != b.order {
+		return a.order < b.order
+	}
+	return a.depth > b.depth
+}
@@ -7,22 +7,47 @@ package renderer
 import (
 	"github.com/g3n/engine/camera"
 	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/environment"
 	"github.com/g3n/engine/gls"
 	"github.com/g3n/engine/graphic"
 	"github.com/g3n/engine/light"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+	"sort"
 )
 
 type Renderer struct {
 	gs          *gls.GLS
-	shaman      Shaman                     // Internal shader manager
-	ambLights   []*light.Ambient           // Array of ambient lights for last scene
-	dirLights   []*light.Directional       // Array of directional lights for last scene
-	pointLights []*light.Point             // Array of point
-	spotLights  []*light.Spot              // Array of spot lights for the scene
-	others      []core.INode               // Other nodes (audio, players, etc)
-	grmats      []*graphic.GraphicMaterial // Array of all graphic materials for scene
-	rinfo       core.RenderInfo            // Preallocated Render info
-	specs       ShaderSpecs                // Preallocated Shader specs
+	shaman      Shaman                       // Internal shader manager
+	ambLights   []*light.Ambient             // Array of ambient lights for last scene
+	dirLights   []*light.Directional         // Array of directional lights for last scene
+	pointLights []*light.Point               // Array of point
+	spotLights  []*light.Spot                // Array of spot lights for the scene
+	others      []core.INode                 // Other nodes (audio, players, etc)
+	opaque      renderQueue                  // Queue of opaque graphic materials
+	transparent renderQueue                  // Queue of transparent graphic materials
+	screen      []*graphic.GraphicMaterial   // Screen space graphic materials in scene order
+	casters     []*graphic.GraphicMaterial   // Graphic materials which cast shadows
+	shadowMaps  map[*light.Shadow]*shadowMap // Maps lights shadows to their shadow maps
+	renders     uint64                       // Number of scenes rendered, not affected by the stats reset
+	dirShadows  []*shadowMap                 // Shadow maps of directional lights for the current render
+	spotShadows []*shadowMap                 // Shadow maps of spot lights for the current render
+	matids      map[*material.Material]int   // Maps materials to their identifiers for the current render
+	rinfo       core.RenderInfo              // Preallocated Render info
+	specs       ShaderSpecs                  // Preallocated Shader specs
+	frustum     *math32.Frustum              // Camera frustum for the current render
+	cullEnabled bool                         // Frustum culling enabled flag
+	sortEnabled bool                         // Render queues sorting enabled flag
+	target      *RenderTarget                // Current render target (nil for the default framebuffer)
+	camera      *gls.UBO                     // Camera uniform block data
+	lights      *light.Block                 // Lights uniform block data
+	fog         *gls.UBO                     // Fog uniform block data
+	env         *environment.Environment     // Environment of the current render or nil
+	background  backgroundUniforms           // Uniforms of the background program
+	screenVAO   uint32                       // Vertex array object without attributes for the viewport passes
+	uLODFade    gls.Uniform1f                // Level of detail cross-fade factor uniform
+	deferred    deferredPath                 // Deferred render path
+	stats       Stats                        // Activity counters since the last reset
 }
 
 func NewRenderer(gs *gls.GLS) *Renderer {
@@ -36,11 +61,79 @@ func NewRenderer(gs *gls.GLS) *Renderer {
 	r.pointLights = make([]*light.Point, 0)
 	r.spotLights = make([]*light.Spot, 0)
 	r.others = make([]core.INode, 0)
-	r.grmats = make([]*graphic.GraphicMaterial, 0)
+	r.opaque = make(renderQueue, 0)
+	r.transparent = make(renderQueue, 0)
+	r.screen = make([]*graphic.GraphicMaterial, 0)
+	r.casters = make([]*graphic.GraphicMaterial, 0)
+	r.shadowMaps = make(map[*light.Shadow]*shadowMap)
+	r.dirShadows = make([]*shadowMap, 0)
+	r.spotShadows = make([]*shadowMap, 0)
+	r.matids = make(map[*material.Material]int)
+	r.frustum = math32.NewFrustum(nil, nil, nil, nil, nil, nil)
+	r.cullEnabled = true
+	r.sortEnabled = true
+	r.camera = gls.NewUBO(CameraBlockBinding, 32)
+	r.lights = light.NewBlock(LightsBlockBinding)
+	r.fog = gls.NewUBO(FogBlockBinding, 12)
+	r.background.init()
+	r.deferred.init()
+	r.uLODFade.Init("LODFade")
 
 	return r
 }
 
+// SetFrustumCulling enables or disables frustum culling (default = true).
+// When enabled, graphics whose bounding volumes lie completely outside
+// of the camera frustum are not rendered.
+func (r *Renderer) SetFrustumCulling(state bool) {
+
+	r.cullEnabled = state
+}
+
+// FrustumCulling returns the current frustum culling state
+func (r *Renderer) FrustumCulling() bool {
+
+	return r.cullEnabled
+}
+
+// SetSorting enables or disables the sorting of the render queues (default = true).
+// When enabled, opaque graphics are rendered front to back and transparent
+// graphics back to front. When disabled, graphics are rendered in scene order.
+// In both cases nodes render orders are respected and all opaque graphics
+// are rendered before the transparent ones.
+func (r *Renderer) SetSorting(state bool) {
+
+	r.sortEnabled = state
+}
+
+// Sorting returns the current render queues sorting state
+func (r *Renderer) Sorting() bool {
+
+	return r.sortEnabled
+}
+
+// SetRenderTarget sets the render target used by the next renders.
+// If nil, the scene is rendered to the default framebuffer.
+func (r *Renderer) SetRenderTarget(rt *RenderTarget) {
+
+	r.target = rt
+}
+
+// RenderTarget returns the current render target or nil
+// if the scene is rendered to the default framebuffer.
+func (r *Renderer) RenderTarget() *RenderTarget {
+
+	return r.target
+}
+
+// Shaman returns a pointer to the shader manager used by this renderer.
+// It may be used to render with additional programs, such as
+// full screen passes, keeping the current program state consistent.
+func (r *Renderer) Shaman() *Shaman {
+
+	return &r.shaman
+}
+
 func (r *Renderer) AddDefaultShaders() error {
 
 	return r.shaman.AddDefaultShaders()
@@ -61,8 +154,35 @@ func (r *Renderer) AddProgram(name, vertex, frag string) error {
 	return r.shaman.AddProgram(name, vertex, frag)
 }
 
+// Render renders the specified scene with the specified camera into
+// the current render target or the current viewport of the default framebuffer.
 func (r *Renderer) Render(iscene core.INode, icam camera.ICamera) error {
 
+	// Binds the render target if set, saving the current viewport
+	if r.target != nil {
+		vx, vy, vwidth, vheight := r.gs.GetViewport()
+		err := r.target.Bind(r.gs)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			r.gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+			r.gs.Viewport(vx, vy, vwidth, vheight)
+		}()
+	}
+	return r.renderScene(iscene, icam)
+}
+
+// renderScene renders the specified scene with the specified
+// camera into the current framebuffer and viewport.
+func (r *Renderer) renderScene(iscene core.INode, icam camera.ICamera) error {
+
+	r.stats.Renders++
+	r.renders++
+
+	// Reloads the shaders files if changed
+	r.shaman.pollShaderDir()
+
 	// Updates world matrices of all scene nodes
 	iscene.UpdateMatrixWorld()
 	scene := iscene.GetNode()
@@ -71,17 +191,35 @@ func (r *Renderer) Render(iscene core.INode, icam camera.ICamera) error {
 	icam.ViewMatrix(&r.rinfo.ViewMatrix)
 	icam.ProjMatrix(&r.rinfo.ProjMatrix)
 
+	// Builds the camera frustum in world coordinates
+	var vpm math32.Matrix4
+	vpm.MultiplyMatrices(&r.rinfo.ProjMatrix, &r.rinfo.ViewMatrix)
+	r.frustum.SetFromMatrix(&vpm)
+
 	// Clear scene arrays
 	r.ambLights = r.ambLights[0:0]
 	r.dirLights = r.dirLights[0:0]
 	r.pointLights = r.pointLights[0:0]
 	r.spotLights = r.spotLights[0:0]
 	r.others = r.others[0:0]
-	r.grmats = r.grmats[0:0]
+	r.opaque = r.opaque[0:0]
+	r.transparent = r.transparent[0:0]
+	r.deferred.queue = r.deferred.queue[0:0]
+	r.screen = r.screen[0:0]
+	r.casters = r.casters[0:0]
+	r.env = nil
+	for mat := range r.matids {
+		delete(r.matids, mat)
+	}
+
+	layerMask := icam.GetCamera().LayerMask()
+	var camPos math32.Vector3
+	icam.GetCamera().WorldPosition(&camPos)
 
 	// Internal function to classify a node and its children
-	var classifyNode func(inode core.INode)
-	classifyNode = func(inode core.INode) {
+	// with the specified level of detail cross-fade factor
+	var classifyNode func(inode core.INode, fade float32)
+	classifyNode = func(inode core.INode, fade float32) {
 
 		// If node not visible, ignore
 		node := inode.GetNode()
@@ -89,22 +227,64 @@ func (r *Renderer) Render(iscene core.INode, icam camera.ICamera) error {
 			return
 		}
 
+		// Classifies the levels of detail selected by the camera distance
+		if lod, ok := inode.(*graphic.LOD); ok {
+			cur, next, t := lod.Select(icam.GetCamera(), &camPos)
+			if next >= 0 {
+				level, _ := lod.Level(cur)
+				classifyNode(level, -t)
+				level, _ = lod.Level(next)
+				classifyNode(level, t)
+			} else if cur >= 0 {
+				level, _ := lod.Level(cur)
+				classifyNode(level, fade)
+			}
+			for _, ichild := range node.Children() {
+				classifyNode(ichild, fade)
+			}
+			return
+		}
+
 		// Checks if node is a Graphic
 		igr, ok := inode.(graphic.IGraphic)
 		if ok {
-			if igr.Renderable() {
-				// Appends to list each graphic material for this graphic
-				gr := igr.GetGraphic()
-				materials := gr.Materials()
-				for i := 0; i < len(materials); i++ {
-					r.grmats = append(r.grmats, &materials[i])
+			// Graphics outside of the camera layers are not rendered
+			if igr.Renderable() && node.InLayers(layerMask) {
+				r.stats.Graphics++
+				// Screen space graphics, such as GUI panels, are
+				// rendered after the scene in their original order
+				if igr.GetGraphic().ScreenSpace() {
+					materials := igr.GetGraphic().Materials()
+					for i := 0; i < len(materials); i++ {
+						r.screen = append(r.screen, &materials[i])
+					}
+				} else {
+					// Shadow casters outside of the camera frustum
+					// may still cast shadows over visible graphics.
+					if igr.CastShadow() {
+						materials := igr.GetGraphic().Materials()
+						for i := 0; i < len(materials); i++ {
+							r.casters = append(r.casters, &materials[i])
+						}
+					}
+					if r.inFrustum(igr) {
+						r.enqueue(igr, fade)
+					} else {
+						r.stats.Culled++
+					}
 				}
 			}
 			// Node is not a Graphic
 		} else {
 			// Checks if node is a Light
 			il, ok := inode.(light.ILight)
-			if ok {
+			if env, isEnv := inode.(*environment.Environment); isEnv {
+				// Uses the first environment found in the scene
+				if r.env == nil {
+					r.env = env
+				}
+			} else if ok {
+				r.stats.Lights++
 				switch l := il.(type) {
 				case *light.Ambient:
 					r.ambLights = append(r.ambLights, l)
@@ -125,18 +305,39 @@ func (r *Renderer) Render(iscene core.INode, icam camera.ICamera) error {
 
 		// Classify node children
 		for _, ichild := range node.Children() {
-			classifyNode(ichild)
+			classifyNode(ichild, fade)
 		}
 	}
 
 	// Classify all scene nodes
-	classifyNode(scene)
+	classifyNode(scene, 0)
 
-	// Sets lights count in shader specs
-	r.specs.AmbientLightsMax = len(r.ambLights)
-	r.specs.DirLightsMax = len(r.dirLights)
-	r.specs.PointLightsMax = len(r.pointLights)
-	r.specs.SpotLightsMax = len(r.spotLights)
+	// Sorts the render queues
+	if r.sortEnabled {
+		sort.Stable(opaqueQueue{r.deferred.queue})
+		sort.Stable(opaqueQueue{r.opaque})
+		sort.Stable(transparentQueue{r.transparent})
+	} else {
+		sort.Stable(orderQueue{r.deferred.queue})
+		sort.Stable(orderQueue{r.opaque})
+		sort.Stable(orderQueue{r.transparent})
+	}
+
+	// Renders the shadow maps of the lights which cast shadows
+	ndir, nspot := r.sortShadowLights()
+	err := r.renderShadows(ndir, nspot)
+	if err != nil {
+		return err
+	}
+
+	// Transfers the camera, lights and fog uniform blocks used by all programs
+	r.setupBlocks()
+
+	// Draws the environment background before all the graphics
+	err = r.renderBackground()
+	if err != nil {
+		return err
+	}
 
 	// Render other nodes (audio players, etc)
 	for i := 0; i < len(r.others); i++ {
@@ -147,36 +348,196 @@ func (r *Renderer) Render(iscene core.INode, icam camera.ICamera) error {
 		r.others[i].Render(r.gs)
 	}
 
-	// For each *GraphicMaterial
-	for _, grmat := range r.grmats {
-		//log.Debug("grmat:%v", grmat)
-		mat := grmat.GetMaterial().GetMaterial()
+	// Renders the graphic materials of the deferred path and their lights
+	err = r.renderDeferred()
+	if err != nil {
+		return err
+	}
 
-		// Sets the shader specs for this material and sets shader program
-		r.specs.Name = mat.Shader()
-		r.specs.UseLights = mat.UseLights()
-		r.specs.MatTexturesMax = mat.TextureCount()
-		_, err := r.shaman.SetProgram(&r.specs)
+	// Render opaque graphic materials, then the transparent
+	// ones and finally the screen space ones.
+	for i := 0; i < len(r.opaque); i++ {
+		err := r.renderGraphicMaterial(r.opaque[i].grmat, r.opaque[i].fade)
 		if err != nil {
 			return err
 		}
+	}
+	for i := 0; i < len(r.transparent); i++ {
+		err := r.renderGraphicMaterial(r.transparent[i].grmat, r.transparent[i].fade)
+		if err != nil {
+			return err
+		}
+	}
+	for _, grmat := range r.screen {
+		err := r.renderGraphicMaterial(grmat, 0)
+		if err != nil {
+			return err
+		}
+	}
+	r.stats.ScreenSpace += len(r.screen)
+	return nil
+}
+
+// enqueue appends each graphic material of the specified graphic
+// to the deferred, opaque or transparent render queue with its sorting
+// keys and the specified level of detail cross-fade factor.
+func (r *Renderer) enqueue(igr graphic.IGraphic, fade float32) {
 
-		// Setup lights (transfer lights uniforms)
-		for idx, l := range r.ambLights {
-			l.RenderSetup(r.gs, &r.rinfo, idx)
+	gr := igr.GetGraphic()
+
+	// Calculates the depth of the graphic bounding sphere center in camera coordinates
+	sphere := gr.GetGeometry().BoundingSphere()
+	matrixWorld := gr.MatrixWorld()
+	center := sphere.Center
+	center.ApplyMatrix4(&matrixWorld).ApplyMatrix4(&r.rinfo.ViewMatrix)
+
+	materials := gr.Materials()
+	for i := 0; i < len(materials); i++ {
+		mat := materials[i].GetMaterial().GetMaterial()
+		matid, ok := r.matids[mat]
+		if !ok {
+			matid = len(r.matids)
+			r.matids[mat] = matid
 		}
-		for idx, l := range r.dirLights {
-			l.RenderSetup(r.gs, &r.rinfo, idx)
+		item := renderItem{
+			grmat: &materials[i],
+			order: gr.RenderOrder(),
+			depth: -center.Z,
+			prog:  mat.Shader(),
+			matid: matid,
+			fade:  fade,
 		}
-		for idx, l := range r.pointLights {
-			l.RenderSetup(r.gs, &r.rinfo, idx)
+		if r.deferrable(mat) {
+			r.deferred.queue = append(r.deferred.queue, item)
+		} else if mat.Transparent() {
+			r.transparent = append(r.transparent, item)
+		} else {
+			r.opaque = append(r.opaque, item)
 		}
-		for idx, l := range r.spotLights {
-			l.RenderSetup(r.gs, &r.rinfo, idx)
+	}
+}
+
+// setGraphicSpecs sets the shader specs which depend on the specified graphic
+// and on its geometry
+func setGraphicSpecs(specs *ShaderSpecs, gr *graphic.Graphic) {
+
+	specs.Instanced = gr.Instanced()
+	specs.BonesMax = 0
+	sk := gr.Skeleton()
+	if sk != nil && sk.BoneCount() > 0 {
+		// Rounds up the number of bones so skeletons with similar
+		// sizes share the same programs, up to MaxBones (a multiple of 12)
+		bones := sk.BoneCount()
+		if bones > graphic.MaxBones {
+			bones = graphic.MaxBones
+		}
+		specs.BonesMax = (bones + 11) / 12 * 12
+	}
+	specs.MorphTargetsMax = graphic.MorphTargetsMax(gr.GetGeometry().MorphTargetCount())
+}
+
+// renderGraphicMaterial sets the shader program for the specified
+// graphic material, transfer the lights uniforms and renders it
+// with the specified level of detail cross-fade factor.
+func (r *Renderer) renderGraphicMaterial(grmat *graphic.GraphicMaterial, fade float32) error {
+
+	mat := grmat.GetMaterial().GetMaterial()
+
+	// Adds the program of materials with their own shaders templates
+	// when first rendered or when their templates changed
+	if ishader, ok := grmat.GetMaterial().(material.IShader); ok {
+		vertex, frag := ishader.Shaders()
+		pinfo, found := r.shaman.proginfo[mat.Shader()]
+		if !found || pinfo.Vertex != vertex || pinfo.Frag != frag {
+			r.shaman.AddProgram(mat.Shader(), vertex, frag)
 		}
+	}
+
+	// Sets the shader specs for this material and sets shader program
+	r.specs.Name = mat.Shader()
+	r.specs.UseLights = mat.UseLights()
+	r.specs.MatTexturesMax = mat.TextureCount()
+	r.specs.Defines = mat.ShaderDefines()
+	r.specs.Extras = mat.ShaderExtras()
+	setGraphicSpecs(&r.specs, grmat.IGraphic().GetGraphic())
+	r.specs.GBuffer = false
+	receiveShadow := grmat.IGraphic().ReceiveShadow()
+	if receiveShadow {
+		r.specs.DirShadowsMax = len(r.dirShadows)
+		r.specs.SpotShadowsMax = len(r.spotShadows)
+	} else {
+		r.specs.DirShadowsMax = 0
+		r.specs.SpotShadowsMax = 0
+	}
+	_, err := r.shaman.SetProgram(&r.specs)
+	if err != nil {
+		return err
+	}
 
-		// Render this graphic material
-		grmat.Render(r.gs, &r.rinfo)
+	// Setup shadow maps after the material textures units
+	// and leaves the following units to the graphic
+	r.rinfo.TextureUnit = mat.TextureUnits()
+	if receiveShadow {
+		r.rinfo.TextureUnit = r.setupShadows(r.rinfo.TextureUnit)
 	}
+	r.uLODFade.Set(fade)
+	r.uLODFade.Transfer(r.gs)
+
+	// Render this graphic material
+	grmat.Render(r.gs, &r.rinfo)
+	r.stats.GraphicMat++
 	return nil
 }
+
+// setupBlocks sets the data of the camera, lights and fog uniform blocks
+// for the current render and transfers them once for all the programs.
+// Lights exceeding the maximum number of lights of its type are ignored.
+func (r *Renderer) setupBlocks() {
+
+	buf := r.camera.Buffer()
+	buf.Set(0, r.rinfo.ViewMatrix[:]...)
+	buf.Set(16, r.rinfo.ProjMatrix[:]...)
+	r.camera.Update()
+	r.camera.Transfer(r.gs)
+
+	for idx, l := range r.ambLights {
+		l.RenderSetup(r.lights, &r.rinfo, idx)
+	}
+	for idx, l := range r.dirLights {
+		l.RenderSetup(r.lights, &r.rinfo, idx)
+	}
+	for idx, l := range r.pointLights {
+		l.RenderSetup(r.lights, &r.rinfo, idx)
+	}
+	for idx, l := range r.spotLights {
+		l.RenderSetup(r.lights, &r.rinfo, idx)
+	}
+	r.lights.SetCounts(len(r.ambLights), len(r.dirLights), len(r.pointLights), len(r.spotLights))
+	r.lights.Transfer(r.gs)
+	r.setupFog()
+}
+
+// inFrustum checks if the specified graphic must be rendered
+// considering the current camera frustum.
+func (r *Renderer) inFrustum(igr graphic.IGraphic) bool {
+
+	if !r.cullEnabled || !igr.Cullable() {
+		return true
+	}
+	geom := igr.GetGeometry()
+	sphere := geom.BoundingSphere()
+	bbox := geom.BoundingBox()
+	matrixWorld := igr.GetNode().MatrixWorld()
+	return r.frustum.IntersectsObject(&sphere, &bbox, &matrixWorld)
+}
+
+// drawViewport draws a triangle which covers the current viewport with the
+// current program, which generates its vertices from their indices.
+func (r *Renderer) drawViewport() {
+
+	if r.screenVAO == 0 {
+		r.screenVAO = r.gs.GenVertexArray()
+	}
+	r.gs.BindVertexArray(r.screenVAO)
+	r.gs.DrawArrays(gls.TRIANGLES, 0, 3)
+}
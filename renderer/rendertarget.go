@@ -0,0 +1,256 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/texture"
+)
+
+// RenderTarget is an offscreen framebuffer which can be used by the
+// Renderer instead of the default framebuffer.
+// It has one or more color textures (multiple render targets)
+// and a depth buffer which may also be a texture.
+type RenderTarget struct {
+	gs         *gls.GLS           // OpenGL state (nil if not yet created)
+	fbo        uint32             // Framebuffer object handle
+	rbo        uint32             // Depth and stencil renderbuffer handle
+	width      int                // Width in pixels
+	height     int                // Height in pixels
+	colors     []colorAttachment  // Color attachments
+	depth      *texture.Texture2D // Optional depth attachment texture
+	clear      bool               // Clear buffers before rendering flag
+	clearColor math32.Color4      // Color used to clear the color textures
+	update     bool               // Attachments need to be (re)created flag
+}
+
+// colorAttachment describes a color texture of a render target
+type colorAttachment struct {
+	tex        *texture.Texture2D // Color texture
+	format     int                // Format of the pixel data
+	formatType int                // Type of the pixel data
+	iformat    int                // Internal format
+}
+
+// NewRenderTarget creates and returns a pointer to a new render target
+// with the specified size, one RGBA color texture and a depth renderbuffer.
+func NewRenderTarget(width, height int) *RenderTarget {
+
+	rt := new(RenderTarget)
+	rt.width = width
+	rt.height = height
+	rt.colors = make([]colorAttachment, 0)
+	rt.clear = true
+	rt.clearColor.Set(0, 0, 0, 1)
+	rt.AddColorTexture(gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8)
+	return rt
+}
+
+// AddColorTexture adds a new color texture with the specified format,
+// data type and internal format to this render target and returns it.
+// Its index is the location of the fragment shader output which writes to it.
+func (rt *RenderTarget) AddColorTexture(format, formatType, iformat int) *texture.Texture2D {
+
+	tex := texture.NewTexture2DFromData(rt.width, rt.height, format, formatType, iformat, nil)
+	tex.SetGenMipmap(false)
+	// Framebuffer rows are stored from bottom to top
+	tex.SetFlipY(false)
+	rt.colors = append(rt.colors, colorAttachment{tex, format, formatType, iformat})
+	rt.update = true
+	return tex
+}
+
+// SetColorFormat sets the format, data type and internal format
+// of the color texture with the specified index.
+func (rt *RenderTarget) SetColorFormat(idx int, format, formatType, iformat int) {
+
+	ca := &rt.colors[idx]
+	ca.format = format
+	ca.formatType = formatType
+	ca.iformat = iformat
+	rt.update = true
+}
+
+// ColorTexture returns the color texture with the specified index
+func (rt *RenderTarget) ColorTexture(idx int) *texture.Texture2D {
+
+	return rt.colors[idx].tex
+}
+
+// ColorTextureCount returns the number of color textures of this render target
+func (rt *RenderTarget) ColorTextureCount() int {
+
+	return len(rt.colors)
+}
+
+// SetDepthTexture sets if the depth buffer of this render target is a
+// texture which can be sampled after rendering (default = false).
+func (rt *RenderTarget) SetDepthTexture(state bool) {
+
+	if state == (rt.depth != nil) {
+		return
+	}
+	if state {
+		rt.depth = texture.NewTexture2DFromData(rt.width, rt.height, gls.DEPTH_COMPONENT, gls.FLOAT, gls.DEPTH_COMPONENT24, nil)
+		rt.depth.SetGenMipmap(false)
+		rt.depth.SetFlipY(false)
+		rt.depth.SetMinFilter(gls.NEAREST)
+		rt.depth.SetMagFilter(gls.NEAREST)
+	} else {
+		rt.depth.Dispose()
+		rt.depth = nil
+	}
+	rt.update = true
+}
+
+// DepthTexture returns the depth texture of this render target
+// or nil if its depth buffer is not a texture.
+func (rt *RenderTarget) DepthTexture() *texture.Texture2D {
+
+	return rt.depth
+}
+
+// SetSize sets the width and height in pixels of this render target
+// and of all its textures.
+func (rt *RenderTarget) SetSize(width, height int) {
+
+	if rt.width == width && rt.height == height {
+		return
+	}
+	rt.width = width
+	rt.height = height
+	rt.update = true
+}
+
+// Size returns the width and height in pixels of this render target
+func (rt *RenderTarget) Size() (int, int) {
+
+	return rt.width, rt.height
+}
+
+// SetClear sets if the color and depth buffers of this render
+// target are cleared before rendering (default = true).
+func (rt *RenderTarget) SetClear(state bool) {
+
+	rt.clear = state
+}
+
+// Clear returns the current clear state of this render target
+func (rt *RenderTarget) Clear() bool {
+
+	return rt.clear
+}
+
+// SetClearColor sets the color used to clear the color textures
+func (rt *RenderTarget) SetClearColor(color *math32.Color4) {
+
+	rt.clearColor = *color
+}
+
+// ClearColor returns the color used to clear the color textures
+func (rt *RenderTarget) ClearColor() math32.Color4 {
+
+	return rt.clearColor
+}
+
+// Bind creates or updates the framebuffer of this render target if
+// necessary, binds it as the current framebuffer and sets the viewport
+// to its size. If clear is enabled its buffers are also cleared.
+func (rt *RenderTarget) Bind(gs *gls.GLS) error {
+
+	if rt.gs == nil {
+		rt.gs = gs
+		rt.fbo = gs.GenFramebuffer()
+		rt.update = true
+	}
+	gs.BindFramebuffer(gls.FRAMEBUFFER, rt.fbo)
+	if rt.update {
+		err := rt.attach()
+		if err != nil {
+			return err
+		}
+		rt.update = false
+	}
+	gs.Viewport(0, 0, int32(rt.width), int32(rt.height))
+
+	if rt.clear {
+		color := []float32{rt.clearColor.R, rt.clearColor.G, rt.clearColor.B, rt.clearColor.A}
+		for i := 0; i < len(rt.colors); i++ {
+			gs.ClearBufferfv(gls.COLOR, int32(i), color)
+		}
+		gs.DepthMask(true)
+		gs.ClearBufferfv(gls.DEPTH, 0, []float32{1})
+	}
+	return nil
+}
+
+// attach allocates the textures and the renderbuffer with the current
+// size and attaches them to the currently bound framebuffer.
+func (rt *RenderTarget) attach() error {
+
+	gs := rt.gs
+	drawBuffers := make([]uint32, len(rt.colors))
+	for i, ca := range rt.colors {
+		tex := ca.tex
+		tex.SetData(rt.width, rt.height, ca.format, ca.formatType, ca.iformat, nil)
+		tex.Bind(gs, 0)
+		attachment := uint32(gls.COLOR_ATTACHMENT0 + i)
+		gs.FramebufferTexture2D(gls.FRAMEBUFFER, attachment, gls.TEXTURE_2D, tex.TexName(), 0)
+		drawBuffers[i] = attachment
+	}
+	if len(drawBuffers) > 0 {
+		gs.DrawBuffers(drawBuffers...)
+	} else {
+		gs.DrawBuffer(gls.NONE)
+	}
+
+	// Depth texture or depth and stencil renderbuffer
+	if rt.depth != nil {
+		if rt.rbo != 0 {
+			gs.DeleteRenderbuffers(rt.rbo)
+			rt.rbo = 0
+		}
+		rt.depth.SetData(rt.width, rt.height, gls.DEPTH_COMPONENT, gls.FLOAT, gls.DEPTH_COMPONENT24, nil)
+		rt.depth.Bind(gs, 0)
+		gs.FramebufferTexture2D(gls.FRAMEBUFFER, gls.DEPTH_ATTACHMENT, gls.TEXTURE_2D, rt.depth.TexName(), 0)
+	} else {
+		if rt.rbo == 0 {
+			rt.rbo = gs.GenRenderbuffer()
+		}
+		gs.BindRenderbuffer(rt.rbo)
+		gs.RenderbufferStorage(gls.DEPTH24_STENCIL8, int32(rt.width), int32(rt.height))
+		gs.FramebufferRenderbuffer(gls.FRAMEBUFFER, gls.DEPTH_STENCIL_ATTACHMENT, rt.rbo)
+	}
+
+	status := gs.CheckFramebufferStatus(gls.FRAMEBUFFER)
+	if status != gls.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("Render target framebuffer incomplete: 0x%X", status)
+	}
+	return nil
+}
+
+// Dispose releases the OpenGL objects and the textures of this render target
+func (rt *RenderTarget) Dispose() {
+
+	for _, ca := range rt.colors {
+		ca.tex.Dispose()
+	}
+	if rt.depth != nil {
+		rt.depth.Dispose()
+	}
+	if rt.gs == nil {
+		return
+	}
+	rt.gs.DeleteFramebuffers(rt.fbo)
+	if rt.rbo != 0 {
+		rt.gs.DeleteRenderbuffers(rt.rbo)
+	}
+	rt.gs = nil
+	rt.fbo = 0
+	rt.rbo = 0
+}
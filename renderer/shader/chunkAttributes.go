@@ -6,6 +6,7 @@ package shader
 
 func init() {
 	AddChunk("attributes", chunkAttributes)
+	AddChunk("instance_transform", chunkInstanceTransform)
 }
 
 const chunkAttributes = `
@@ -16,4 +17,29 @@ layout(location = 2) in vec3  VertexColor;
 layout(location = 3) in vec2  VertexTexcoord;
 layout(location = 4) in float VertexDistance;
 layout(location = 5) in vec4  VertexTexoffsets;
+layout(location = 13) in vec4  VertexTangent;
+
+{{if .Instanced}}
+// Instance attributes
+layout(location = 6)  in mat4  InstanceMatrix;
+layout(location = 10) in vec4  InstanceColor;
+{{end}}
+{{template "morphing" .}}
+{{template "skinning" .}}
+`
+
+// Declares and sets the vertex position, normal and tangent in model coordinates
+// applying the morph targets, the bones of skinned meshes and the transform
+// of the current instance when drawing instances.
+const chunkInstanceTransform = `
+    vec3 vertexPosition = VertexPosition;
+    vec3 vertexNormal = VertexNormal;
+    vec3 vertexTangent = VertexTangent.xyz;
+    {{template "morph_transform" .}}
+    {{template "skin_transform" .}}
+    {{if .Instanced}}
+    vertexPosition = vec3(InstanceMatrix * vec4(vertexPosition, 1.0));
+    vertexNormal = transpose(inverse(mat3(InstanceMatrix))) * vertexNormal;
+    vertexTangent = mat3(InstanceMatrix) * vertexTangent;
+    {{end}}
 `
@@ -0,0 +1,54 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddChunk("fog", chunkFog)
+}
+
+const chunkFog = `
+// Fog uniform block shared by all programs
+layout(std140) uniform Fog {
+    vec4 FogColor;      // Color (rgb) and mode (a): 0 = none, 1 = linear, 2 = exponential, 3 = squared exponential
+    vec4 FogParams;     // Linear fog near (x) and far (y) distances and exponential fog density (z)
+    vec4 FogProjection; // Projection matrix elements [2][2], [3][2], [2][3] and [3][3]
+};
+
+/***
+ fogFactor returns the fraction of fog from 0.0 to 1.0 at the
+ specified depth along the camera direction.
+*/
+float fogFactor(float depth) {
+
+    int mode = int(FogColor.a);
+    if (mode == 1) {
+        return clamp((depth - FogParams.x) / (FogParams.y - FogParams.x), 0.0, 1.0);
+    }
+    if (mode == 2) {
+        return 1.0 - exp(-FogParams.z * depth);
+    }
+    if (mode == 3) {
+        float d = FogParams.z * depth;
+        return 1.0 - exp(-d * d);
+    }
+    return 0.0;
+}
+
+// fogDepth returns the depth along the camera direction of the current fragment
+float fogDepth() {
+
+    float z = gl_FragCoord.z * 2.0 - 1.0;
+    return (FogProjection.y - FogProjection.w * z) / (FogProjection.x - FogProjection.z * z);
+}
+
+// applyFog returns the specified color faded into the fog at the current fragment
+vec3 applyFog(vec3 color) {
+
+    if (FogColor.a == 0.0) {
+        return color;
+    }
+    return mix(color, FogColor.rgb, fogFactor(fogDepth()));
+}
+`
@@ -0,0 +1,108 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddChunk("gbuffer_output", chunkGBufferOutput)
+	AddChunk("gbuffer", chunkGBuffer)
+}
+
+// chunkGBufferOutput declares the outputs of the fragment shaders
+// of the materials rendered into the deferred G-buffer.
+const chunkGBufferOutput = `
+// G-buffer outputs
+layout(location = 0) out vec4 GOutAlbedo;   // Albedo (rgb) and lighting model (a): 0 = Phong, 1 = physical
+layout(location = 1) out vec4 GOutNormal;   // Normal in camera coordinates (xyz) and Phong shininess (w)
+layout(location = 2) out vec4 GOutParams;   // Phong specular color (rgb) or metallic (r) and roughness (g) and receive shadows (a)
+layout(location = 3) out vec4 GOutEmissive; // Ambient, emissive and environment light (rgb)
+
+// Receive shadows flag of the graphic (0 or 1)
+uniform float GBufferShadows;
+`
+
+// chunkGBuffer declares the G-buffer textures sampled by the deferred
+// light passes and the functions which decode and light their surfaces.
+// It must be included after the "physical_model" chunk.
+const chunkGBuffer = `
+// G-buffer textures
+uniform sampler2D GBufferAlbedo;
+uniform sampler2D GBufferNormal;
+uniform sampler2D GBufferParams;
+uniform sampler2D GBufferEmissive;
+uniform sampler2D GBufferDepth;
+
+// Origin (xy) and size (zw) of the viewport covered by the G-buffer
+uniform vec4 GBufferViewport;
+// Inverse of the camera projection matrix
+uniform mat4 InvProjMatrix;
+
+// Surface decoded from the G-buffer
+struct Surface {
+    vec4  position;  // Position in camera coordinates
+    vec3  normal;    // Normal in camera coordinates
+    vec3  camDir;    // Direction from the surface to the camera
+    vec3  albedo;    // Diffuse or base color
+    vec4  params;    // Phong specular color or metallic and roughness
+    float shininess; // Phong shininess
+    float physical;  // Physical lighting model flag
+    float shadows;   // Receive shadows flag
+};
+
+// gbufferTexcoord returns the G-buffer texture coordinates of the current fragment
+vec2 gbufferTexcoord() {
+
+    return (gl_FragCoord.xy - GBufferViewport.xy) / GBufferViewport.zw;
+}
+
+/***
+ gbufferSurface decodes the surface stored in the G-buffer
+ Parameters:
+    texcoord: G-buffer texture coordinates
+    depth:    depth sampled from the G-buffer
+*/
+Surface gbufferSurface(vec2 texcoord, float depth) {
+
+    Surface s;
+    vec4 pos = InvProjMatrix * vec4(vec3(texcoord, depth) * 2.0 - 1.0, 1.0);
+    s.position = vec4(pos.xyz / pos.w, 1.0);
+    s.camDir = normalize(-s.position.xyz);
+    vec4 albedo = texture(GBufferAlbedo, texcoord);
+    vec4 normal = texture(GBufferNormal, texcoord);
+    s.albedo = albedo.rgb;
+    s.physical = albedo.a;
+    s.normal = normalize(normal.xyz);
+    s.shininess = normal.w;
+    s.params = texture(GBufferParams, texcoord);
+    s.shadows = s.params.a;
+    return s;
+}
+
+/***
+ surfaceLight returns the light reflected to the camera by a surface
+ decoded from the G-buffer using its lighting model.
+ Parameters:
+    s:        surface
+    L:        normalized direction from the surface to the light
+    radiance: light color multiplied by its attenuation
+*/
+vec3 surfaceLight(Surface s, vec3 L, vec3 radiance) {
+
+    if (s.physical > 0.5) {
+        float metallic = s.params.r;
+        float roughness = s.params.g;
+        vec3 F0 = mix(vec3(0.04), s.albedo, metallic);
+        return cookTorrance(L, s.camDir, s.normal, s.albedo, F0, metallic, roughness, radiance);
+    }
+
+    // Phong model
+    float dotNormal = max(dot(L, s.normal), 0.0);
+    vec3 color = radiance * s.albedo * dotNormal;
+    if (dotNormal > 0.0) {
+        vec3 ref = reflect(-L, s.normal);
+        color += radiance * s.params.rgb * pow(max(dot(ref, s.camDir), 0.0), s.shininess);
+    }
+    return color;
+}
+`
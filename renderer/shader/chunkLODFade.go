@@ -0,0 +1,35 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddChunk("lod_fade", chunkLODFade)
+}
+
+const chunkLODFade = `
+// Cross-fade factor of the level of detail being rendered:
+// zero if not fading, positive if fading in, negative if fading out.
+uniform float LODFade;
+
+// Ordered dither thresholds of 4x4 pixels blocks
+const float LODDither[16] = float[16](0.0, 8.0, 2.0, 10.0, 12.0, 4.0, 14.0, 6.0, 3.0, 11.0, 1.0, 9.0, 15.0, 7.0, 13.0, 5.0);
+
+/***
+ lodFade discards the fragments hidden by the current cross-fade
+ factor, so the fragments kept by the level fading in complement
+ the ones kept by the level fading out.
+*/
+void lodFade() {
+
+    if (LODFade == 0.0) {
+        return;
+    }
+    ivec2 p = ivec2(gl_FragCoord.xy) & 3;
+    float threshold = (LODDither[p.y * 4 + p.x] + 0.5) / 16.0;
+    if (LODFade > 0.0 ? threshold >= LODFade : threshold < -LODFade) {
+        discard;
+    }
+}
+`
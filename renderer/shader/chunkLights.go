@@ -5,37 +5,84 @@
 package shader
 
 func init() {
+	AddChunk("camera", chunkCamera)
 	AddChunk("lights", chunkLights)
 }
 
+const chunkCamera = `
+// Camera uniform block shared by all programs
+layout(std140) uniform Camera {
+    mat4 ViewMatrix;
+    mat4 ProjMatrix;
+};
+`
+
 const chunkLights = `
-{{if .AmbientLightsMax}}
-// Ambient lights uniforms
-uniform vec3 AmbientLightColor[{{.AmbientLightsMax}}];
-{{end}}
-
-{{if .DirLightsMax}}
-// Directional lights uniforms
-uniform vec3  DirLightColor[{{.DirLightsMax}}];
-uniform vec3  DirLightPosition[{{.DirLightsMax}}];
-{{end}}
-
-{{if .PointLightsMax}}
-// Point lights uniforms
-uniform vec3  PointLightColor[{{.PointLightsMax}}];
-uniform vec3  PointLightPosition[{{.PointLightsMax}}];
-uniform float PointLightLinearDecay[{{.PointLightsMax}}];
-uniform float PointLightQuadraticDecay[{{.PointLightsMax}}];
-{{end}}
-
-{{if .SpotLightsMax}}
-// Spot lights uniforms
-uniform vec3  SpotLightColor[{{.SpotLightsMax}}];
-uniform vec3  SpotLightPosition[{{.SpotLightsMax}}];
-uniform vec3  SpotLightDirection[{{.SpotLightsMax}}];
-uniform float SpotLightAngularDecay[{{.SpotLightsMax}}];
-uniform float SpotLightCutoffAngle[{{.SpotLightsMax}}];
-uniform float SpotLightLinearDecay[{{.SpotLightsMax}}];
-uniform float SpotLightQuadraticDecay[{{.SpotLightsMax}}];
-{{end}}
+// Maximum number of lights of each type.
+// They must be equal to the maximums of the light package.
+#define MAX_AMBIENT_LIGHTS 8
+#define MAX_DIR_LIGHTS     8
+#define MAX_POINT_LIGHTS   16
+#define MAX_SPOT_LIGHTS    16
+
+// Lights uniform block shared by all programs.
+// Positions and directions are in camera coordinates.
+layout(std140) uniform Lights {
+    vec4 LightCounts;                           // Number of ambient, directional, point and spot lights
+    vec4 AmbientLightColor[MAX_AMBIENT_LIGHTS]; // Color (rgb)
+    vec4 DirLightColor[MAX_DIR_LIGHTS];         // Color (rgb)
+    vec4 DirLightPosition[MAX_DIR_LIGHTS];      // Direction (xyz)
+    vec4 PointLightColor[MAX_POINT_LIGHTS];     // Color (rgb)
+    vec4 PointLightPosition[MAX_POINT_LIGHTS];  // Position (xyz)
+    vec4 PointLightDecay[MAX_POINT_LIGHTS];     // Linear (x) and quadratic (y) decay
+    vec4 SpotLightColor[MAX_SPOT_LIGHTS];       // Color (rgb)
+    vec4 SpotLightPosition[MAX_SPOT_LIGHTS];    // Position (xyz)
+    vec4 SpotLightDirection[MAX_SPOT_LIGHTS];   // Direction (xyz)
+    vec4 SpotLightParams[MAX_SPOT_LIGHTS];      // Angular decay (x), cutoff angle (y), linear (z) and quadratic (w) decay
+};
+
+/***
+ pointLightFactor returns the attenuation of the point light at the
+ specified index due to its distance to the specified position.
+ Parameters:
+    i:        index of the point light
+    position: input position in camera coordinates
+    L:        output normalized direction from the position to the light
+*/
+float pointLightFactor(int i, vec4 position, out vec3 L) {
+
+    L = PointLightPosition[i].xyz - vec3(position);
+    float lightDistance = length(L);
+    L = L / lightDistance;
+    vec4 decay = PointLightDecay[i];
+    return 1.0 / (1.0 + decay.x * lightDistance + decay.y * lightDistance * lightDistance);
+}
+
+/***
+ spotLightFactor returns the attenuation of the spot light at the specified
+ index due to its distance and angle to the specified position.
+ Positions outside of the light cutoff angle are not lit.
+ Parameters:
+    i:        index of the spot light
+    position: input position in camera coordinates
+    L:        output normalized direction from the position to the light
+*/
+float spotLightFactor(int i, vec4 position, out vec3 L) {
+
+    L = SpotLightPosition[i].xyz - vec3(position);
+    float lightDistance = length(L);
+    L = L / lightDistance;
+    vec4 params = SpotLightParams[i];
+
+    // Calculates the angle between the light direction and spot direction
+    float cosAngle = dot(-L, SpotLightDirection[i].xyz);
+    float cutoff = radians(clamp(params.y, 0.0, 90.0));
+    if (acos(cosAngle) >= cutoff) {
+        return 0.0;
+    }
+    float attenuation = 1.0 / (1.0 + params.z * lightDistance + params.w * lightDistance * lightDistance);
+    return attenuation * pow(cosAngle, params.x);
+}
+
+{{template "shadows" .}}
 `
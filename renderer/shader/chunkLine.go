@@ -0,0 +1,179 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddChunk("line_vertex", chunkLineVertex)
+	AddChunk("line_fragment", chunkLineFragment)
+}
+
+// Expands the vertices of the quads of the segments and of the joins of lines
+// drawn with the Line material to their positions in screen space.
+// Segment vertices have an endpoint of the segment as VertexPosition and the
+// other endpoint as LineOther. Join vertices have the joint as VertexPosition
+// and the previous and next points of the strip as LineOther and LineNeighbor.
+// The attributes have fixed locations, as the vertex arrays of the quads are
+// drawn by several programs, and pack other values in their fourth elements
+// to not exceed the 16 locations guaranteed by OpenGL.
+const chunkLineVertex = `
+// Attributes of the vertices of the quads of the segments and joins
+// LineOther.w packs the side of the segment or corner of the join plus one,
+// the kind of the vertex times 8 and the cap flag times 32.
+layout(location = 14) in vec4 LineOther;    // Other endpoint of the segment or previous point of the join (xyz)
+layout(location = 15) in vec4 LineNeighbor; // Next point of the join (xyz) and index of the segment (w)
+
+// Size of the viewport in pixels
+uniform vec2 Viewport;
+
+// Line material uniforms
+uniform float LineWidth;
+uniform int   LineCap;
+uniform int   LineJoin;
+uniform float LineMiterLimit;
+
+// Outputs for the fragment shader
+noperspective out vec2 LineCoord;   // Pixels along and across the segment or from the joint
+flat out float         LineLength;  // Length of the segment in pixels
+flat out float         LineRound;   // Round cap or join flag
+out float              LineDistance;
+
+// lineClipNear moves the specified clip coordinates which are in front of
+// the near plane to the near plane along the line to the other coordinates
+vec4 lineClipNear(vec4 pos, vec4 other) {
+
+    float d = pos.z + pos.w;
+    if (d < 0.0) {
+        pos = mix(pos, other, d / (d - other.z - other.w));
+    }
+    return pos;
+}
+
+// lineScreen returns the position in pixels of the specified clip coordinates
+vec2 lineScreen(vec4 pos) {
+
+    return pos.xy / pos.w * Viewport * 0.5;
+}
+
+// lineDir returns the direction in screen space between the specified positions
+vec2 lineDir(vec2 from, vec2 to) {
+
+    vec2 d = to - from;
+    float len = length(d);
+    if (len < 1e-6) {
+        return vec2(1.0, 0.0);
+    }
+    return d / len;
+}
+
+/***
+ lineVertex returns the clip coordinates of the current vertex of the
+ quads of the segments and joins offset in pixels from its endpoint.
+*/
+vec4 lineVertex() {
+
+    // Unpacks the side or corner (x), kind (y) and cap flag (z) of the vertex
+    int bits = int(LineOther.w + 0.5);
+    vec3 lineCorner = vec3(float(bits & 7) - 1.0, float((bits >> 3) & 3), float(bits >> 5));
+
+    float hw = LineWidth * 0.5;
+    vec4 pos = MVP * vec4(VertexPosition, 1.0);
+    vec4 other = MVP * vec4(LineOther.xyz, 1.0);
+    vec2 offset;
+    LineDistance = VertexDistance;
+
+    // Vertex of the quad of a segment
+    if (lineCorner.y < 1.5) {
+        bool end = lineCorner.y > 0.5;
+        vec4 clipped = lineClipNear(pos, other);
+        other = lineClipNear(other, pos);
+        pos = clipped;
+        vec2 s = lineScreen(pos);
+        vec2 so = lineScreen(other);
+        vec2 dir = end ? lineDir(so, s) : lineDir(s, so);
+        vec2 normal = vec2(-dir.y, dir.x);
+        // Caps other than butt extend beyond the endpoints
+        float ext = (lineCorner.z > 0.5 && LineCap != 0) ? hw : 0.0;
+        float len = length(s - so);
+        offset = normal * lineCorner.x * hw + dir * (end ? ext : -ext);
+        LineCoord = vec2(end ? len + ext : -ext, lineCorner.x * hw);
+        LineLength = len;
+        LineRound = LineCap == 2 ? 1.0 : 0.0;
+    // Vertex of the quad of a join
+    } else {
+        vec4 next = MVP * vec4(LineNeighbor.xyz, 1.0);
+        vec2 s = lineScreen(pos);
+        vec2 dprev = lineDir(lineScreen(lineClipNear(other, pos)), s);
+        vec2 dnext = lineDir(s, lineScreen(lineClipNear(next, pos)));
+        vec2 nprev = vec2(-dprev.y, dprev.x);
+        vec2 nnext = vec2(-dnext.y, dnext.x);
+        // The join fills the outer side of the turn
+        float side = (dprev.x * dnext.y - dprev.y * dnext.x) > 0.0 ? -1.0 : 1.0;
+        int corner = int(lineCorner.x + 0.5);
+        if (LineJoin == 2) {
+            // Square around the joint cut to a circle by the fragment shader
+            const vec2 square[4] = vec2[4](vec2(-1, -1), vec2(1, -1), vec2(1, 1), vec2(-1, 1));
+            offset = square[corner] * hw;
+        } else if (corner == 0) {
+            offset = vec2(0.0);
+        } else if (corner == 1) {
+            offset = nprev * side * hw;
+        } else if (corner == 3) {
+            offset = nnext * side * hw;
+        } else {
+            // Tip of the miter or middle of the bevel
+            float cosHalf = sqrt(max((1.0 + dot(nprev, nnext)) * 0.5, 0.0));
+            if (LineJoin == 0 && cosHalf * LineMiterLimit >= 1.0) {
+                offset = normalize(nprev + nnext) * side * hw / cosHalf;
+            } else {
+                offset = (nprev + nnext) * 0.5 * side * hw;
+            }
+        }
+        // Joints in front of the near plane are not visible
+        if (pos.z + pos.w < 0.0) {
+            offset = vec2(0.0);
+        }
+        LineCoord = offset;
+        LineLength = 0.0;
+        LineRound = LineJoin == 2 ? 1.0 : 0.0;
+    }
+    pos.xy += offset / Viewport * 2.0 * pos.w;
+    return pos;
+}
+`
+
+// Discards the fragments of lines drawn with the Line material which are
+// outside of their round caps and joins or in the gaps of their dashes.
+const chunkLineFragment = `
+// Inputs from the vertex shader
+noperspective in vec2 LineCoord;
+flat in float         LineLength;
+flat in float         LineRound;
+in float              LineDistance;
+
+// Line material uniforms
+uniform float LineWidth;
+uniform vec3  LineDash;     // Dash size (x), gap size (y) and offset (z)
+
+// lineDiscard discards the fragments outside of the line shape
+void lineDiscard() {
+
+    if (LineRound > 0.5) {
+        float d = abs(LineCoord.y);
+        if (LineCoord.x < 0.0) {
+            d = length(LineCoord);
+        } else if (LineCoord.x > LineLength) {
+            d = length(vec2(LineCoord.x - LineLength, LineCoord.y));
+        }
+        if (d > LineWidth * 0.5) {
+            discard;
+        }
+    }
+    if (LineDash.x > 0.0 && LineDash.y > 0.0) {
+        if (mod(LineDistance + LineDash.z, LineDash.x + LineDash.y) > LineDash.x) {
+            discard;
+        }
+    }
+}
+`
@@ -6,6 +6,8 @@ package shader
 
 func init() {
 	AddChunk("material", chunkMaterial)
+	AddChunk("material_maps", chunkMaterialMaps)
+	AddChunk("material_envmap", chunkMaterialEnvMap)
 }
 
 const chunkMaterial = `
@@ -25,3 +27,153 @@ uniform int       MatTexFlipY[{{.MatTexturesMax}}];
 uniform bool      MatTexVisible[{{.MatTexturesMax}}];
 {{ end }}
 `
+
+// Declares the texture maps with specific roles of the standard and phong
+// materials, which are sampled by the fragment shaders.
+const chunkMaterialMaps = `
+// Material texture maps
+#ifdef HAS_DIFFUSEMAP
+uniform sampler2D MatDiffuseMap;
+uniform int       MatDiffuseMapFlipY;
+uniform bool      MatDiffuseMapVisible;
+uniform vec2      MatDiffuseMapOffset;
+uniform vec2      MatDiffuseMapRepeat;
+#endif
+
+#ifdef HAS_NORMALMAP
+uniform sampler2D MatNormalMap;
+uniform int       MatNormalMapFlipY;
+uniform bool      MatNormalMapVisible;
+uniform vec2      MatNormalMapOffset;
+uniform vec2      MatNormalMapRepeat;
+uniform float     MatNormalScale;
+#endif
+
+#ifdef HAS_BUMPMAP
+uniform sampler2D MatBumpMap;
+uniform int       MatBumpMapFlipY;
+uniform bool      MatBumpMapVisible;
+uniform vec2      MatBumpMapOffset;
+uniform vec2      MatBumpMapRepeat;
+uniform float     MatBumpScale;
+#endif
+
+#ifdef HAS_SPECULARMAP
+uniform sampler2D MatSpecularMap;
+uniform int       MatSpecularMapFlipY;
+uniform bool      MatSpecularMapVisible;
+uniform vec2      MatSpecularMapOffset;
+uniform vec2      MatSpecularMapRepeat;
+#endif
+
+#ifdef HAS_EMISSIVEMAP
+uniform sampler2D MatEmissiveMap;
+uniform int       MatEmissiveMapFlipY;
+uniform bool      MatEmissiveMapVisible;
+uniform vec2      MatEmissiveMapOffset;
+uniform vec2      MatEmissiveMapRepeat;
+#endif
+
+#ifdef HAS_OCCLUSIONMAP
+uniform sampler2D MatOcclusionMap;
+uniform int       MatOcclusionMapFlipY;
+uniform bool      MatOcclusionMapVisible;
+uniform vec2      MatOcclusionMapOffset;
+uniform vec2      MatOcclusionMapRepeat;
+#endif
+
+#ifdef HAS_ALPHAMAP
+uniform sampler2D MatAlphaMap;
+uniform int       MatAlphaMapFlipY;
+uniform bool      MatAlphaMapVisible;
+uniform vec2      MatAlphaMapOffset;
+uniform vec2      MatAlphaMapRepeat;
+#endif
+
+/***
+ mapTexcoord returns the coordinates of a texture map
+ Parameters:
+    texcoord: input vertex texture coordinates
+    flipY:    input flag to flip the Y coordinate
+    offset:   input texture offset
+    repeat:   input texture repeat
+*/
+vec2 mapTexcoord(vec2 texcoord, int flipY, vec2 offset, vec2 repeat) {
+
+    if (flipY > 0) {
+        texcoord.y = 1.0 - texcoord.y;
+    }
+    return texcoord * repeat + offset;
+}
+
+/***
+ bumpNormal returns the normal perturbed by the derivatives of the height
+ of the surface in screen space, so it does not need tangents.
+ Parameters:
+    position: input fragment position in camera coordinates
+    normal:   input normalized surface normal in camera coordinates
+    height:   input height of the surface at the fragment
+*/
+vec3 bumpNormal(vec3 position, vec3 normal, float height) {
+
+    vec3 dpdx = dFdx(position);
+    vec3 dpdy = dFdy(position);
+    vec3 r1 = cross(dpdy, normal);
+    vec3 r2 = cross(normal, dpdx);
+    float det = dot(dpdx, r1);
+    vec3 grad = sign(det) * (dFdx(height) * r1 + dFdy(height) * r2);
+    return normalize(abs(det) * normal - grad);
+}
+
+/***
+ tangentNormal returns the normal in camera coordinates of the specified
+ normal map texel, which is in the tangent space of the surface.
+ Parameters:
+    normal:    input normalized surface normal in camera coordinates
+    tangent:   input tangent in camera coordinates and bitangent sign (w)
+    mapNormal: input texel of the normal map
+    scale:     input scale of the X and Y components of the map normal
+*/
+vec3 tangentNormal(vec3 normal, vec4 tangent, vec3 mapNormal, float scale) {
+
+    // Geometries without tangents keep the surface normal
+    vec3 T = tangent.xyz - normal * dot(normal, tangent.xyz);
+    if (dot(T, T) < 1e-8) {
+        return normal;
+    }
+    T = normalize(T);
+    vec3 B = cross(normal, T) * tangent.w;
+    vec3 N = mapNormal * 2.0 - 1.0;
+    N.xy *= scale;
+    return normalize(mat3(T, B, normal) * N);
+}
+`
+
+// Environment map uniforms and functions of the standard and phong
+// materials, which require the camera uniform block.
+const chunkMaterialEnvMap = `
+#ifdef HAS_ENVMAP
+uniform samplerCube MatEnvMap;
+uniform float       MatReflectivity;
+uniform float       MatRefractionRatio;
+
+/***
+ envMapDirection returns the direction in world coordinates in which the
+ environment map is reflected or refracted by the surface.
+ Parameters:
+    position: input position in camera coordinates
+    normal:   input normalized surface normal in camera coordinates
+*/
+vec3 envMapDirection(vec3 position, vec3 normal) {
+
+    vec3 incident = normalize(position);
+#ifdef ENVMAP_REFRACTION
+    vec3 dir = refract(incident, normal, MatRefractionRatio);
+#else
+    vec3 dir = reflect(incident, normal);
+#endif
+    // The transpose of the view rotation transforms to world coordinates
+    return transpose(mat3(ViewMatrix)) * dir;
+}
+#endif
+`
@@ -0,0 +1,40 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddChunk("morphing", chunkMorphing)
+	AddChunk("morph_transform", chunkMorphTransform)
+}
+
+// Declares the uniforms of the morph targets offsets and weights
+// when drawing meshes whose geometries have morph targets.
+const chunkMorphing = `
+{{if .MorphTargetsMax}}
+// Offsets of the positions and normals of the morph targets in rows of 1024 texels
+uniform sampler2D MorphTexture;
+uniform int MorphVertexCount;
+
+// Weights of the morph targets
+uniform float MorphWeights[{{.MorphTargetsMax}}];
+{{end}}
+`
+
+// Blends the vertex position and normal in model coordinates with
+// the weighted morph targets offsets fetched by the vertex index.
+const chunkMorphTransform = `
+    {{if .MorphTargetsMax}}
+    for (int i = 0; i < {{.MorphTargetsMax}}; i++) {
+        float weight = MorphWeights[i];
+        if (weight == 0.0) {
+            continue;
+        }
+        int pidx = 2 * i * MorphVertexCount + gl_VertexID;
+        int nidx = pidx + MorphVertexCount;
+        vertexPosition += weight * texelFetch(MorphTexture, ivec2(pidx % 1024, pidx / 1024), 0).xyz;
+        vertexNormal += weight * texelFetch(MorphTexture, ivec2(nidx % 1024, nidx / 1024), 0).xyz;
+    }
+    {{end}}
+`
@@ -9,115 +9,102 @@ func init() {
 }
 
 const chunkPhongModel = `
+/***
+ phongLight adds the diffuse and specular reflections of a light
+ Parameters:
+    L:           input normalized direction from the surface to the light
+    radiance:    input light color multiplied by its attenuation
+    normal:      input surface normal in camera coordinates
+    camDir:      input camera direction
+    matDiffuse:  input material diffuse color
+    matSpecular: input material specular color
+    diffuse:     input/output diffuse color
+    specular:    input/output specular color
+*/
+void phongLight(vec3 L, vec3 radiance, vec3 normal, vec3 camDir, vec3 matDiffuse, vec3 matSpecular, inout vec3 diffuse, inout vec3 specular) {
+
+    // Calculates the dot product between the light direction and this vertex normal.
+    float dotNormal = max(dot(L, normal), 0.0);
+    diffuse += radiance * matDiffuse * dotNormal;
+
+    // Specular reflection
+    // Calculates the light reflection vector
+    vec3 ref = reflect(-L, normal);
+    if (dotNormal > 0.0) {
+        specular += radiance * matSpecular * pow(max(dot(ref, camDir), 0.0), MatShininess);
+    }
+}
+
 /***
  phong lighting model
  Parameters:
-    position:   input vertex position in camera coordinates
-    normal:     input vertex normal in camera coordinates
-    camDir:     input camera directions
-    matAmbient: input material ambient color
-    matDiffuse: input material diffuse color
-    ambdiff:    output ambient+diffuse color
-    spec:       output specular color
+    position:    input vertex position in camera coordinates
+    normal:      input vertex normal in camera coordinates
+    camDir:      input camera directions
+    matAmbient:  input material ambient color
+    matDiffuse:  input material diffuse color
+    matSpecular: input material specular color
+    matEmissive: input material emissive color
+    ambdiff:     output ambient+diffuse color
+    spec:        output specular color
  Uniforms:
-    AmbientLightColor[]
-    DiffuseLightColor[]
-    DiffuseLightPosition[]
-    PointLightColor[]
-    PointLightPosition[];
-    PointLightLinearDecay[];
-    PointLightQuadraticDecay[];
-    MatSpecularColor
+    Lights uniform block
+    DirShadowMap[], DirShadowMatrix[], DirShadowBias[], DirShadowFilter[]
+    SpotShadowMap[], SpotShadowMatrix[], SpotShadowBias[], SpotShadowFilter[]
     MatShininess
 */
-void phongModel(vec4 position, vec3 normal, vec3 camDir, vec3 matAmbient, vec3 matDiffuse, out vec3 ambdiff, out vec3 spec) {
+void phongModel(vec4 position, vec3 normal, vec3 camDir, vec3 matAmbient, vec3 matDiffuse, vec3 matSpecular, vec3 matEmissive, out vec3 ambdiff, out vec3 spec) {
 
     vec3 ambientTotal  = vec3(0.0);
     vec3 diffuseTotal  = vec3(0.0);
     vec3 specularTotal = vec3(0.0);
 
-    {{ range loop .AmbientLightsMax }}
-        ambientTotal += AmbientLightColor[{{.}}] * matAmbient;
-    {{ end }}
+    for (int i = 0; i < int(LightCounts.x); i++) {
+        ambientTotal += AmbientLightColor[i].rgb * matAmbient;
+    }
 
-    {{ range loop .DirLightsMax }}
+    // Lights which cast shadows are the first ones. They are unrolled
+    // because arrays of samplers can only be indexed by constants.
+    {{ range loop .DirShadowsMax }}
     {
-        // Diffuse reflection
         // DirLightPosition is the direction of the current light
-        vec3 lightDirection = normalize(DirLightPosition[{{.}}]);
-        // Calculates the dot product between the light direction and this vertex normal.
-        float dotNormal = max(dot(lightDirection, normal), 0.0);
-        diffuseTotal += DirLightColor[{{.}}] * matDiffuse * dotNormal;
-
-        // Specular reflection
-        // Calculates the light reflection vector 
-        vec3 ref = reflect(-lightDirection, normal);
-        if (dotNormal > 0.0) {
-            specularTotal += DirLightColor[{{.}}] * MatSpecularColor * pow(max(dot(ref, camDir), 0.0), MatShininess);
-        }
+        vec3 L = normalize(DirLightPosition[{{.}}].xyz);
+        // Calculates the fraction of this light not blocked by shadow casters.
+        float shadow = shadowFactor(DirShadowMap[{{.}}], DirShadowMatrix[{{.}}], DirShadowBias[{{.}}], DirShadowFilter[{{.}}], position);
+        phongLight(L, DirLightColor[{{.}}].rgb * shadow, normal, camDir, matDiffuse, matSpecular, diffuseTotal, specularTotal);
     }
     {{ end }}
+    for (int i = {{.DirShadowsMax}}; i < int(LightCounts.y); i++) {
+        vec3 L = normalize(DirLightPosition[i].xyz);
+        phongLight(L, DirLightColor[i].rgb, normal, camDir, matDiffuse, matSpecular, diffuseTotal, specularTotal);
+    }
 
-    {{ range loop .PointLightsMax }}
-    {
-        // Calculates the direction and distance from the current vertex to this point light.
-        vec3 lightDirection = PointLightPosition[{{.}}] - vec3(position);
-        float lightDistance = length(lightDirection);
-        // Normalizes the lightDirection
-        lightDirection = lightDirection / lightDistance;
-        // Calculates the attenuation due to the distance of the light
-        float attenuation = 1.0 / (1.0 + PointLightLinearDecay[{{.}}] * lightDistance +
-            PointLightQuadraticDecay[{{.}}] * lightDistance * lightDistance);
-
-        // Diffuse reflection
-        float dotNormal = max(dot(lightDirection, normal), 0.0);
-        diffuseTotal += PointLightColor[{{.}}] * matDiffuse * dotNormal * attenuation;
-        
-        // Specular reflection
-        // Calculates the light reflection vector 
-        vec3 ref = reflect(-lightDirection, normal);
-        if (dotNormal > 0.0) {
-            specularTotal += PointLightColor[{{.}}] * MatSpecularColor *
-                pow(max(dot(ref, camDir), 0.0), MatShininess) * attenuation;
-        }
+    for (int i = 0; i < int(LightCounts.z); i++) {
+        vec3 L;
+        float attenuation = pointLightFactor(i, position, L);
+        phongLight(L, PointLightColor[i].rgb * attenuation, normal, camDir, matDiffuse, matSpecular, diffuseTotal, specularTotal);
     }
-    {{ end }}
 
-    {{ range loop .SpotLightsMax }}
+    {{ range loop .SpotShadowsMax }}
     {
-        // Calculates the direction and distance from the current vertex to this spot light.
-        vec3 lightDirection = SpotLightPosition[{{.}}] - vec3(position);
-        float lightDistance = length(lightDirection);
-        lightDirection = lightDirection / lightDistance;
-
-        // Calculates the attenuation due to the distance of the light
-        float attenuation = 1.0 / (1.0 + SpotLightLinearDecay[{{.}}] * lightDistance +
-            SpotLightQuadraticDecay[{{.}}] * lightDistance * lightDistance);
-
-        // Calculates the angle between the vertex direction and spot direction
-        // If this angle is greater than the cutoff the spotlight will not contribute
-        // to the final color.
-        float angle = acos(dot(-lightDirection, SpotLightDirection[{{.}}]));
-        float cutoff = radians(clamp(SpotLightCutoffAngle[{{.}}], 0.0, 90.0));
-
-        if (angle < cutoff) {
-            float spotFactor = pow(dot(-lightDirection, SpotLightDirection[{{.}}]), SpotLightAngularDecay[{{.}}]);
-
-            // Diffuse reflection
-            float dotNormal = max(dot(lightDirection, normal), 0.0);
-            diffuseTotal += SpotLightColor[{{.}}] * matDiffuse * dotNormal * attenuation * spotFactor;
-
-            // Specular reflection
-            vec3 ref = reflect(-lightDirection, normal);
-            if (dotNormal > 0.0) {
-                specularTotal += SpotLightColor[{{.}}] * MatSpecularColor * pow(max(dot(ref, camDir), 0.0), MatShininess) * attenuation * spotFactor;
-            }
+        vec3 L;
+        float spotFactor = spotLightFactor({{.}}, position, L);
+        if (spotFactor > 0.0) {
+            spotFactor *= shadowFactor(SpotShadowMap[{{.}}], SpotShadowMatrix[{{.}}], SpotShadowBias[{{.}}], SpotShadowFilter[{{.}}], position);
+            phongLight(L, SpotLightColor[{{.}}].rgb * spotFactor, normal, camDir, matDiffuse, matSpecular, diffuseTotal, specularTotal);
         }
     }
     {{ end }}
+    for (int i = {{.SpotShadowsMax}}; i < int(LightCounts.w); i++) {
+        vec3 L;
+        float spotFactor = spotLightFactor(i, position, L);
+        if (spotFactor > 0.0) {
+            phongLight(L, SpotLightColor[i].rgb * spotFactor, normal, camDir, matDiffuse, matSpecular, diffuseTotal, specularTotal);
+        }
+    }
 
     // Sets output colors
-    ambdiff = ambientTotal + MatEmissiveColor + diffuseTotal;
+    ambdiff = ambientTotal + matEmissive + diffuseTotal;
     spec = specularTotal;
 }
 `
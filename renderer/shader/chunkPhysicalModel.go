@@ -0,0 +1,195 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddChunk("physical_material", chunkPhysicalMaterial)
+	AddChunk("physical_model", chunkPhysicalModel)
+}
+
+const chunkPhysicalMaterial = `
+// Physical material uniforms
+uniform vec4  MatBaseColor;
+uniform float MatMetallic;
+uniform float MatRoughness;
+uniform float MatOcclusionStrength;
+uniform vec3  MatEmissiveColor;
+
+// Physical material texture maps
+#ifdef HAS_BASECOLORMAP
+uniform sampler2D MatBaseColorMap;
+uniform int       MatBaseColorMapFlipY;
+uniform bool      MatBaseColorMapVisible;
+uniform vec2      MatBaseColorMapOffset;
+uniform vec2      MatBaseColorMapRepeat;
+#endif
+
+#ifdef HAS_METALLICROUGHNESSMAP
+uniform sampler2D MatMetallicRoughnessMap;
+uniform int       MatMetallicRoughnessMapFlipY;
+uniform bool      MatMetallicRoughnessMapVisible;
+uniform vec2      MatMetallicRoughnessMapOffset;
+uniform vec2      MatMetallicRoughnessMapRepeat;
+#endif
+
+#ifdef HAS_OCCLUSIONMAP
+uniform sampler2D MatOcclusionMap;
+uniform int       MatOcclusionMapFlipY;
+uniform bool      MatOcclusionMapVisible;
+uniform vec2      MatOcclusionMapOffset;
+uniform vec2      MatOcclusionMapRepeat;
+#endif
+
+#ifdef HAS_EMISSIVEMAP
+uniform sampler2D MatEmissiveMap;
+uniform int       MatEmissiveMapFlipY;
+uniform bool      MatEmissiveMapVisible;
+uniform vec2      MatEmissiveMapOffset;
+uniform vec2      MatEmissiveMapRepeat;
+#endif
+
+#ifdef HAS_ENVMAP
+uniform samplerCube MatEnvMap;
+uniform float       MatEnvIntensity;
+#endif
+`
+
+const chunkPhysicalModel = `
+const float PI = 3.14159265359;
+
+// Normal distribution function (Trowbridge-Reitz GGX)
+float distributionGGX(float NdotH, float roughness) {
+
+    float a2 = roughness * roughness * roughness * roughness;
+    float d = NdotH * NdotH * (a2 - 1.0) + 1.0;
+    return a2 / (PI * d * d);
+}
+
+// Geometry function (Smith with Schlick-GGX for direct lighting)
+float geometrySmith(float NdotV, float NdotL, float roughness) {
+
+    float r = roughness + 1.0;
+    float k = (r * r) / 8.0;
+    float geomV = NdotV / (NdotV * (1.0 - k) + k);
+    float geomL = NdotL / (NdotL * (1.0 - k) + k);
+    return geomV * geomL;
+}
+
+// Fresnel function (Schlick approximation)
+vec3 fresnelSchlick(float cosTheta, vec3 F0) {
+
+    return F0 + (1.0 - F0) * pow(1.0 - cosTheta, 5.0);
+}
+
+/***
+ cookTorrance returns the radiance reflected to the camera from a light
+ Parameters:
+    L:         direction from the surface to the light
+    V:         direction from the surface to the camera
+    N:         surface normal
+    albedo:    surface base color
+    F0:        surface reflectance at normal incidence
+    metallic:  surface metallic factor
+    roughness: surface roughness factor
+    radiance:  light color reaching the surface
+*/
+vec3 cookTorrance(vec3 L, vec3 V, vec3 N, vec3 albedo, vec3 F0, float metallic, float roughness, vec3 radiance) {
+
+    float NdotL = max(dot(N, L), 0.0);
+    if (NdotL <= 0.0) {
+        return vec3(0.0);
+    }
+    vec3 H = normalize(V + L);
+    float NdotV = max(dot(N, V), 0.0001);
+    float NdotH = max(dot(N, H), 0.0);
+
+    float D = distributionGGX(NdotH, roughness);
+    float G = geometrySmith(NdotV, NdotL, roughness);
+    vec3  F = fresnelSchlick(max(dot(H, V), 0.0), F0);
+    vec3 specular = (D * G * F) / (4.0 * NdotV * NdotL + 0.0001);
+
+    // Metals have no diffuse reflection
+    vec3 kD = (vec3(1.0) - F) * (1.0 - metallic);
+    return (kD * albedo / PI + specular) * radiance * NdotL;
+}
+
+/***
+ physicalModel calculates the light reflected from all the lights
+ Parameters:
+    position:  input surface position in camera coordinates
+    N:         input surface normal in camera coordinates
+    V:         input direction from the surface to the camera
+    albedo:    input surface base color
+    F0:        input surface reflectance at normal incidence
+    metallic:  input surface metallic factor
+    roughness: input surface roughness factor
+    direct:    output color reflected from the directional, point and spot lights
+    ambient:   output color reflected from the ambient lights
+*/
+void physicalModel(vec4 position, vec3 N, vec3 V, vec3 albedo, vec3 F0, float metallic, float roughness, out vec3 direct, out vec3 ambient) {
+
+    direct = vec3(0.0);
+    ambient = vec3(0.0);
+
+    for (int i = 0; i < int(LightCounts.x); i++) {
+        ambient += AmbientLightColor[i].rgb * albedo;
+    }
+
+    // Lights which cast shadows are the first ones. They are unrolled
+    // because arrays of samplers can only be indexed by constants.
+    {{ range loop .DirShadowsMax }}
+    {
+        // DirLightPosition is the direction of the current light
+        vec3 L = normalize(DirLightPosition[{{.}}].xyz);
+        float shadow = shadowFactor(DirShadowMap[{{.}}], DirShadowMatrix[{{.}}], DirShadowBias[{{.}}], DirShadowFilter[{{.}}], position);
+        direct += cookTorrance(L, V, N, albedo, F0, metallic, roughness, DirLightColor[{{.}}].rgb * shadow);
+    }
+    {{ end }}
+    for (int i = {{.DirShadowsMax}}; i < int(LightCounts.y); i++) {
+        vec3 L = normalize(DirLightPosition[i].xyz);
+        direct += cookTorrance(L, V, N, albedo, F0, metallic, roughness, DirLightColor[i].rgb);
+    }
+
+    for (int i = 0; i < int(LightCounts.z); i++) {
+        vec3 L;
+        float attenuation = pointLightFactor(i, position, L);
+        direct += cookTorrance(L, V, N, albedo, F0, metallic, roughness, PointLightColor[i].rgb * attenuation);
+    }
+
+    {{ range loop .SpotShadowsMax }}
+    {
+        vec3 L;
+        float spotFactor = spotLightFactor({{.}}, position, L);
+        if (spotFactor > 0.0) {
+            spotFactor *= shadowFactor(SpotShadowMap[{{.}}], SpotShadowMatrix[{{.}}], SpotShadowBias[{{.}}], SpotShadowFilter[{{.}}], position);
+            direct += cookTorrance(L, V, N, albedo, F0, metallic, roughness, SpotLightColor[{{.}}].rgb * spotFactor);
+        }
+    }
+    {{ end }}
+    for (int i = {{.SpotShadowsMax}}; i < int(LightCounts.w); i++) {
+        vec3 L;
+        float spotFactor = spotLightFactor(i, position, L);
+        if (spotFactor > 0.0) {
+            direct += cookTorrance(L, V, N, albedo, F0, metallic, roughness, SpotLightColor[i].rgb * spotFactor);
+        }
+    }
+}
+
+#ifdef HAS_ENVMAP
+/***
+ envBRDF returns the scale and bias applied to F0 by the split sum
+ approximation of the specular environment lighting, using an
+ analytical fit instead of a precomputed lookup texture.
+*/
+vec2 envBRDF(float NdotV, float roughness) {
+
+    const vec4 c0 = vec4(-1.0, -0.0275, -0.572, 0.022);
+    const vec4 c1 = vec4(1.0, 0.0425, 1.04, -0.04);
+    vec4 r = roughness * c0 + c1;
+    float a004 = min(r.x * r.x, exp2(-9.28 * NdotV)) * r.x + r.y;
+    return vec2(-1.04, 1.04) * a004 + r.zw;
+}
+#endif
+`
@@ -0,0 +1,65 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddChunk("shadows", chunkShadows)
+}
+
+const chunkShadows = `
+{{if .DirShadowsMax}}
+// Directional lights shadows uniforms
+uniform sampler2DShadow DirShadowMap[{{.DirShadowsMax}}];
+uniform mat4  DirShadowMatrix[{{.DirShadowsMax}}];
+uniform float DirShadowBias[{{.DirShadowsMax}}];
+uniform int   DirShadowFilter[{{.DirShadowsMax}}];
+{{end}}
+
+{{if .SpotShadowsMax}}
+// Spot lights shadows uniforms
+uniform sampler2DShadow SpotShadowMap[{{.SpotShadowsMax}}];
+uniform mat4  SpotShadowMatrix[{{.SpotShadowsMax}}];
+uniform float SpotShadowBias[{{.SpotShadowsMax}}];
+uniform int   SpotShadowFilter[{{.SpotShadowsMax}}];
+{{end}}
+
+{{if or .DirShadowsMax .SpotShadowsMax}}
+/***
+ shadowFactor returns the fraction of light from 0.0 to 1.0 which reaches the
+ specified position in camera coordinates, sampling the specified shadow map.
+ Parameters:
+    smap:     shadow map
+    smatrix:  matrix which transforms camera coordinates to shadow map coordinates
+    bias:     depth bias
+    filter:   radius in texels of the percentage closer filter
+    position: position in camera coordinates
+*/
+float shadowFactor(sampler2DShadow smap, mat4 smatrix, float bias, int filter, vec4 position) {
+
+    vec4 coord = smatrix * position;
+    coord.xyz = coord.xyz / coord.w;
+
+    // Positions outside of the shadow camera frustum are not shadowed
+    if (coord.z > 1.0 || any(lessThan(coord.xy, vec2(0.0))) || any(greaterThan(coord.xy, vec2(1.0)))) {
+        return 1.0;
+    }
+    float ref = coord.z - bias;
+    if (filter == 0) {
+        return texture(smap, vec3(coord.xy, ref));
+    }
+
+    // Percentage closer filtering
+    vec2 texel = 1.0 / vec2(textureSize(smap, 0));
+    float lit = 0.0;
+    for (int x = -filter; x <= filter; x++) {
+        for (int y = -filter; y <= filter; y++) {
+            lit += texture(smap, vec3(coord.xy + vec2(x, y) * texel, ref));
+        }
+    }
+    float size = float(2 * filter + 1);
+    return lit / (size * size);
+}
+{{end}}
+`
@@ -0,0 +1,38 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddChunk("skinning", chunkSkinning)
+	AddChunk("skin_transform", chunkSkinTransform)
+}
+
+// Declares the attributes and uniforms of the vertices deformed
+// by the bones of a skeleton when drawing skinned meshes.
+const chunkSkinning = `
+{{if .BonesMax}}
+// Skinning attributes: indices and weights of the bones of each vertex
+layout(location = 11) in vec4  VertexJoints;
+layout(location = 12) in vec4  VertexWeights;
+
+// Transforms of the bones from the bind pose to the current pose
+uniform mat4 BoneMatrices[{{.BonesMax}}];
+{{end}}
+`
+
+// Transforms the vertex position, normal and tangent in model coordinates
+// by the weighted bone matrices when drawing skinned meshes.
+const chunkSkinTransform = `
+    {{if .BonesMax}}
+    mat4 skinMatrix =
+        VertexWeights.x * BoneMatrices[int(VertexJoints.x)] +
+        VertexWeights.y * BoneMatrices[int(VertexJoints.y)] +
+        VertexWeights.z * BoneMatrices[int(VertexJoints.z)] +
+        VertexWeights.w * BoneMatrices[int(VertexJoints.w)];
+    vertexPosition = vec3(skinMatrix * vec4(vertexPosition, 1.0));
+    vertexNormal = mat3(skinMatrix) * vertexNormal;
+    vertexTangent = mat3(skinMatrix) * vertexTangent;
+    {{end}}
+`
@@ -0,0 +1,60 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddShader("shaderBackgroundVertex", shaderBackgroundVertex)
+	AddShader("shaderBackgroundFrag", shaderBackgroundFrag)
+	AddProgram("shaderBackground", "shaderBackgroundVertex", "shaderBackgroundFrag")
+}
+
+// Vertex Shader template of the environment background.
+// Draws a triangle which covers the viewport without vertex attributes.
+const shaderBackgroundVertex = `
+#version {{.Version}}
+
+// Transforms the far plane normalized device coordinates to world directions
+uniform mat4 BackgroundMatrix;
+
+// Output variables for Fragment shader
+out vec3 Direction;
+
+void main() {
+
+    vec2 pos = vec2(float((gl_VertexID & 1) << 2) - 1.0, float((gl_VertexID & 2) << 1) - 1.0);
+    vec4 dir = BackgroundMatrix * vec4(pos, 1.0, 1.0);
+    Direction = dir.xyz / dir.w;
+    gl_Position = vec4(pos, 1.0, 1.0);
+}
+`
+
+// Fragment Shader template of the environment background.
+// Samples the cube map if BACKGROUND_CUBE is defined or
+// else interpolates the gradient colors.
+const shaderBackgroundFrag = `
+#version {{.Version}}
+
+// Inputs from vertex shader
+in vec3 Direction;
+
+#ifdef BACKGROUND_CUBE
+uniform samplerCube BackgroundCube;
+#else
+uniform vec3 BackgroundTop;
+uniform vec3 BackgroundBottom;
+#endif
+
+out vec4 FragColor;
+
+void main() {
+
+    vec3 dir = normalize(Direction);
+#ifdef BACKGROUND_CUBE
+    FragColor = vec4(texture(BackgroundCube, dir).rgb, 1.0);
+#else
+    FragColor = vec4(mix(BackgroundBottom, BackgroundTop, dir.y * 0.5 + 0.5), 1.0);
+#endif
+}
+`
@@ -28,8 +28,12 @@ out vec3 Color;
 
 void main() {
 
+    {{template "instance_transform" .}}
     Color = VertexColor;
-    gl_Position = MVP * vec4(VertexPosition, 1.0);
+    {{if .Instanced}}
+    Color *= InstanceColor.rgb;
+    {{end}}
+    gl_Position = MVP * vec4(vertexPosition, 1.0);
 }
 `
 
@@ -39,11 +43,14 @@ void main() {
 const shaderBasicFrag = `
 #version {{.Version}}
 
+{{template "lod_fade" .}}
+
 in vec3 Color;
 out vec4 FragColor;
 
 void main() {
 
+    lodFade();
     FragColor = vec4(Color, 1.0);
 }
 
@@ -0,0 +1,160 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddShader("shaderDeferredLightVertex", shaderDeferredLightVertex)
+	AddShader("shaderDeferredLightFrag", shaderDeferredLightFrag)
+	AddProgram("shaderDeferredLight", "shaderDeferredLightVertex", "shaderDeferredLightFrag")
+	AddShader("shaderDeferredPointVertex", shaderDeferredPointVertex)
+	AddShader("shaderDeferredPointFrag", shaderDeferredPointFrag)
+	AddProgram("shaderDeferredPoint", "shaderDeferredPointVertex", "shaderDeferredPointFrag")
+}
+
+// Vertex Shader template of the deferred directional and spot lights pass.
+// Draws a triangle which covers the viewport without vertex attributes.
+const shaderDeferredLightVertex = `
+#version {{.Version}}
+
+void main() {
+
+    vec2 pos = vec2(float((gl_VertexID & 1) << 2) - 1.0, float((gl_VertexID & 2) << 1) - 1.0);
+    gl_Position = vec4(pos, 0.0, 1.0);
+}
+`
+
+// Fragment Shader template of the deferred directional and spot lights pass.
+// Adds the G-buffer ambient and emissive light to the light of the
+// directional and spot lights and writes the G-buffer depth.
+const shaderDeferredLightFrag = `
+#version {{.Version}}
+
+{{template "lights" .}}
+{{template "physical_model" .}}
+{{template "gbuffer" .}}
+{{template "fog" .}}
+
+out vec4 FragColor;
+
+void main() {
+
+    // Fragments without surfaces keep the current color
+    vec2 texcoord = gbufferTexcoord();
+    float depth = texture(GBufferDepth, texcoord).r;
+    if (depth >= 1.0) {
+        discard;
+    }
+    Surface s = gbufferSurface(texcoord, depth);
+    vec3 color = texture(GBufferEmissive, texcoord).rgb;
+
+    // Lights which cast shadows are the first ones. They are unrolled
+    // because arrays of samplers can only be indexed by constants.
+    {{ range loop .DirShadowsMax }}
+    {
+        vec3 L = normalize(DirLightPosition[{{.}}].xyz);
+        float shadow = mix(1.0, shadowFactor(DirShadowMap[{{.}}], DirShadowMatrix[{{.}}], DirShadowBias[{{.}}], DirShadowFilter[{{.}}], s.position), s.shadows);
+        color += surfaceLight(s, L, DirLightColor[{{.}}].rgb * shadow);
+    }
+    {{ end }}
+    for (int i = {{.DirShadowsMax}}; i < int(LightCounts.y); i++) {
+        vec3 L = normalize(DirLightPosition[i].xyz);
+        color += surfaceLight(s, L, DirLightColor[i].rgb);
+    }
+
+    {{ range loop .SpotShadowsMax }}
+    {
+        vec3 L;
+        float spotFactor = spotLightFactor({{.}}, s.position, L);
+        if (spotFactor > 0.0) {
+            spotFactor *= mix(1.0, shadowFactor(SpotShadowMap[{{.}}], SpotShadowMatrix[{{.}}], SpotShadowBias[{{.}}], SpotShadowFilter[{{.}}], s.position), s.shadows);
+            color += surfaceLight(s, L, SpotLightColor[{{.}}].rgb * spotFactor);
+        }
+    }
+    {{ end }}
+    for (int i = {{.SpotShadowsMax}}; i < int(LightCounts.w); i++) {
+        vec3 L;
+        float spotFactor = spotLightFactor(i, s.position, L);
+        if (spotFactor > 0.0) {
+            color += surfaceLight(s, L, SpotLightColor[i].rgb * spotFactor);
+        }
+    }
+
+    FragColor = vec4(mix(color, FogColor.rgb, fogFactor(-s.position.z)), 1.0);
+    // Forward rendered graphics are depth tested against the G-buffer surfaces
+    gl_FragDepth = depth;
+}
+`
+
+// Vertex Shader template of the deferred point lights pass.
+// Each instance is a sphere which encloses the volume lit by a point light.
+const shaderDeferredPointVertex = `
+#version {{.Version}}
+
+// Unit sphere vertex position
+layout(location = 0) in vec3 VertexPosition;
+
+// Point light instance attributes
+in vec4 LightPosition; // Position in camera coordinates (xyz) and radius (w)
+in vec3 LightColor;    // Color multiplied by intensity
+in vec2 LightDecay;    // Linear (x) and quadratic (y) decay
+
+{{template "camera" .}}
+
+// Output variables for Fragment shader
+flat out vec4 FragLightPosition;
+flat out vec3 FragLightColor;
+flat out vec2 FragLightDecay;
+
+void main() {
+
+    FragLightPosition = LightPosition;
+    FragLightColor = LightColor;
+    FragLightDecay = LightDecay;
+
+    // The sphere is enlarged so its faces enclose the light radius
+    vec3 position = LightPosition.xyz + VertexPosition * LightPosition.w * 1.05;
+    gl_Position = ProjMatrix * vec4(position, 1.0);
+}
+`
+
+// Fragment Shader template of the deferred point lights pass.
+// The light of each volume is added to the current color.
+const shaderDeferredPointFrag = `
+#version {{.Version}}
+
+{{template "lights" .}}
+{{template "physical_model" .}}
+{{template "gbuffer" .}}
+{{template "fog" .}}
+
+// Inputs from vertex shader
+flat in vec4 FragLightPosition;
+flat in vec3 FragLightColor;
+flat in vec2 FragLightDecay;
+
+out vec4 FragColor;
+
+void main() {
+
+    vec2 texcoord = gbufferTexcoord();
+    float depth = texture(GBufferDepth, texcoord).r;
+    if (depth >= 1.0) {
+        discard;
+    }
+    Surface s = gbufferSurface(texcoord, depth);
+
+    // Surfaces outside of the light radius are not lit
+    vec3 L = FragLightPosition.xyz - s.position.xyz;
+    float lightDistance = length(L);
+    if (lightDistance > FragLightPosition.w) {
+        discard;
+    }
+    L = L / lightDistance;
+    float attenuation = 1.0 / (1.0 + FragLightDecay.x * lightDistance + FragLightDecay.y * lightDistance * lightDistance);
+    // The light is attenuated by the fog between the surface and the camera
+    vec3 color = surfaceLight(s, L, FragLightColor * attenuation);
+    FragColor = vec4(color * (1.0 - fogFactor(-s.position.z)), 1.0);
+}
+`
@@ -0,0 +1,41 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddShader("shaderDepthVertex", shaderDepthVertex)
+	AddShader("shaderDepthFrag", shaderDepthFrag)
+	AddProgram("shaderDepth", "shaderDepthVertex", "shaderDepthFrag")
+}
+
+//
+// Vertex Shader template
+//
+const shaderDepthVertex = `
+#version {{.Version}}
+
+{{template "attributes" .}}
+
+// Model uniforms
+uniform mat4 MVP;
+
+void main() {
+
+    {{template "instance_transform" .}}
+    gl_Position = MVP * vec4(vertexPosition, 1.0);
+}
+`
+
+//
+// Fragment Shader template
+// Only the depth buffer is written
+//
+const shaderDepthFrag = `
+#version {{.Version}}
+
+void main() {
+
+}
+`
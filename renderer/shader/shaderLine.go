@@ -0,0 +1,53 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddShader("shaderLineVertex", shaderLineVertex)
+	AddShader("shaderLineFrag", shaderLineFrag)
+	AddProgram("shaderLine", "shaderLineVertex", "shaderLineFrag")
+}
+
+// Vertex Shader template of lines drawn as screen-space quads
+const shaderLineVertex = `
+#version {{.Version}}
+
+{{template "attributes" .}}
+
+// Model uniforms
+uniform mat4 MVP;
+
+{{template "line_vertex" .}}
+
+// Output color for fragment shader
+out vec3 Color;
+
+void main() {
+
+    Color = VertexColor;
+    gl_Position = lineVertex();
+}
+`
+
+// Fragment Shader template of lines drawn as screen-space quads
+const shaderLineFrag = `
+#version {{.Version}}
+
+{{template "material" .}}
+{{template "line_fragment" .}}
+{{template "fog" .}}
+{{template "lod_fade" .}}
+
+in vec3 Color;
+out vec4 FragColor;
+
+void main() {
+
+    lodFade();
+    lineDiscard();
+    FragColor = vec4(Color * MatDiffuseColor, MatOpacity);
+    FragColor.rgb = applyFog(FragColor.rgb);
+}
+`
@@ -30,29 +30,39 @@ out vec4 Position;
 out vec3 Normal;
 out vec3 CamDir;
 out vec2 FragTexcoord;
+#ifdef HAS_NORMALMAP
+out vec4 Tangent;
+#endif
+{{if .Instanced}}
+out vec4 FragInstanceColor;
+{{end}}
 
 void main() {
 
+    {{template "instance_transform" .}}
+
     // Transform this vertex position to camera coordinates.
-    Position = ModelViewMatrix * vec4(VertexPosition, 1.0);
+    Position = ModelViewMatrix * vec4(vertexPosition, 1.0);
 
     // Transform this vertex normal to camera coordinates.
-    Normal = normalize(NormalMatrix * VertexNormal);
+    Normal = normalize(NormalMatrix * vertexNormal);
 
     // Calculate the direction vector from the vertex to the camera
     // The camera is at 0,0,0
     CamDir = normalize(-Position.xyz);
 
-    // Flips texture coordinate Y if requested.
-    vec2 texcoord = VertexTexcoord;
-    {{ if .MatTexturesMax }}
-    if (MatTexFlipY[0] > 0) {
-        texcoord.y = 1 - texcoord.y;
-    }
-    {{ end }}
-    FragTexcoord = texcoord;
+    // Transform this vertex tangent to camera coordinates.
+#ifdef HAS_NORMALMAP
+    Tangent = vec4(normalize(mat3(ModelViewMatrix) * vertexTangent), VertexTangent.w);
+#endif
 
-    gl_Position = MVP * vec4(VertexPosition, 1.0);
+    // Texture coordinates are flipped for each texture in the fragment shader
+    FragTexcoord = VertexTexcoord;
+    {{if .Instanced}}
+    FragInstanceColor = InstanceColor;
+    {{end}}
+
+    gl_Position = MVP * vec4(vertexPosition, 1.0);
 }
 `
 
@@ -67,21 +77,46 @@ in vec4 Position;       // Vertex position in camera coordinates.
 in vec3 Normal;         // Vertex normal in camera coordinates.
 in vec3 CamDir;         // Direction from vertex to camera
 in vec2 FragTexcoord;
-
+#ifdef HAS_NORMALMAP
+in vec4 Tangent;        // Vertex tangent in camera coordinates and bitangent sign
+#endif
+{{if .Instanced}}
+in vec4 FragInstanceColor;
+{{end}}
+
+{{template "camera" .}}
 {{template "lights" .}}
 {{template "material" .}}
+{{template "material_maps" .}}
+{{template "material_envmap" .}}
 {{template "phong_model" .}}
+{{template "fog" .}}
+{{template "lod_fade" .}}
 
+{{if .GBuffer}}
+{{template "gbuffer_output" .}}
+{{else}}
 // Final fragment color
 out vec4 FragColor;
+{{end}}
 
 void main() {
 
+    lodFade();
+
+    // Flips texture coordinate Y if requested.
+    vec2 texcoord = FragTexcoord;
+    {{ if .MatTexturesMax }}
+    if (MatTexFlipY[0] > 0) {
+        texcoord.y = 1 - texcoord.y;
+    }
+    {{ end }}
+
     // Combine all texture colors
     vec4 texCombined = vec4(1);
     {{ range loop .MatTexturesMax }}
     if (MatTexVisible[{{.}}] == true) {
-        vec4 texcolor = texture(MatTexture[{{.}}], FragTexcoord * MatTexRepeat[{{.}}] + MatTexOffset[{{.}}]);
+        vec4 texcolor = texture(MatTexture[{{.}}], texcoord * MatTexRepeat[{{.}}] + MatTexOffset[{{.}}]);
         if ({{.}} == 0) {
             texCombined = texcolor;
         } else {
@@ -89,23 +124,95 @@ void main() {
         }
     }
     {{ end }}
+#ifdef HAS_DIFFUSEMAP
+    if (MatDiffuseMapVisible) {
+        texCombined *= texture(MatDiffuseMap, mapTexcoord(FragTexcoord, MatDiffuseMapFlipY, MatDiffuseMapOffset, MatDiffuseMapRepeat));
+    }
+#endif
 
     // Combine material with texture colors
     vec4 matDiffuse = vec4(MatDiffuseColor, MatOpacity) * texCombined;
     vec4 matAmbient = vec4(MatAmbientColor, MatOpacity) * texCombined;
+    {{if .Instanced}}
+    matDiffuse *= FragInstanceColor;
+    matAmbient *= FragInstanceColor;
+    {{end}}
+#ifdef HAS_ALPHAMAP
+    if (MatAlphaMapVisible) {
+        matDiffuse.a *= texture(MatAlphaMap, mapTexcoord(FragTexcoord, MatAlphaMapFlipY, MatAlphaMapOffset, MatAlphaMapRepeat)).r;
+    }
+#endif
+#ifdef HAS_OCCLUSIONMAP
+    if (MatOcclusionMapVisible) {
+        matAmbient.rgb *= texture(MatOcclusionMap, mapTexcoord(FragTexcoord, MatOcclusionMapFlipY, MatOcclusionMapOffset, MatOcclusionMapRepeat)).r;
+    }
+#endif
+    vec3 matSpecular = MatSpecularColor;
+#ifdef HAS_SPECULARMAP
+    if (MatSpecularMapVisible) {
+        matSpecular *= texture(MatSpecularMap, mapTexcoord(FragTexcoord, MatSpecularMapFlipY, MatSpecularMapOffset, MatSpecularMapRepeat)).rgb;
+    }
+#endif
+    vec3 matEmissive = MatEmissiveColor;
+#ifdef HAS_EMISSIVEMAP
+    if (MatEmissiveMapVisible) {
+        matEmissive *= texture(MatEmissiveMap, mapTexcoord(FragTexcoord, MatEmissiveMapFlipY, MatEmissiveMapOffset, MatEmissiveMapRepeat)).rgb;
+    }
+#endif
+
+    // Perturbs the fragment normal by the normal and bump maps
+    vec3 fragNormal = normalize(Normal);
+#ifdef HAS_NORMALMAP
+    if (MatNormalMapVisible) {
+        vec3 mapNormal = texture(MatNormalMap, mapTexcoord(FragTexcoord, MatNormalMapFlipY, MatNormalMapOffset, MatNormalMapRepeat)).rgb;
+        fragNormal = tangentNormal(fragNormal, Tangent, mapNormal, MatNormalScale);
+    }
+#endif
+#ifdef HAS_BUMPMAP
+    if (MatBumpMapVisible) {
+        float height = texture(MatBumpMap, mapTexcoord(FragTexcoord, MatBumpMapFlipY, MatBumpMapOffset, MatBumpMapRepeat)).r;
+        fragNormal = bumpNormal(Position.xyz, fragNormal, height * MatBumpScale);
+    }
+#endif
 
     // Inverts the fragment normal if not FrontFacing
-    vec3 fragNormal = Normal;
     if (!gl_FrontFacing) {
         fragNormal = -fragNormal;
     }
 
+    // Color of the environment map reflected or refracted by the fragment
+    // which replaces part of the lit ambient and diffuse colors
+#ifdef HAS_ENVMAP
+    vec3 envColor = texture(MatEnvMap, envMapDirection(Position.xyz, fragNormal)).rgb;
+#endif
+
+    {{if .GBuffer}}
+    // Stores the surface in the G-buffer. Only the ambient lights
+    // are applied here and the other lights by the light passes.
+    vec3 ambient = matEmissive;
+    for (int i = 0; i < int(LightCounts.x); i++) {
+        ambient += AmbientLightColor[i].rgb * matAmbient.rgb;
+    }
+#ifdef HAS_ENVMAP
+    ambient = mix(ambient, envColor, MatReflectivity);
+    matDiffuse.rgb *= 1.0 - MatReflectivity;
+#endif
+    GOutAlbedo = vec4(matDiffuse.rgb, 0.0);
+    GOutNormal = vec4(normalize(fragNormal), MatShininess);
+    GOutParams = vec4(matSpecular, GBufferShadows);
+    GOutEmissive = vec4(ambient, 1.0);
+    {{else}}
     // Calculates the Ambient+Diffuse and Specular colors for this fragment using the Phong model.
     vec3 Ambdiff, Spec;
-    phongModel(Position, fragNormal, CamDir, vec3(matAmbient), vec3(matDiffuse), Ambdiff, Spec);
+    phongModel(Position, fragNormal, CamDir, vec3(matAmbient), vec3(matDiffuse), matSpecular, matEmissive, Ambdiff, Spec);
+#ifdef HAS_ENVMAP
+    Ambdiff = mix(Ambdiff, envColor, MatReflectivity);
+#endif
 
     // Final fragment color
     FragColor = min(vec4(Ambdiff + Spec, matDiffuse.a), vec4(1.0));
+    FragColor.rgb = applyFog(FragColor.rgb);
+    {{end}}
 }
 
 `
@@ -0,0 +1,191 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddShader("shaderPhysicalVertex", shaderPhysicalVertex)
+	AddShader("shaderPhysicalFrag", shaderPhysicalFrag)
+	AddProgram("shaderPhysical", "shaderPhysicalVertex", "shaderPhysicalFrag")
+}
+
+//
+// Vertex Shader template
+//
+const shaderPhysicalVertex = `
+#version {{.Version}}
+
+{{template "attributes" .}}
+
+// Model uniforms
+uniform mat4 ModelViewMatrix;
+uniform mat3 NormalMatrix;
+uniform mat4 MVP;
+
+// Output variables for Fragment shader
+out vec4 Position;
+out vec3 Normal;
+out vec2 FragTexcoord;
+{{if .Instanced}}
+out vec4 FragInstanceColor;
+{{end}}
+
+void main() {
+
+    {{template "instance_transform" .}}
+
+    // Transform vertex position and normal to camera coordinates
+    Position = ModelViewMatrix * vec4(vertexPosition, 1.0);
+    Normal = normalize(NormalMatrix * vertexNormal);
+
+    // Texture coordinates are transformed for each map in the fragment shader
+    FragTexcoord = VertexTexcoord;
+    {{if .Instanced}}
+    FragInstanceColor = InstanceColor;
+    {{end}}
+
+    gl_Position = MVP * vec4(vertexPosition, 1.0);
+}
+`
+
+//
+// Fragment Shader template
+//
+const shaderPhysicalFrag = `
+#version {{.Version}}
+
+// Inputs from vertex shader
+in vec4 Position;
+in vec3 Normal;
+in vec2 FragTexcoord;
+{{if .Instanced}}
+in vec4 FragInstanceColor;
+{{end}}
+
+{{template "lights" .}}
+{{template "physical_material" .}}
+{{template "physical_model" .}}
+{{template "fog" .}}
+{{template "lod_fade" .}}
+
+// View matrix used to transform directions to world coordinates
+{{template "camera" .}}
+
+{{if .GBuffer}}
+{{template "gbuffer_output" .}}
+{{else}}
+// Output
+out vec4 FragColor;
+{{end}}
+
+// mapTexcoord returns the texture coordinates of a map
+vec2 mapTexcoord(int flipY, vec2 offset, vec2 repeat) {
+
+    vec2 texcoord = FragTexcoord;
+    if (flipY > 0) {
+        texcoord.y = 1.0 - texcoord.y;
+    }
+    return texcoord * repeat + offset;
+}
+
+void main() {
+
+    lodFade();
+
+    // Base color and opacity
+    vec4 baseColor = MatBaseColor;
+#ifdef HAS_BASECOLORMAP
+    if (MatBaseColorMapVisible) {
+        baseColor *= texture(MatBaseColorMap, mapTexcoord(MatBaseColorMapFlipY, MatBaseColorMapOffset, MatBaseColorMapRepeat));
+    }
+#endif
+
+    {{if .Instanced}}
+    baseColor *= FragInstanceColor;
+    {{end}}
+
+    // Metallic and roughness factors
+    float metallic = MatMetallic;
+    float roughness = MatRoughness;
+#ifdef HAS_METALLICROUGHNESSMAP
+    if (MatMetallicRoughnessMapVisible) {
+        vec4 mr = texture(MatMetallicRoughnessMap, mapTexcoord(MatMetallicRoughnessMapFlipY, MatMetallicRoughnessMapOffset, MatMetallicRoughnessMapRepeat));
+        roughness *= mr.g;
+        metallic *= mr.b;
+    }
+#endif
+    metallic = clamp(metallic, 0.0, 1.0);
+    roughness = clamp(roughness, 0.04, 1.0);
+
+    // Ambient occlusion
+    float occlusion = 1.0;
+#ifdef HAS_OCCLUSIONMAP
+    if (MatOcclusionMapVisible) {
+        float ao = texture(MatOcclusionMap, mapTexcoord(MatOcclusionMapFlipY, MatOcclusionMapOffset, MatOcclusionMapRepeat)).r;
+        occlusion = mix(1.0, ao, MatOcclusionStrength);
+    }
+#endif
+
+    // Emissive color
+    vec3 emissive = MatEmissiveColor;
+#ifdef HAS_EMISSIVEMAP
+    if (MatEmissiveMapVisible) {
+        emissive *= texture(MatEmissiveMap, mapTexcoord(MatEmissiveMapFlipY, MatEmissiveMapOffset, MatEmissiveMapRepeat)).rgb;
+    }
+#endif
+
+    // The camera is at 0,0,0 and back faces use the inverted normal
+    vec3 N = normalize(Normal);
+    if (!gl_FrontFacing) {
+        N = -N;
+    }
+    vec3 V = normalize(-Position.xyz);
+
+    // Dielectrics reflect 4% at normal incidence and metals their base color
+    vec3 albedo = baseColor.rgb;
+    vec3 F0 = mix(vec3(0.04), albedo, metallic);
+
+    vec3 direct;
+    vec3 ambient;
+    {{if .GBuffer}}
+    // Only the ambient lights are applied here and the other lights by the light passes
+    direct = vec3(0.0);
+    ambient = vec3(0.0);
+    for (int i = 0; i < int(LightCounts.x); i++) {
+        ambient += AmbientLightColor[i].rgb * albedo;
+    }
+    {{else}}
+    physicalModel(Position, N, V, albedo, F0, metallic, roughness, direct, ambient);
+    {{end}}
+
+#ifdef HAS_ENVMAP
+    {
+        // Image based lighting from the environment cube map in world coordinates.
+        // The lowest mipmap level approximates the irradiance and the
+        // level corresponding to the roughness the prefiltered radiance.
+        mat3 viewToWorld = transpose(mat3(ViewMatrix));
+        vec3 worldNormal = viewToWorld * N;
+        vec3 worldReflect = viewToWorld * reflect(-V, N);
+        float maxLod = log2(float(textureSize(MatEnvMap, 0).x));
+        float NdotV = max(dot(N, V), 0.0001);
+
+        vec3 F = F0 + (max(vec3(1.0 - roughness), F0) - F0) * pow(1.0 - NdotV, 5.0);
+        vec3 kD = (vec3(1.0) - F) * (1.0 - metallic);
+        vec3 irradiance = textureLod(MatEnvMap, worldNormal, maxLod).rgb;
+        vec3 radiance = textureLod(MatEnvMap, worldReflect, roughness * maxLod).rgb;
+        vec2 brdf = envBRDF(NdotV, roughness);
+        ambient += (kD * irradiance * albedo + radiance * (F0 * brdf.x + brdf.y)) * MatEnvIntensity;
+    }
+#endif
+
+    {{if .GBuffer}}
+    GOutAlbedo = vec4(albedo, 1.0);
+    GOutNormal = vec4(N, 0.0);
+    GOutParams = vec4(metallic, roughness, 0.0, GBufferShadows);
+    GOutEmissive = vec4(ambient * occlusion + emissive, 1.0);
+    {{else}}
+    FragColor = vec4(applyFog(direct + ambient * occlusion + emissive), baseColor.a);
+    {{end}}
+}
+`
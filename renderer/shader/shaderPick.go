@@ -0,0 +1,107 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddShader("shaderPickVertex", shaderPickVertex)
+	AddShader("shaderPickFrag", shaderPickFrag)
+	AddProgram("shaderPick", "shaderPickVertex", "shaderPickFrag")
+	AddShader("shaderLinePickVertex", shaderLinePickVertex)
+	AddShader("shaderLinePickFrag", shaderLinePickFrag)
+	AddProgram("shaderLinePick", "shaderLinePickVertex", "shaderLinePickFrag")
+}
+
+// Vertex Shader template of the GPU picking pass
+const shaderPickVertex = `
+#version {{.Version}}
+
+{{template "attributes" .}}
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Size of the points drawn with the Point material
+uniform float PointSize;
+
+// Output variables for Fragment shader
+flat out int Instance;
+
+void main() {
+
+    {{template "instance_transform" .}}
+    Instance = gl_InstanceID;
+
+    vec4 pos = MVP * vec4(vertexPosition, 1.0);
+    gl_Position = pos;
+    gl_PointSize = (1.0 - pos.z / pos.w) * PointSize;
+}
+`
+
+// Fragment Shader template of the GPU picking pass.
+// Writes the identifier of the graphic material, the index of the
+// primitive and the index of the instance of the fragment.
+const shaderPickFrag = `
+#version {{.Version}}
+
+// Identifier of the graphic material being drawn
+uniform int PickObject;
+
+// Inputs from vertex shader
+flat in int Instance;
+
+out uvec4 FragID;
+
+void main() {
+
+    FragID = uvec4(uint(PickObject), uint(gl_PrimitiveID), uint(Instance), 0u);
+}
+`
+
+// Vertex Shader template of the GPU picking pass of the
+// lines drawn as screen-space quads with the Line material
+const shaderLinePickVertex = `
+#version {{.Version}}
+
+{{template "attributes" .}}
+
+// Model uniforms
+uniform mat4 MVP;
+
+// Index of the first segment of the material group being drawn
+uniform int LineFirst;
+
+{{template "line_vertex" .}}
+
+// Output variables for Fragment shader
+flat out int Segment;
+
+void main() {
+
+    Segment = int(LineNeighbor.w + 0.5) - LineFirst;
+    gl_Position = lineVertex();
+}
+`
+
+// Fragment Shader template of the GPU picking pass of the lines drawn as
+// screen-space quads. The primitive index is the index of the segment.
+const shaderLinePickFrag = `
+#version {{.Version}}
+
+// Identifier of the graphic material being drawn
+uniform int PickObject;
+
+{{template "line_fragment" .}}
+
+// Inputs from vertex shader
+flat in int Segment;
+
+out uvec4 FragID;
+
+void main() {
+
+    lineDiscard();
+    FragID = uvec4(uint(PickObject), uint(Segment), 0u, 0u);
+}
+`
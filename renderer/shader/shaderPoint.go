@@ -58,6 +58,7 @@ const shaderPointFrag = `
 #version {{.Version}}
 
 {{template "material" .}}
+{{template "fog" .}}
 
 // Inputs from vertex shader
 in vec3 Color;
@@ -84,6 +85,7 @@ void main() {
 
     // Combine material color with texture
     FragColor = min(vec4(Color, MatOpacity) * texCombined, vec4(1));
+    FragColor.rgb = applyFog(FragColor.rgb);
 }
 
 `
@@ -0,0 +1,52 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shader
+
+func init() {
+	AddShader("shaderSkyboxVertex", shaderSkyboxVertex)
+	AddShader("shaderSkyboxFrag", shaderSkyboxFrag)
+	AddProgram("shaderSkybox", "shaderSkyboxVertex", "shaderSkyboxFrag")
+}
+
+// Vertex Shader template of the skybox.
+// Draws the vertices of the box around the camera at the far plane.
+const shaderSkyboxVertex = `
+#version {{.Version}}
+
+{{template "attributes" .}}
+
+// Model view projection matrix without the camera translation
+uniform mat4 MVP;
+
+// Output variables for Fragment shader
+out vec3 Direction;
+
+void main() {
+
+    Direction = VertexPosition;
+    vec4 pos = MVP * vec4(VertexPosition, 1.0);
+    // The depth of the vertices is the far plane, which is
+    // not clipped when Z is equal to W
+    gl_Position = pos.xyww;
+}
+`
+
+// Fragment Shader template of the skybox.
+// The fog is not applied as the skybox is infinitely distant.
+const shaderSkyboxFrag = `
+#version {{.Version}}
+
+// Inputs from vertex shader
+in vec3 Direction;
+
+uniform samplerCube SkyboxCube;
+
+out vec4 FragColor;
+
+void main() {
+
+    FragColor = vec4(texture(SkyboxCube, Direction).rgb, 1.0);
+}
+`
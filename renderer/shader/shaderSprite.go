@@ -4,6 +4,12 @@
 
 package shader
 
+func init() {
+	AddShader("shaderSpriteVertex", shaderSpriteVertex)
+	AddShader("shaderSpriteFrag", shaderSpriteFrag)
+	AddProgram("shaderSprite", "shaderSpriteVertex", "shaderSpriteFrag")
+}
+
 const shaderSpriteVertex = `
 #version {{.Version}}
 
@@ -44,6 +50,7 @@ const shaderSpriteFrag = `
 #version {{.Version}}
 
 {{template "material" .}}
+{{template "fog" .}}
 
 // Inputs from vertex shader
 in vec3 Color;
@@ -69,6 +76,7 @@ void main() {
 
     // Combine material color with texture
     FragColor = min(vec4(Color, MatOpacity) * texCombined, vec4(1));
+    FragColor.rgb = applyFog(FragColor.rgb);
 }
 
 `
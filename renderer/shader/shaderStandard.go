@@ -23,8 +23,10 @@ uniform mat4 ModelViewMatrix;
 uniform mat3 NormalMatrix;
 uniform mat4 MVP;
 
+{{template "camera" .}}
 {{template "lights" .}}
 {{template "material" .}}
+{{template "material_envmap" .}}
 {{template "phong_model" .}}
 
 
@@ -34,14 +36,27 @@ out vec3 ColorFrontSpec;
 out vec3 ColorBackAmbdiff;
 out vec3 ColorBackSpec;
 out vec2 FragTexcoord;
+#ifdef HAS_ENVMAP
+out vec3 EnvDir;
+#endif
 
 void main() {
 
+    {{template "instance_transform" .}}
+
     // Transform this vertex normal to camera coordinates.
-    vec3 normal = normalize(NormalMatrix * VertexNormal);
+    vec3 normal = normalize(NormalMatrix * vertexNormal);
 
     // Calculate this vertex position in camera coordinates
-    vec4 position = ModelViewMatrix * vec4(VertexPosition, 1.0);
+    vec4 position = ModelViewMatrix * vec4(vertexPosition, 1.0);
+
+    // Multiplies the material colors by the instance color
+    vec3 matAmbient = MatAmbientColor;
+    vec3 matDiffuse = MatDiffuseColor;
+    {{if .Instanced}}
+    matAmbient *= InstanceColor.rgb;
+    matDiffuse *= InstanceColor.rgb;
+    {{end}}
 
     // Calculate the direction vector from the vertex to the camera
     // The camera is at 0,0,0
@@ -49,19 +64,18 @@ void main() {
 
     // Calculates the vertex Ambient+Diffuse and Specular colors using the Phong model
     // for the front and back
-    phongModel(position,  normal, camDir, MatAmbientColor, MatDiffuseColor, ColorFrontAmbdiff, ColorFrontSpec);
-    phongModel(position, -normal, camDir, MatAmbientColor, MatDiffuseColor, ColorBackAmbdiff, ColorBackSpec);
+    phongModel(position,  normal, camDir, matAmbient, matDiffuse, MatSpecularColor, MatEmissiveColor, ColorFrontAmbdiff, ColorFrontSpec);
+    phongModel(position, -normal, camDir, matAmbient, matDiffuse, MatSpecularColor, MatEmissiveColor, ColorBackAmbdiff, ColorBackSpec);
 
-    vec2 texcoord = VertexTexcoord;
-    {{if .MatTexturesMax }}
-    // Flips texture coordinate Y if requested.
-    if (MatTexFlipY[0] > 0) {
-        texcoord.y = 1 - texcoord.y;
-    }
-    {{ end }}
-    FragTexcoord = texcoord;
+    // Texture coordinates are flipped for each texture in the fragment shader
+    FragTexcoord = VertexTexcoord;
+
+    // Direction of the environment map reflected or refracted by the vertex
+#ifdef HAS_ENVMAP
+    EnvDir = envMapDirection(position.xyz, normal);
+#endif
 
-    gl_Position = MVP * vec4(VertexPosition, 1.0);
+    gl_Position = MVP * vec4(vertexPosition, 1.0);
 }
 `
 
@@ -71,14 +85,22 @@ void main() {
 const shaderStandardFrag = `
 #version {{.Version}}
 
-{{template "material" .}}
-
 // Inputs from Vertex shader
 in vec3 ColorFrontAmbdiff;
 in vec3 ColorFrontSpec;
 in vec3 ColorBackAmbdiff;
 in vec3 ColorBackSpec;
 in vec2 FragTexcoord;
+#ifdef HAS_ENVMAP
+in vec3 EnvDir;
+#endif
+
+{{template "camera" .}}
+{{template "material" .}}
+{{template "material_maps" .}}
+{{template "material_envmap" .}}
+{{template "fog" .}}
+{{template "lod_fade" .}}
 
 // Output
 out vec4 FragColor;
@@ -86,6 +108,16 @@ out vec4 FragColor;
 
 void main() {
 
+    lodFade();
+
+    // Flips texture coordinate Y if requested.
+    vec2 texcoord = FragTexcoord;
+    {{if .MatTexturesMax }}
+    if (MatTexFlipY[0] > 0) {
+        texcoord.y = 1 - texcoord.y;
+    }
+    {{ end }}
+
     vec4 texCombined = vec4(1);
 
     // Combine all texture colors and opacity
@@ -93,7 +125,7 @@ void main() {
     // array indexes are not allowed until GLSL 4.00.
     {{ range loop .MatTexturesMax }}
     if (MatTexVisible[{{.}}] == true) {
-        vec4 texcolor = texture(MatTexture[{{.}}], FragTexcoord * MatTexRepeat[{{.}}] + MatTexOffset[{{.}}]);
+        vec4 texcolor = texture(MatTexture[{{.}}], texcoord * MatTexRepeat[{{.}}] + MatTexOffset[{{.}}]);
         if ({{.}} == 0) {
             texCombined = texcolor;
         } else {
@@ -101,6 +133,16 @@ void main() {
         }
     }
     {{ end }}
+#ifdef HAS_DIFFUSEMAP
+    if (MatDiffuseMapVisible) {
+        texCombined *= texture(MatDiffuseMap, mapTexcoord(FragTexcoord, MatDiffuseMapFlipY, MatDiffuseMapOffset, MatDiffuseMapRepeat));
+    }
+#endif
+#ifdef HAS_ALPHAMAP
+    if (MatAlphaMapVisible) {
+        texCombined.a *= texture(MatAlphaMap, mapTexcoord(FragTexcoord, MatAlphaMapFlipY, MatAlphaMapOffset, MatAlphaMapRepeat)).r;
+    }
+#endif
 
     vec4 colorAmbDiff;
     vec4 colorSpec;
@@ -111,7 +153,14 @@ void main() {
         colorAmbDiff = vec4(ColorBackAmbdiff, MatOpacity);
         colorSpec = vec4(ColorBackSpec, 0);
     }
-    FragColor = min(colorAmbDiff * texCombined + colorSpec, vec4(1));
+    vec4 color = colorAmbDiff * texCombined;
+
+    // Replaces part of the lit color by the color of the environment map
+#ifdef HAS_ENVMAP
+    color.rgb = mix(color.rgb, texture(MatEnvMap, EnvDir).rgb, MatReflectivity);
+#endif
+    FragColor = min(color + colorSpec, vec4(1));
+    FragColor.rgb = applyFog(FragColor.rgb);
 }
 
 `
@@ -43,8 +43,8 @@ void main() {
 
     // Calculates the vertex Ambient+Diffuse and Specular colors using the Phong model
     // for the front and back
-    phongModel(position,  normal, camDir, MatAmbientColor, MatDiffuseColor, ColorFrontAmbdiff, ColorFrontSpec);
-    phongModel(position, -normal, camDir, MatAmbientColor, MatDiffuseColor, ColorBackAmbdiff, ColorBackSpec);
+    phongModel(position,  normal, camDir, MatAmbientColor, MatDiffuseColor, MatSpecularColor, MatEmissiveColor, ColorFrontAmbdiff, ColorFrontSpec);
+    phongModel(position, -normal, camDir, MatAmbientColor, MatDiffuseColor, MatSpecularColor, MatEmissiveColor, ColorBackAmbdiff, ColorBackSpec);
 
     // Flips texture coordinate Y if requested.
     vec2 texcoord = VertexTexcoord;
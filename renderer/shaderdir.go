@@ -0,0 +1,345 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Extension of the chunks and shaders template files
+const shaderFileExt = ".glsl"
+
+// shaderDir is a directory with chunks and shaders template files
+// which are loaded and reloaded when changed by the shader manager.
+type shaderDir struct {
+	path     string                 // Directory path
+	chunks   map[string]*shaderFile // Maps chunk name to its file
+	shaders  map[string]*shaderFile // Maps shader name to its file
+	interval time.Duration          // Interval between automatic checks (0 = disabled)
+	checked  time.Time              // Time of the last automatic check
+}
+
+// shaderFile is a template file of a shader directory
+type shaderFile struct {
+	path    string    // File path
+	modTime time.Time // Modification time of the loaded source
+	size    int64     // Size of the loaded source
+	source  string    // Template source
+}
+
+// Regular expressions to find the template name and line in template errors
+// and the source line in the GLSL compiler logs of the different drivers,
+// as in "0:12(3): error", "ERROR: 0:12: error" or "0(12) : error".
+var reTemplateError = regexp.MustCompile(`template: ([^:]+):(\d+)`)
+var reCompileError = regexp.MustCompile(`\b\d+[:(](\d+)\b`)
+
+// Lines of the templates loaded from files are followed by markers with
+// their file path and line number, which are removed before compiling.
+const lineMarker = "\x1f"
+
+var reLineMarker = regexp.MustCompile(lineMarker + `([^` + lineMarker + `]*):(\d+)` + lineMarker)
+
+// LoadShaderDir loads the chunks and shaders templates from the files
+// with the ".glsl" extension in the "chunks" and "shaders" subdirectories
+// of the specified directory. The name of each chunk or shader is its file
+// name without extension, so a file may replace a default shader or chunk.
+// Programs are still registered by AddProgram or AddDefaultShaders.
+// Errors in the templates and in the compiled programs refer to the files lines.
+func (sm *Shaman) LoadShaderDir(path string) error {
+
+	dir := new(shaderDir)
+	dir.path = path
+	dir.chunks = make(map[string]*shaderFile)
+	dir.shaders = make(map[string]*shaderFile)
+	_, err := dir.scan()
+	if err != nil {
+		return err
+	}
+	sm.dir = dir
+	return sm.applyShaderDir()
+}
+
+// WatchShaderDir sets the interval between the checks for changed files
+// in the shader directory loaded by LoadShaderDir, which are done by the
+// renderer before rendering a scene. Errors are logged and the last good
+// templates and programs are kept. Zero (default) disables the checks.
+func (sm *Shaman) WatchShaderDir(interval time.Duration) {
+
+	if sm.dir != nil {
+		sm.dir.interval = interval
+	}
+}
+
+// ReloadShaderDir checks the shader directory loaded by LoadShaderDir for new or
+// changed files. If any is found the templates are parsed again and the compiled
+// programs whose sources changed are rebuilt. If a template has an error the
+// previous templates are kept and if a program fails to build the previous
+// program is kept. Returns an indication if any file changed and an error.
+func (sm *Shaman) ReloadShaderDir() (bool, error) {
+
+	if sm.dir == nil {
+		return false, nil
+	}
+	changed, err := sm.dir.scan()
+	if err != nil || !changed {
+		return false, err
+	}
+	return true, sm.applyShaderDir()
+}
+
+// pollShaderDir reloads the shader directory if watched and the check
+// interval has elapsed, logging the changes and errors.
+func (sm *Shaman) pollShaderDir() {
+
+	if sm.dir == nil || sm.dir.interval == 0 {
+		return
+	}
+	now := time.Now()
+	if now.Sub(sm.dir.checked) < sm.dir.interval {
+		return
+	}
+	sm.dir.checked = now
+	changed, err := sm.ReloadShaderDir()
+	if err != nil {
+		log.Error("Reloading shaders: %v", err)
+		return
+	}
+	if changed {
+		log.Info("Reloaded shaders from:%s", sm.dir.path)
+	}
+}
+
+// applyShaderDir parses again all the templates with the sources
+// from the shader directory and rebuilds the changed programs
+func (sm *Shaman) applyShaderDir() error {
+
+	// Sources of the shader directory replace the current ones
+	csources := make(map[string]string)
+	for name, source := range sm.csources {
+		csources[name] = source
+	}
+	for name, file := range sm.dir.chunks {
+		csources[name] = file.source
+	}
+	ssources := make(map[string]string)
+	for name, source := range sm.ssources {
+		ssources[name] = source
+	}
+	for name, file := range sm.dir.shaders {
+		ssources[name] = file.source
+	}
+
+	// Parses the templates keeping the current ones if any fails
+	chunks := newChunksTemplate()
+	for name, source := range csources {
+		if file := sm.dir.chunks[name]; file != nil {
+			source = markLines(file.path, source)
+		}
+		_, err := chunks.New(name).Parse(source)
+		if err != nil {
+			return sm.templateError(err)
+		}
+	}
+	shaders := make(map[string]*template.Template)
+	for name, source := range ssources {
+		tmpl, err := chunks.Clone()
+		if err != nil {
+			return err
+		}
+		if file := sm.dir.shaders[name]; file != nil {
+			source = markLines(file.path, source)
+		}
+		tmpl, err = tmpl.New(name).Parse(source)
+		if err != nil {
+			return sm.templateError(err)
+		}
+		shaders[name] = tmpl
+	}
+	sm.chunks = chunks
+	sm.shaders = shaders
+	sm.csources = csources
+	sm.ssources = ssources
+
+	// Rebuilds the programs whose generated sources changed
+	errs := make([]string, 0)
+	for _, ps := range sm.programs {
+		vertex, frag, err := sm.genSources(&ps.specs)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if vertex == ps.vertex && frag == ps.frag {
+			continue
+		}
+		prog, err := sm.buildProgram(vertex, frag)
+		if err != nil {
+			errs = append(errs, sm.sourceError(ps.specs.Name, vertex, frag, err).Error())
+			continue
+		}
+		if sm.gs.Prog == ps.program {
+			sm.gs.UseProgram(prog)
+		}
+		ps.program.Dispose()
+		ps.program = prog
+		ps.vertex = vertex
+		ps.frag = frag
+		sm.stats.Compiles++
+		log.Debug("Rebuilt shader:%v", ps.specs.Name)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// templateError returns the specified template error with the
+// template name replaced by its file path if loaded from a file
+func (sm *Shaman) templateError(err error) error {
+
+	if sm.dir == nil {
+		return err
+	}
+	msg := err.Error()
+	match := reTemplateError.FindStringSubmatchIndex(msg)
+	if match == nil {
+		return err
+	}
+	name := msg[match[2]:match[3]]
+	file := sm.dir.chunks[name]
+	if file == nil {
+		file = sm.dir.shaders[name]
+	}
+	if file == nil {
+		return err
+	}
+	return fmt.Errorf("%s%s:%s", msg[:match[0]], file.path, msg[match[4]:])
+}
+
+// sourceError returns the specified error building the specified program
+// with the lines of the compiler log prefixed by the file path and line
+// of the template which originated the line of the generated source.
+func (sm *Shaman) sourceError(progName, vertex, frag string, err error) error {
+
+	if sm.dir == nil {
+		return err
+	}
+	msg := err.Error()
+	source := frag
+	if strings.Contains(msg, "Vertex Shader") {
+		source = vertex
+	}
+	lines := strings.Split(source, "\n")
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Program:%s", progName)
+	for _, logLine := range strings.Split(msg, "\n") {
+		logLine = strings.TrimRight(logLine, "\x00 \r")
+		if logLine == "" {
+			continue
+		}
+		buf.WriteString("\n")
+		match := reCompileError.FindStringSubmatch(logLine)
+		if match != nil {
+			n, _ := strconv.Atoi(match[1])
+			if n > 0 && n <= len(lines) {
+				path, fline := locate(lines[n-1])
+				if path != "" {
+					fmt.Fprintf(&buf, "%s:%d: ", path, fline)
+				}
+			}
+		}
+		buf.WriteString(logLine)
+	}
+	return fmt.Errorf("%s", buf.String())
+}
+
+// scan reads the new and changed template files of this directory.
+// Returns an indication if any file was read and an error.
+func (dir *shaderDir) scan() (bool, error) {
+
+	changed1, err := dir.scanFiles(filepath.Join(dir.path, "chunks"), dir.chunks)
+	if err != nil {
+		return false, err
+	}
+	changed2, err := dir.scanFiles(filepath.Join(dir.path, "shaders"), dir.shaders)
+	if err != nil {
+		return false, err
+	}
+	return changed1 || changed2, nil
+}
+
+// scanFiles reads the new and changed template files
+// of the specified subdirectory into the specified map
+func (dir *shaderDir) scanFiles(path string, files map[string]*shaderFile) (bool, error) {
+
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+	changed := false
+	for _, info := range infos {
+		if info.IsDir() || filepath.Ext(info.Name()) != shaderFileExt {
+			continue
+		}
+		name := strings.TrimSuffix(info.Name(), shaderFileExt)
+		file := files[name]
+		if file != nil && file.modTime.Equal(info.ModTime()) && file.size == info.Size() {
+			continue
+		}
+		fpath := filepath.Join(path, info.Name())
+		data, err := ioutil.ReadFile(fpath)
+		if err != nil {
+			return false, err
+		}
+		files[name] = &shaderFile{path: fpath, modTime: info.ModTime(), size: info.Size(), source: string(data)}
+		changed = true
+	}
+	return changed, nil
+}
+
+// markLines returns the specified template source of the file with the
+// specified path with each line followed by a marker with the path and the
+// line number, which the generated sources keep to locate compiler errors.
+// Lines ending inside a template action are not marked.
+func markLines(path, source string) string {
+
+	lines := strings.Split(source, "\n")
+	depth := 0
+	for i, line := range lines {
+		depth += strings.Count(line, "{{") - strings.Count(line, "}}")
+		if depth > 0 {
+			continue
+		}
+		lines[i] = fmt.Sprintf("%s%s%s:%d%s", line, lineMarker, path, i+1, lineMarker)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stripMarkers returns the specified generated source without the line markers
+func stripMarkers(source string) string {
+
+	return reLineMarker.ReplaceAllString(source, "")
+}
+
+// locate returns the path and line number of the template file line which
+// originated the specified generated source line from its first marker.
+// Returns an empty path if the line has no marker.
+func locate(line string) (string, int) {
+
+	match := reLineMarker.FindStringSubmatch(line)
+	if match == nil {
+		return "", 0
+	}
+	n, _ := strconv.Atoi(match[2])
+	return match[1], n
+}
@@ -0,0 +1,273 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/light"
+	"github.com/g3n/engine/math32"
+)
+
+// shadowMap contains the OpenGL objects and uniforms used to
+// render and sample the shadow map of a light.
+type shadowMap struct {
+	fbo     uint32              // Framebuffer object handle
+	tex     uint32              // Depth texture handle
+	width   int                 // Current depth texture width
+	height  int                 // Current depth texture height
+	used    uint64              // Number of the last render which used this shadow map
+	rinfo   core.RenderInfo     // Render info with the shadow camera matrices
+	uMap    gls.Uniform1i       // Shadow map texture unit uniform
+	uMatrix gls.UniformMatrix4f // Camera to shadow map coordinates matrix uniform
+	uBias   gls.Uniform1f       // Depth bias uniform
+	uFilter gls.Uniform1i       // Filter radius uniform
+}
+
+// shadowMapIdleRenders is the number of consecutive renders after which the
+// shadow map of a light which was not used is disposed. Shadow maps are not
+// disposed as soon as they are not used, so several scenes rendered by the
+// same renderer don't dispose and recreate each other's shadow maps.
+const shadowMapIdleRenders = 300
+
+// shadowBias maps the shadow camera clip coordinates
+// from [-1,1] to the shadow map coordinates [0,1]
+var shadowBias = math32.Matrix4{
+	0.5, 0, 0, 0,
+	0, 0.5, 0, 0,
+	0, 0, 0.5, 0,
+	0.5, 0.5, 0.5, 1,
+}
+
+// newShadowMap creates and returns a shadow map whose uniforms
+// names start with the specified prefix ("Dir" or "Spot").
+func newShadowMap(prefix string) *shadowMap {
+
+	sm := new(shadowMap)
+	sm.uMap.Init(prefix + "ShadowMap")
+	sm.uMatrix.Init(prefix + "ShadowMatrix")
+	sm.uBias.Init(prefix + "ShadowBias")
+	sm.uFilter.Init(prefix + "ShadowFilter")
+	return sm
+}
+
+// setSize creates or recreates the depth texture and the framebuffer
+// of this shadow map if its size is different from the specified size.
+func (sm *shadowMap) setSize(gs *gls.GLS, width, height int) error {
+
+	if sm.fbo != 0 && sm.width == width && sm.height == height {
+		return nil
+	}
+	sm.dispose(gs)
+
+	// Creates depth texture which compares its values in the shader
+	sm.tex = gs.GenTexture()
+	gs.ActiveTexture(gls.TEXTURE0)
+	gs.BindTexture(gls.TEXTURE_2D, sm.tex)
+	gs.TexImage2D(gls.TEXTURE_2D, 0, gls.DEPTH_COMPONENT24, int32(width), int32(height), 0, gls.DEPTH_COMPONENT, gls.FLOAT, nil)
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_MAG_FILTER, gls.LINEAR)
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_MIN_FILTER, gls.LINEAR)
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_WRAP_S, gls.CLAMP_TO_EDGE)
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_WRAP_T, gls.CLAMP_TO_EDGE)
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_COMPARE_MODE, gls.COMPARE_REF_TO_TEXTURE)
+	gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_COMPARE_FUNC, gls.LEQUAL)
+
+	// Creates framebuffer with only the depth texture attached
+	sm.fbo = gs.GenFramebuffer()
+	gs.BindFramebuffer(gls.FRAMEBUFFER, sm.fbo)
+	gs.FramebufferTexture2D(gls.FRAMEBUFFER, gls.DEPTH_ATTACHMENT, gls.TEXTURE_2D, sm.tex, 0)
+	gs.DrawBuffer(gls.NONE)
+	gs.ReadBuffer(gls.NONE)
+	status := gs.CheckFramebufferStatus(gls.FRAMEBUFFER)
+	gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+	if status != gls.FRAMEBUFFER_COMPLETE {
+		sm.dispose(gs)
+		return fmt.Errorf("Shadow map framebuffer incomplete: 0x%X", status)
+	}
+	sm.width = width
+	sm.height = height
+	return nil
+}
+
+// dispose deletes the OpenGL objects of this shadow map
+func (sm *shadowMap) dispose(gs *gls.GLS) {
+
+	if sm.fbo != 0 {
+		gs.DeleteFramebuffers(sm.fbo)
+		sm.fbo = 0
+	}
+	if sm.tex != 0 {
+		gs.DeleteTextures(sm.tex)
+		sm.tex = 0
+	}
+}
+
+// sortShadowLights moves the directional and spot lights which cast
+// shadows to the beginning of their arrays, as expected by the shaders,
+// and returns the number of lights of each type which cast shadows.
+func (r *Renderer) sortShadowLights() (int, int) {
+
+	ndir := 0
+	for i, l := range r.dirLights {
+		if l.Shadow().CastShadow() {
+			r.dirLights[i], r.dirLights[ndir] = r.dirLights[ndir], r.dirLights[i]
+			ndir++
+		}
+	}
+	nspot := 0
+	for i, l := range r.spotLights {
+		if l.Shadow().CastShadow() {
+			r.spotLights[i], r.spotLights[nspot] = r.spotLights[nspot], r.spotLights[i]
+			nspot++
+		}
+	}
+	return ndir, nspot
+}
+
+// renderShadows renders the shadow maps of the first ndir directional
+// lights and the first nspot spot lights with all the shadow casters.
+func (r *Renderer) renderShadows(ndir, nspot int) error {
+
+	r.dirShadows = r.dirShadows[0:0]
+	r.spotShadows = r.spotShadows[0:0]
+
+	if ndir+nspot > 0 {
+		// Saves the current framebuffer, viewport and scissor test
+		// state to restore them after the shadows pass
+		fbo := r.gs.Framebuffer()
+		vx, vy, vwidth, vheight := r.gs.GetViewport()
+		scissor := r.gs.IsEnabled(gls.SCISSOR_TEST)
+		r.gs.Disable(gls.SCISSOR_TEST)
+
+		// Sets the depth only program states
+		r.gs.Enable(gls.DEPTH_TEST)
+		r.gs.DepthMask(true)
+		r.gs.DepthFunc(gls.LEQUAL)
+		r.gs.Disable(gls.CULL_FACE)
+		r.gs.PolygonMode(gls.FRONT_AND_BACK, gls.FILL)
+
+		for i := 0; i < ndir; i++ {
+			sm, err := r.renderShadowMap(r.dirLights[i], "Dir")
+			if err != nil {
+				return err
+			}
+			r.dirShadows = append(r.dirShadows, sm)
+		}
+		for i := 0; i < nspot; i++ {
+			sm, err := r.renderShadowMap(r.spotLights[i], "Spot")
+			if err != nil {
+				return err
+			}
+			r.spotShadows = append(r.spotShadows, sm)
+		}
+		r.gs.BindFramebuffer(gls.FRAMEBUFFER, fbo)
+		r.gs.Viewport(vx, vy, vwidth, vheight)
+		if scissor {
+			r.gs.Enable(gls.SCISSOR_TEST)
+		}
+	}
+
+	// Disposes the shadow maps of lights which no longer cast shadows
+	// or were not rendered for a while
+	for shadow, sm := range r.shadowMaps {
+		if r.renders-sm.used > shadowMapIdleRenders {
+			sm.dispose(r.gs)
+			delete(r.shadowMaps, shadow)
+		}
+	}
+	return nil
+}
+
+// DisposeShadowMap releases the OpenGL resources of the shadow map of the
+// specified light shadow, as when its light is removed or disposed.
+// Shadow maps which are not used are otherwise disposed after several renders.
+func (r *Renderer) DisposeShadowMap(shadow *light.Shadow) {
+
+	sm, ok := r.shadowMaps[shadow]
+	if !ok {
+		return
+	}
+	sm.dispose(r.gs)
+	delete(r.shadowMaps, shadow)
+}
+
+// renderShadowMap renders the shadow casters into the shadow map of the
+// specified light and returns it, creating it if necessary.
+func (r *Renderer) renderShadowMap(l light.IShadowCaster, prefix string) (*shadowMap, error) {
+
+	shadow := l.Shadow()
+	sm, ok := r.shadowMaps[shadow]
+	if !ok {
+		sm = newShadowMap(prefix)
+		r.shadowMaps[shadow] = sm
+	}
+	sm.used = r.renders
+	r.stats.ShadowMaps++
+	width, height := shadow.MapSize()
+	err := sm.setSize(r.gs, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	// Renders the shadow casters depth from the light point of view
+	l.ShadowMatrices(&sm.rinfo.ViewMatrix, &sm.rinfo.ProjMatrix)
+	r.gs.BindFramebuffer(gls.FRAMEBUFFER, sm.fbo)
+	r.gs.Viewport(0, 0, int32(width), int32(height))
+	r.gs.Clear(gls.DEPTH_BUFFER_BIT)
+	var specs ShaderSpecs
+	specs.Name = "shaderDepth"
+	for _, grmat := range r.casters {
+		// Sets the depth only program for the caster
+		setGraphicSpecs(&specs, grmat.IGraphic().GetGraphic())
+		_, err := r.shaman.SetProgram(&specs)
+		if err != nil {
+			return nil, err
+		}
+		grmat.RenderDepth(r.gs, &sm.rinfo)
+	}
+
+	// Calculates the matrix which transforms the camera coordinates
+	// of the scene being rendered to this shadow map coordinates.
+	var invView, matrix math32.Matrix4
+	invView.GetInverse(&r.rinfo.ViewMatrix, false)
+	matrix.MultiplyMatrices(&shadowBias, &sm.rinfo.ProjMatrix)
+	matrix.Multiply(&sm.rinfo.ViewMatrix)
+	matrix.Multiply(&invView)
+	sm.uMatrix.SetMatrix4(&matrix)
+	sm.uBias.Set(shadow.Bias())
+	sm.uFilter.Set(int32(shadow.Filter()))
+	return sm, nil
+}
+
+// setupShadows binds the shadow maps to the texture units following the
+// ones used by the material textures and transfer the shadows uniforms.
+// Returns the first texture unit following the shadow maps.
+func (r *Renderer) setupShadows(unit int) int {
+
+	for idx, sm := range r.dirShadows {
+		sm.setup(r.gs, unit, idx)
+		unit++
+	}
+	for idx, sm := range r.spotShadows {
+		sm.setup(r.gs, unit, idx)
+		unit++
+	}
+	return unit
+}
+
+// setup binds this shadow map to the specified texture unit and
+// transfer its uniforms for the specified light index.
+func (sm *shadowMap) setup(gs *gls.GLS, unit, idx int) {
+
+	gs.ActiveTexture(uint32(gls.TEXTURE0 + unit))
+	gs.BindTexture(gls.TEXTURE_2D, sm.tex)
+	sm.uMap.Set(int32(unit))
+	sm.uMap.TransferIdx(gs, idx)
+	sm.uMatrix.TransferIdx(gs, idx)
+	sm.uBias.TransferIdx(gs, idx)
+	sm.uFilter.TransferIdx(gs, idx)
+}
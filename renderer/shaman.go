@@ -7,6 +7,8 @@ package renderer
 import (
 	"bytes"
 	"fmt"
+	"sort"
+	"strings"
 	"text/template"
 
 	"github.com/g3n/engine/gls"
@@ -14,20 +16,45 @@ import (
 	"github.com/g3n/engine/renderer/shader"
 )
 
+// Binding points of the uniform blocks shared by all the programs
+const (
+	CameraBlockBinding = 0 // Camera uniform block with the view and projection matrices
+	LightsBlockBinding = 1 // Lights uniform block with the data of the scene lights
+	FogBlockBinding    = 2 // Fog uniform block with the fog of the scene environment
+)
+
+// ShaderSpecs contains the parameters used to generate a program from
+// its shaders templates. The data of the lights is in the Lights uniform
+// block, so the number of lights does not change the program.
 type ShaderSpecs struct {
-	Name             string // Shader name
-	Version          string // GLSL version
-	UseLights        material.UseLights
-	AmbientLightsMax int // Current number of ambient lights
-	DirLightsMax     int // Current Number of directional lights
-	PointLightsMax   int // Current Number of point lights
-	SpotLightsMax    int // Current Number of spot lights
-	MatTexturesMax   int // Current Number of material textures
+	Name            string // Shader name
+	Version         string // GLSL version
+	UseLights       material.UseLights
+	MatTexturesMax  int               // Current Number of material textures
+	DirShadowsMax   int               // Current Number of directional lights which cast shadows
+	SpotShadowsMax  int               // Current Number of spot lights which cast shadows
+	Instanced       bool              // Graphic is drawn as instances
+	BonesMax        int               // Size of the bone matrices array of skinned graphics
+	MorphTargetsMax int               // Size of the morph weights array of morphed meshes
+	GBuffer         bool              // Material is rendered into the deferred G-buffer
+	Defines         gls.ShaderDefines // Preprocessor symbols defined in the shaders
+	Extras          gls.ShaderDefines // Values available to the templates as {{.Extras.NAME}}
 }
 
 type ProgSpecs struct {
 	program *gls.Program // program object
 	specs   ShaderSpecs  // associated specs
+	key     string       // key of the associated specs
+	vertex  string       // vertex shader source
+	frag    string       // fragment shader source
+	used    uint64       // value of the activations counter when last activated
+}
+
+// ShamanStats contains the counters of the shader manager activity
+type ShamanStats struct {
+	Switches int // Number of program switches
+	Compiles int // Number of programs generated
+	Evicted  int // Number of programs disposed to keep the maximum number of programs
 }
 
 type Shaman struct {
@@ -35,8 +62,15 @@ type Shaman struct {
 	chunks   *template.Template            // template with all chunks
 	shaders  map[string]*template.Template // maps shader name to its template
 	proginfo map[string]shader.ProgramInfo // maps name of the program to ProgramInfo
-	programs []ProgSpecs                   // list of compiled programs with specs
+	programs map[string]*ProgSpecs         // maps specs key to compiled program with specs
+	maxProgs int                           // Maximum number of compiled programs (0 = unlimited)
+	useCount uint64                        // Counter of programs activations
 	specs    ShaderSpecs                   // Current shader specs
+	key      string                        // Key of the current shader specs
+	stats    ShamanStats                   // Activity counters since the last reset
+	csources map[string]string             // maps chunk name to its template source
+	ssources map[string]string             // maps shader name to its template source
+	dir      *shaderDir                    // Directory with shaders files or nil
 }
 
 // NewShaman creates and returns a pointer to a new shader manager
@@ -51,13 +85,22 @@ func NewShaman(gs *gls.GLS) *Shaman {
 func (sm *Shaman) Init(gs *gls.GLS) {
 
 	sm.gs = gs
-	sm.chunks = template.New("_chunks_")
+	sm.chunks = newChunksTemplate()
 	sm.shaders = make(map[string]*template.Template)
 	sm.proginfo = make(map[string]shader.ProgramInfo)
+	sm.programs = make(map[string]*ProgSpecs)
+	sm.csources = make(map[string]string)
+	sm.ssources = make(map[string]string)
+}
+
+// newChunksTemplate creates and returns the template
+// which contains the chunks used by the shaders
+func newChunksTemplate() *template.Template {
 
+	chunks := template.New("_chunks_")
 	// Add "loop" function to chunks template
 	// "loop" is used inside the shader templates to unroll loops.
-	sm.chunks.Funcs(template.FuncMap{
+	chunks.Funcs(template.FuncMap{
 		"loop": func(n int) []int {
 			s := make([]int, n)
 			for i := range s {
@@ -66,6 +109,7 @@ func (sm *Shaman) Init(gs *gls.GLS) {
 			return s
 		},
 	})
+	return chunks
 }
 
 func (sm *Shaman) AddDefaultShaders() error {
@@ -97,6 +141,7 @@ func (sm *Shaman) AddChunk(name, source string) error {
 	if err != nil {
 		return err
 	}
+	sm.csources[name] = source
 	return nil
 }
 
@@ -109,17 +154,28 @@ func (sm *Shaman) AddShader(name, source string) error {
 		return err
 	}
 	// Parses this shader template source
-	_, err = tmpl.Parse(source)
+	tmpl, err = tmpl.New(name).Parse(source)
 	if err != nil {
 		return err
 	}
 
 	sm.shaders[name] = tmpl
+	sm.ssources[name] = source
 	return nil
 }
 
+// AddProgram registers the program with the specified name generated from
+// the specified vertex and fragment shaders templates. If the name was
+// already registered, its compiled programs are disposed.
 func (sm *Shaman) AddProgram(name, vertexName, fragName string) error {
 
+	if _, ok := sm.proginfo[name]; ok {
+		for key, ps := range sm.programs {
+			if ps.specs.Name == name {
+				sm.dispose(key, ps)
+			}
+		}
+	}
 	sm.proginfo[name] = shader.ProgramInfo{vertexName, fragName}
 	return nil
 }
@@ -134,36 +190,177 @@ func (sm *Shaman) SetProgram(specs *ShaderSpecs) (bool, error) {
 	}
 
 	// Search for compiled program with the specified specs
-	for _, pinfo := range sm.programs {
-		if pinfo.specs.Compare(specs) {
-			sm.gs.UseProgram(pinfo.program)
-			sm.specs = *specs
-			return true, nil
+	// or generates a new one
+	ps, err := sm.program(specs)
+	if err != nil {
+		return false, err
+	}
+
+	// Save specs as current specs and actives program
+	sm.specs = specs.clone()
+	sm.key = ps.key
+	sm.useCount++
+	ps.used = sm.useCount
+	sm.gs.UseProgram(ps.program)
+	sm.stats.Switches++
+	sm.evict()
+	return true, nil
+}
+
+// Warmup compiles the programs for the specified specs which are not yet
+// compiled without activating them, so they are available without delay
+// when first used, as when the number of lights in the scene changes.
+func (sm *Shaman) Warmup(specs []ShaderSpecs) error {
+
+	for i := 0; i < len(specs); i++ {
+		_, err := sm.program(&specs[i])
+		if err != nil {
+			return err
 		}
 	}
+	sm.evict()
+	return nil
+}
+
+// SetMaxPrograms sets the maximum number of compiled programs kept by this
+// shader manager. When exceeded the least recently activated programs are
+// disposed. The default value 0 keeps all the programs.
+func (sm *Shaman) SetMaxPrograms(max int) {
+
+	sm.maxProgs = max
+	sm.evict()
+}
+
+// MaxPrograms returns the current maximum number of compiled programs
+func (sm *Shaman) MaxPrograms() int {
+
+	return sm.maxProgs
+}
+
+// ProgramCount returns the current number of compiled programs
+func (sm *Shaman) ProgramCount() int {
+
+	return len(sm.programs)
+}
+
+// DisposeProgram disposes the compiled program for the specified specs
+// if found and returns if it was found.
+func (sm *Shaman) DisposeProgram(specs *ShaderSpecs) bool {
+
+	key := specs.key()
+	ps, ok := sm.programs[key]
+	if !ok {
+		return false
+	}
+	sm.dispose(key, ps)
+	return true
+}
+
+// DisposePrograms disposes all the compiled programs
+func (sm *Shaman) DisposePrograms() {
+
+	for key, ps := range sm.programs {
+		sm.dispose(key, ps)
+	}
+}
+
+// program returns the compiled program for the specified specs,
+// generating and building it if not found.
+func (sm *Shaman) program(specs *ShaderSpecs) (*ProgSpecs, error) {
+
+	key := specs.key()
+	ps, ok := sm.programs[key]
+	if ok {
+		return ps, nil
+	}
 
 	// Generates new program with the specified specs
-	prog, err := sm.GenProgram(specs)
+	vertex, frag, err := sm.genSources(specs)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	prog, err := sm.buildProgram(vertex, frag)
+	if err != nil {
+		return nil, sm.sourceError(specs.Name, vertex, frag, err)
 	}
 	log.Debug("Created new shader:%v", specs.Name)
 
-	// Save specs as current specs, adds new program to the list
-	// and actives program
-	sm.specs = *specs
-	sm.programs = append(sm.programs, ProgSpecs{prog, *specs})
-	sm.gs.UseProgram(prog)
-	return true, nil
+	ps = &ProgSpecs{program: prog, specs: specs.clone(), key: key, vertex: vertex, frag: frag}
+	sm.programs[key] = ps
+	sm.stats.Compiles++
+	return ps, nil
+}
+
+// dispose removes the specified program from the cache and releases it
+func (sm *Shaman) dispose(key string, ps *ProgSpecs) {
+
+	// The next SetProgram must activate a program
+	if key == sm.key {
+		sm.specs = ShaderSpecs{}
+		sm.key = ""
+	}
+	ps.program.Dispose()
+	delete(sm.programs, key)
+}
+
+// evict disposes the least recently activated programs other than the
+// current one while the number of programs exceeds the maximum
+func (sm *Shaman) evict() {
+
+	if sm.maxProgs <= 0 {
+		return
+	}
+	for len(sm.programs) > sm.maxProgs {
+		var oldKey string
+		var old *ProgSpecs
+		for key, ps := range sm.programs {
+			if key == sm.key {
+				continue
+			}
+			if old == nil || ps.used < old.used {
+				oldKey = key
+				old = ps
+			}
+		}
+		if old == nil {
+			return
+		}
+		sm.dispose(oldKey, old)
+		sm.stats.Evicted++
+	}
+}
+
+// Stats returns the activity counters of this shader manager
+// since the last call to ResetStats
+func (sm *Shaman) Stats() ShamanStats {
+
+	return sm.stats
+}
+
+// ResetStats clears the activity counters of this shader manager
+func (sm *Shaman) ResetStats() {
+
+	sm.stats = ShamanStats{}
 }
 
 // Generates shader program from the specified specs
 func (sm *Shaman) GenProgram(specs *ShaderSpecs) (*gls.Program, error) {
 
+	vertex, frag, err := sm.genSources(specs)
+	if err != nil {
+		return nil, err
+	}
+	return sm.buildProgram(vertex, frag)
+}
+
+// genSources generates the vertex and fragment shaders sources
+// of the program with the specified specs from their templates
+func (sm *Shaman) genSources(specs *ShaderSpecs) (string, string, error) {
+
 	// Get info for the specified shader program
 	progInfo, ok := sm.proginfo[specs.Name]
 	if !ok {
-		return nil, fmt.Errorf("Program:%s not found", specs.Name)
+		return "", "", fmt.Errorf("Program:%s not found", specs.Name)
 	}
 
 	// Sets the GLSL version string
@@ -172,47 +369,143 @@ func (sm *Shaman) GenProgram(specs *ShaderSpecs) (*gls.Program, error) {
 	// Get vertex shader compiled template
 	vtempl, ok := sm.shaders[progInfo.Vertex]
 	if !ok {
-		return nil, fmt.Errorf("Shader:%s template not found", progInfo.Vertex)
+		return "", "", fmt.Errorf("Shader:%s template not found", progInfo.Vertex)
 	}
 	// Generates vertex shader source from template
 	var sourceVertex bytes.Buffer
 	err := vtempl.Execute(&sourceVertex, specs)
 	if err != nil {
-		return nil, err
+		return "", "", sm.templateError(err)
 	}
 
 	// Get fragment shader compiled template
 	fragTempl, ok := sm.shaders[progInfo.Frag]
 	if !ok {
-		return nil, fmt.Errorf("Shader:%s template not found", progInfo.Frag)
+		return "", "", fmt.Errorf("Shader:%s template not found", progInfo.Frag)
 	}
 	// Generates fragment shader source from template
 	var sourceFrag bytes.Buffer
 	err = fragTempl.Execute(&sourceFrag, specs)
 	if err != nil {
-		return nil, err
+		return "", "", sm.templateError(err)
 	}
 
-	// Creates shader program
+	vertex := insertDefines(sourceVertex.String(), specs.Defines)
+	frag := insertDefines(sourceFrag.String(), specs.Defines)
+	return vertex, frag, nil
+}
+
+// buildProgram creates and builds a program with the specified
+// vertex and fragment shaders sources
+func (sm *Shaman) buildProgram(vertex, frag string) (*gls.Program, error) {
+
 	prog := sm.gs.NewProgram()
-	prog.AddShader(gls.VERTEX_SHADER, sourceVertex.String(), nil)
-	prog.AddShader(gls.FRAGMENT_SHADER, sourceFrag.String(), nil)
-	err = prog.Build()
+	// The errors of shaders loaded from files refer to the files lines
+	if sm.dir != nil {
+		prog.ShowSource = false
+		vertex = stripMarkers(vertex)
+		frag = stripMarkers(frag)
+	}
+	prog.AddShader(gls.VERTEX_SHADER, vertex, nil)
+	prog.AddShader(gls.FRAGMENT_SHADER, frag, nil)
+	err := prog.Build()
 	if err != nil {
 		return nil, err
 	}
+	// Sets the binding points of the shared uniform blocks used by the program
+	prog.SetUniformBlockBinding("Camera", CameraBlockBinding)
+	prog.SetUniformBlockBinding("Lights", LightsBlockBinding)
+	prog.SetUniformBlockBinding("Fog", FogBlockBinding)
 	return prog, nil
 }
 
+// Compare returns if these specs and the specified specs generate the same
+// program. It is called for each rendered graphic, so it compares the fields
+// without allocating, and must compare the same fields as key.
 func (ss *ShaderSpecs) Compare(other *ShaderSpecs) bool {
 
 	if ss.Name == other.Name &&
-		ss.AmbientLightsMax == other.AmbientLightsMax &&
-		ss.DirLightsMax == other.DirLightsMax &&
-		ss.PointLightsMax == other.PointLightsMax &&
-		ss.SpotLightsMax == other.SpotLightsMax &&
-		ss.MatTexturesMax == other.MatTexturesMax {
+		ss.UseLights == other.UseLights &&
+		ss.MatTexturesMax == other.MatTexturesMax &&
+		ss.DirShadowsMax == other.DirShadowsMax &&
+		ss.SpotShadowsMax == other.SpotShadowsMax &&
+		ss.Instanced == other.Instanced &&
+		ss.BonesMax == other.BonesMax &&
+		ss.MorphTargetsMax == other.MorphTargetsMax &&
+		ss.GBuffer == other.GBuffer &&
+		ss.Defines.Equals(other.Defines) &&
+		ss.Extras.Equals(other.Extras) {
 		return true
 	}
 	return false
 }
+
+// key returns a string which identifies these specs, used to find the
+// compiled program with the same specs. It must use the same fields as Compare.
+func (ss *ShaderSpecs) key() string {
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s/%d/%d/%d/%d/%t/%d/%d/%t",
+		ss.Name, ss.UseLights, ss.MatTexturesMax, ss.DirShadowsMax, ss.SpotShadowsMax, ss.Instanced, ss.BonesMax, ss.MorphTargetsMax, ss.GBuffer)
+
+	// Appends the defines and the extras sorted by name
+	appendSorted(&buf, "/", ss.Defines)
+	appendSorted(&buf, "/.", ss.Extras)
+	return buf.String()
+}
+
+// appendSorted appends to the specified buffer the names and values
+// of the specified map sorted by name, each after the specified prefix
+func appendSorted(buf *bytes.Buffer, prefix string, values gls.ShaderDefines) {
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(buf, "%s%s=%s", prefix, name, values[name])
+	}
+}
+
+// clone returns a copy of these specs which does not share the
+// maps of defines and extras, as they may be changed by the material.
+func (ss *ShaderSpecs) clone() ShaderSpecs {
+
+	clone := *ss
+	clone.Defines = ss.Defines.Clone()
+	clone.Extras = ss.Extras.Clone()
+	return clone
+}
+
+// insertDefines inserts the specified preprocessor symbols
+// after the #version directive of the specified shader source.
+func insertDefines(source string, defines gls.ShaderDefines) string {
+
+	if len(defines) == 0 {
+		return source
+	}
+
+	// Sorts the symbols so equal specs always generate the same source
+	names := make([]string, 0, len(defines))
+	for name := range defines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "#define %s %s\n", name, defines[name])
+	}
+
+	// Finds the end of the #version line
+	pos := 0
+	vpos := strings.Index(source, "#version")
+	if vpos >= 0 {
+		eol := strings.Index(source[vpos:], "\n")
+		if eol < 0 {
+			return source + "\n" + buf.String()
+		}
+		pos = vpos + eol + 1
+	}
+	return source[:pos] + buf.String() + source[pos:]
+}
@@ -0,0 +1,40 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+// Stats contains the counters of the renderer activity
+// since the last call to ResetStats.
+// The counters of the OpenGL calls are kept by gls.GLS.FrameStats.
+type Stats struct {
+	Renders      int         // Number of scenes rendered
+	Graphics     int         // Number of renderable graphics found in the scenes
+	Culled       int         // Number of graphics not rendered as outside of the camera frustum
+	GraphicMat   int         // Number of graphic materials rendered including the screen space ones
+	ScreenSpace  int         // Number of screen space graphic materials rendered, such as GUI panels
+	Lights       int         // Number of lights found in the scenes
+	ShadowMaps   int         // Number of shadow maps rendered
+	Deferred     int         // Number of graphic materials rendered into the G-buffer
+	LightVolumes int         // Number of point light volumes rendered by the deferred path
+	Shaman       ShamanStats // Shader manager counters
+}
+
+// Stats returns the activity counters of this renderer
+// since the last call to ResetStats
+func (r *Renderer) Stats() Stats {
+
+	stats := r.stats
+	stats.Shaman = r.shaman.Stats()
+	return stats
+}
+
+// ResetStats clears the activity counters of this renderer, its shader
+// manager and the OpenGL calls counters of its GLS.
+// It is normally called at the beginning of each frame.
+func (r *Renderer) ResetStats() {
+
+	r.stats = Stats{}
+	r.shaman.ResetStats()
+	r.gs.ResetFrameStats()
+}
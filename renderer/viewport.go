@@ -0,0 +1,226 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"github.com/g3n/engine/camera"
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/math32"
+)
+
+// Viewport is a rectangle of the window or of the render target
+// where a scene is rendered with a camera.
+// Several viewports may be rendered in the same frame by
+// Renderer.RenderViewports for split screen or picture in picture views.
+type Viewport struct {
+	x          int32          // Left of the rectangle in pixels
+	y          int32          // Bottom of the rectangle in pixels
+	width      int32          // Width of the rectangle in pixels
+	height     int32          // Height of the rectangle in pixels
+	scene      core.INode     // Scene rendered in this viewport
+	cam        camera.ICamera // Camera used to render the scene
+	enabled    bool           // Enabled flag
+	scissor    bool           // Discard fragments outside of the rectangle flag
+	clearColor bool           // Clear the color buffer before rendering flag
+	clearDepth bool           // Clear the depth buffer before rendering flag
+	color      math32.Color4  // Color used to clear the rectangle
+	autoAspect bool           // Update the aspect of perspective cameras flag
+}
+
+// NewViewport creates and returns a pointer to a new viewport with the specified
+// rectangle in pixels from the lower left corner, scene and camera.
+// By default the rectangle depth buffer is cleared before rendering
+// and fragments outside of the rectangle are discarded.
+func NewViewport(x, y, width, height int, scene core.INode, cam camera.ICamera) *Viewport {
+
+	vp := new(Viewport)
+	vp.SetRect(x, y, width, height)
+	vp.scene = scene
+	vp.cam = cam
+	vp.enabled = true
+	vp.scissor = true
+	vp.clearColor = false
+	vp.clearDepth = true
+	vp.color.Set(0, 0, 0, 1)
+	vp.autoAspect = true
+	return vp
+}
+
+// SetRect sets the rectangle of this viewport in pixels from the
+// lower left corner of the window or render target
+func (vp *Viewport) SetRect(x, y, width, height int) {
+
+	vp.x = int32(x)
+	vp.y = int32(y)
+	vp.width = int32(width)
+	vp.height = int32(height)
+}
+
+// Rect returns the current rectangle of this viewport
+func (vp *Viewport) Rect() (x, y, width, height int) {
+
+	return int(vp.x), int(vp.y), int(vp.width), int(vp.height)
+}
+
+// SetScene sets the scene rendered in this viewport
+func (vp *Viewport) SetScene(scene core.INode) {
+
+	vp.scene = scene
+}
+
+// Scene returns the scene rendered in this viewport
+func (vp *Viewport) Scene() core.INode {
+
+	return vp.scene
+}
+
+// SetCamera sets the camera used to render the scene of this viewport
+func (vp *Viewport) SetCamera(cam camera.ICamera) {
+
+	vp.cam = cam
+}
+
+// Camera returns the camera used to render the scene of this viewport
+func (vp *Viewport) Camera() camera.ICamera {
+
+	return vp.cam
+}
+
+// SetEnabled sets if this viewport is rendered (default = true)
+func (vp *Viewport) SetEnabled(state bool) {
+
+	vp.enabled = state
+}
+
+// Enabled returns the current enabled state of this viewport
+func (vp *Viewport) Enabled() bool {
+
+	return vp.enabled
+}
+
+// SetScissor sets if fragments outside of the rectangle of this viewport
+// are discarded when rendering its scene (default = true).
+// The rectangle is always scissored when it is cleared.
+func (vp *Viewport) SetScissor(state bool) {
+
+	vp.scissor = state
+}
+
+// Scissor returns the current scissor state of this viewport
+func (vp *Viewport) Scissor() bool {
+
+	return vp.scissor
+}
+
+// SetClear sets if the color and depth buffers in the rectangle of this
+// viewport are cleared before rendering its scene.
+// The defaults are false for the color and true for the depth.
+func (vp *Viewport) SetClear(color, depth bool) {
+
+	vp.clearColor = color
+	vp.clearDepth = depth
+}
+
+// Clear returns if the color and depth buffers are
+// cleared before rendering the scene of this viewport
+func (vp *Viewport) Clear() (color, depth bool) {
+
+	return vp.clearColor, vp.clearDepth
+}
+
+// SetClearColor sets the color used to clear the rectangle of this viewport
+func (vp *Viewport) SetClearColor(color *math32.Color4) {
+
+	vp.color = *color
+}
+
+// ClearColor returns the color used to clear the rectangle of this viewport
+func (vp *Viewport) ClearColor() math32.Color4 {
+
+	return vp.color
+}
+
+// SetAutoAspect sets if the aspect ratio of perspective cameras is set to
+// the aspect ratio of this viewport before rendering (default = true).
+func (vp *Viewport) SetAutoAspect(state bool) {
+
+	vp.autoAspect = state
+}
+
+// AutoAspect returns the current auto aspect state of this viewport
+func (vp *Viewport) AutoAspect() bool {
+
+	return vp.autoAspect
+}
+
+// setup sets the OpenGL viewport and scissor rectangle to the rectangle of this
+// viewport, clears it if requested and updates the camera aspect ratio.
+func (vp *Viewport) setup(gs *gls.GLS) {
+
+	gs.Viewport(vp.x, vp.y, vp.width, vp.height)
+
+	// Clears only the rectangle of this viewport
+	if vp.scissor || vp.clearColor || vp.clearDepth {
+		gs.Enable(gls.SCISSOR_TEST)
+		gs.Scissor(vp.x, vp.y, vp.width, vp.height)
+	}
+	if vp.clearColor {
+		gs.ClearBufferfv(gls.COLOR, 0, []float32{vp.color.R, vp.color.G, vp.color.B, vp.color.A})
+	}
+	if vp.clearDepth {
+		gs.DepthMask(true)
+		gs.ClearBufferfv(gls.DEPTH, 0, []float32{1})
+	}
+	if !vp.scissor {
+		gs.Disable(gls.SCISSOR_TEST)
+	}
+
+	if vp.autoAspect && vp.height > 0 {
+		if persp, ok := vp.cam.(*camera.Perspective); ok {
+			persp.SetAspect(float32(vp.width) / float32(vp.height))
+		}
+	}
+}
+
+// RenderViewports renders the scenes of the specified enabled viewports in
+// order into their rectangles of the current render target or of the default
+// framebuffer. The current viewport and scissor test state are restored after
+// rendering. The render target, if set, is cleared once before the viewports.
+func (r *Renderer) RenderViewports(viewports ...*Viewport) error {
+
+	// Saves the current viewport and scissor test state
+	vx, vy, vwidth, vheight := r.gs.GetViewport()
+	scissor := r.gs.IsEnabled(gls.SCISSOR_TEST)
+	defer func() {
+		if r.target != nil {
+			r.gs.BindFramebuffer(gls.FRAMEBUFFER, 0)
+		}
+		r.gs.Viewport(vx, vy, vwidth, vheight)
+		if scissor {
+			r.gs.Enable(gls.SCISSOR_TEST)
+		} else {
+			r.gs.Disable(gls.SCISSOR_TEST)
+		}
+	}()
+
+	if r.target != nil {
+		err := r.target.Bind(r.gs)
+		if err != nil {
+			return err
+		}
+	}
+	for _, vp := range viewports {
+		if !vp.enabled || vp.scene == nil || vp.cam == nil {
+			continue
+		}
+		vp.setup(r.gs)
+		err := r.renderScene(vp.scene, vp.cam)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
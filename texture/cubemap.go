@@ -0,0 +1,167 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// NewTextureCubeFromCross creates and returns a pointer to a new TextureCube
+// using the specified image file with the six faces in a cross layout.
+// See NewTextureCubeFromCrossRGBA for the supported layouts.
+func NewTextureCubeFromCross(file string) (*TextureCube, error) {
+
+	rgba, err := DecodeImage(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewTextureCubeFromCrossRGBA(rgba)
+}
+
+// NewTextureCubeFromCrossRGBA creates and returns a pointer to a new
+// TextureCube using the specified image with the six faces in a cross layout.
+// The horizontal cross is 4 faces wide and 3 faces high with the -X, +Z, +X
+// and -Z faces in the middle row and the +Y and -Y faces above and below +Z.
+// The vertical cross is 3 faces wide and 4 faces high with the +Y, +Z, -Y
+// and -Z faces in the middle column, the -Z face upside down, and the -X
+// and +X faces at the left and right of +Z.
+func NewTextureCubeFromCrossRGBA(rgba *image.RGBA) (*TextureCube, error) {
+
+	size := rgba.Rect.Size()
+	// Column and row of each face in the cross
+	var cells [6][2]int
+	var face int
+	rotated := false
+	if size.X*3 == size.Y*4 {
+		face = size.X / 4
+		cells = [6][2]int{{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {3, 1}}
+	} else if size.X*4 == size.Y*3 {
+		face = size.X / 3
+		cells = [6][2]int{{2, 1}, {0, 1}, {1, 0}, {1, 2}, {1, 1}, {1, 3}}
+		rotated = true
+	} else {
+		return nil, fmt.Errorf("cube map cross image must have 4:3 or 3:4 aspect ratio")
+	}
+
+	var faces [6]*image.RGBA
+	for i, cell := range cells {
+		dst := image.NewRGBA(image.Rect(0, 0, face, face))
+		x0 := rgba.Rect.Min.X + cell[0]*face
+		y0 := rgba.Rect.Min.Y + cell[1]*face
+		// The -Z face of the vertical cross is rotated 180 degrees
+		flip := rotated && i == FaceNegZ
+		for y := 0; y < face; y++ {
+			for x := 0; x < face; x++ {
+				sx, sy := x, y
+				if flip {
+					sx, sy = face-1-x, face-1-y
+				}
+				copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], rgba.Pix[rgba.PixOffset(x0+sx, y0+sy):])
+			}
+		}
+		faces[i] = dst
+	}
+	return NewTextureCubeFromRGBA(faces)
+}
+
+// NewTextureCubeFromEquirect creates and returns a pointer to a new
+// TextureCube with faces of the specified size in pixels projected from
+// the specified image file with an equirectangular panorama.
+// See NewTextureCubeFromEquirectRGBA for the panorama orientation.
+func NewTextureCubeFromEquirect(file string, size int) (*TextureCube, error) {
+
+	rgba, err := DecodeImage(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewTextureCubeFromEquirectRGBA(rgba, size)
+}
+
+// NewTextureCubeFromEquirectRGBA creates and returns a pointer to a new
+// TextureCube with faces of the specified size in pixels projected from the
+// specified equirectangular panorama image, whose width is the longitude
+// and height the latitude. The center of the image is the -Z direction
+// and its top row the +Y direction.
+func NewTextureCubeFromEquirectRGBA(rgba *image.RGBA, size int) (*TextureCube, error) {
+
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid cube map face size")
+	}
+	bounds := rgba.Rect.Size()
+	if bounds.X == 0 || bounds.Y == 0 {
+		return nil, fmt.Errorf("empty equirectangular image")
+	}
+
+	var faces [6]*image.RGBA
+	for i := range faces {
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		for y := 0; y < size; y++ {
+			tc := 2*(float64(y)+0.5)/float64(size) - 1
+			for x := 0; x < size; x++ {
+				sc := 2*(float64(x)+0.5)/float64(size) - 1
+				dx, dy, dz := cubeFaceDirection(i, sc, tc)
+				// Longitude and latitude of the direction as image coordinates
+				u := 0.5 + math.Atan2(dx, -dz)/(2*math.Pi)
+				v := math.Acos(dy/math.Sqrt(dx*dx+dy*dy+dz*dz)) / math.Pi
+				sampleBilinear(rgba, u*float64(bounds.X)-0.5, v*float64(bounds.Y)-0.5, dst.Pix[dst.PixOffset(x, y):])
+			}
+		}
+		faces[i] = dst
+	}
+	return NewTextureCubeFromRGBA(faces)
+}
+
+// cubeFaceDirection returns the direction sampled by the texel of the
+// specified cube map face at the specified coordinates from -1 to 1,
+// with the T coordinate increasing downwards as the image rows.
+func cubeFaceDirection(face int, sc, tc float64) (float64, float64, float64) {
+
+	switch face {
+	case FacePosX:
+		return 1, -tc, -sc
+	case FaceNegX:
+		return -1, -tc, sc
+	case FacePosY:
+		return sc, 1, tc
+	case FaceNegY:
+		return sc, -1, -tc
+	case FacePosZ:
+		return sc, -tc, 1
+	default:
+		return -sc, -tc, -1
+	}
+}
+
+// sampleBilinear sets the specified RGBA pixel to the bilinear interpolation
+// of the texels around the specified position of the image, which wraps
+// horizontally and is clamped vertically.
+func sampleBilinear(rgba *image.RGBA, fx, fy float64, pix []uint8) {
+
+	size := rgba.Rect.Size()
+	x0 := int(math.Floor(fx))
+	y0 := int(math.Floor(fy))
+	wx := fx - float64(x0)
+	wy := fy - float64(y0)
+	texel := func(x, y int) []uint8 {
+		x = ((x % size.X) + size.X) % size.X
+		if y < 0 {
+			y = 0
+		} else if y >= size.Y {
+			y = size.Y - 1
+		}
+		return rgba.Pix[rgba.PixOffset(rgba.Rect.Min.X+x, rgba.Rect.Min.Y+y):]
+	}
+	t00 := texel(x0, y0)
+	t10 := texel(x0+1, y0)
+	t01 := texel(x0, y0+1)
+	t11 := texel(x0+1, y0+1)
+	for c := 0; c < 4; c++ {
+		top := float64(t00[c])*(1-wx) + float64(t10[c])*wx
+		bottom := float64(t01[c])*(1-wx) + float64(t11[c])*wx
+		pix[c] = uint8(top*(1-wy) + bottom*wy + 0.5)
+	}
+}
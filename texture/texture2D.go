@@ -32,6 +32,7 @@ type Texture2D struct {
 	updateParams bool          // texture parameters needs to be sent
 	genMipmap    bool          // generate mipmaps flag
 	data         interface{}   // array with texture data
+	named        bool          // uniforms are not arrays indexed by texture unit
 	uTexture     gls.Uniform1i // Texture unit uniform
 	uFlipY       gls.Uniform1i // Flip Y coordinate flag uniform
 	uVisible     gls.Uniform1i // Texture visible uniform
@@ -53,11 +54,7 @@ func newTexture2D() *Texture2D {
 	t.updateParams = true
 	t.genMipmap = true
 
-	t.uTexture.Init("MatTexture")
-	t.uFlipY.Init("MatTexFlipY")
-	t.uVisible.Init("MatTexVisible")
-	t.uOffset.Init("MatTexOffset")
-	t.uRepeat.Init("MatTexRepeat")
+	t.initUniforms("MatTexture", "MatTexFlipY", "MatTexVisible", "MatTexOffset", "MatTexRepeat")
 
 	t.uRepeat.Set(1, 1)
 	t.uOffset.Set(0, 0)
@@ -124,6 +121,27 @@ func (t *Texture2D) Dispose() {
 	}
 }
 
+// initUniforms sets the names of the uniforms of this texture
+func (t *Texture2D) initUniforms(texture, flipY, visible, offset, repeat string) {
+
+	t.uTexture.Init(texture)
+	t.uFlipY.Init(flipY)
+	t.uVisible.Init(visible)
+	t.uOffset.Init(offset)
+	t.uRepeat.Init(repeat)
+}
+
+// SetUniformNames sets the prefix of the names of the uniforms of this
+// texture, which are then not arrays indexed by texture unit.
+// The uniforms names are: prefix, prefix+"FlipY", prefix+"Visible",
+// prefix+"Offset" and prefix+"Repeat".
+// It is used by materials which bind each texture to a specific sampler.
+func (t *Texture2D) SetUniformNames(prefix string) {
+
+	t.initUniforms(prefix, prefix+"FlipY", prefix+"Visible", prefix+"Offset", prefix+"Repeat")
+	t.named = true
+}
+
 // SetImage sets a new image for this texture
 func (t *Texture2D) SetImage(imgfile string) error {
 
@@ -237,6 +255,13 @@ func (t *Texture2D) Offset() (float32, float32) {
 	return t.uOffset.Get()
 }
 
+// SetGenMipmap sets if mipmaps are generated when the texture data
+// is transferred to OpenGL (default = true)
+func (t *Texture2D) SetGenMipmap(state bool) {
+
+	t.genMipmap = state
+}
+
 // SetFlipY set the state for flipping the Y coordinate
 func (t *Texture2D) SetFlipY(state bool) {
 
@@ -285,8 +310,16 @@ func DecodeImage(imgfile string) (*image.RGBA, error) {
 	return rgba, nil
 }
 
-// Called by material render setup
-func (t *Texture2D) RenderSetup(gs *gls.GLS, idx int) {
+// TexName returns the OpenGL handle of this texture or
+// zero if the texture was not yet created by Bind or RenderSetup.
+func (t *Texture2D) TexName() uint32 {
+
+	return t.texname
+}
+
+// Bind creates the OpenGL texture if necessary, transfer its data and
+// parameters if they were changed and binds it to the specified texture unit.
+func (t *Texture2D) Bind(gs *gls.GLS, unit int) {
 
 	// One time initialization
 	if t.gs == nil {
@@ -297,7 +330,7 @@ func (t *Texture2D) RenderSetup(gs *gls.GLS, idx int) {
 	// Transfer texture data to OpenGL if necessary
 	if t.updateData {
 		// Sets the texture unit for this texture
-		gs.ActiveTexture(uint32(gls.TEXTURE0 + idx))
+		gs.ActiveTexture(uint32(gls.TEXTURE0 + unit))
 		gs.BindTexture(gls.TEXTURE_2D, t.texname)
 		gs.TexImage2D(
 			gls.TEXTURE_2D, // texture type
@@ -319,7 +352,7 @@ func (t *Texture2D) RenderSetup(gs *gls.GLS, idx int) {
 	}
 
 	// Sets the texture unit for this texture
-	gs.ActiveTexture(uint32(gls.TEXTURE0 + idx))
+	gs.ActiveTexture(uint32(gls.TEXTURE0 + unit))
 	gs.BindTexture(gls.TEXTURE_2D, t.texname)
 
 	// Sets texture parameters if needed
@@ -330,9 +363,23 @@ func (t *Texture2D) RenderSetup(gs *gls.GLS, idx int) {
 		gs.TexParameteri(gls.TEXTURE_2D, gls.TEXTURE_WRAP_T, int32(t.wrapT))
 		t.updateParams = false
 	}
+}
+
+// Called by material render setup
+func (t *Texture2D) RenderSetup(gs *gls.GLS, idx int) {
+
+	t.Bind(gs, idx)
 
 	// Transfer uniforms
 	t.uTexture.Set(int32(idx))
+	if t.named {
+		t.uTexture.Transfer(gs)
+		t.uFlipY.Transfer(gs)
+		t.uVisible.Transfer(gs)
+		t.uOffset.Transfer(gs)
+		t.uRepeat.Transfer(gs)
+		return
+	}
 	t.uTexture.TransferIdx(gs, idx)
 	t.uFlipY.TransferIdx(gs, idx)
 	t.uVisible.TransferIdx(gs, idx)
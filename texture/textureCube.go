@@ -0,0 +1,236 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texture
+
+import (
+	"fmt"
+	"github.com/g3n/engine/gls"
+	"image"
+)
+
+// Cube map faces in the order used to create cube textures
+const (
+	FacePosX = 0
+	FaceNegX = 1
+	FacePosY = 2
+	FaceNegY = 3
+	FacePosZ = 4
+	FaceNegZ = 5
+)
+
+// TextureCube is a texture with six square faces which is sampled
+// by a direction vector, as used by environment maps.
+type TextureCube struct {
+	gs           *gls.GLS       // Pointer to OpenGL state
+	refcount     int            // Current number of references
+	texname      uint32         // Texture handle
+	magFilter    uint32         // magnification filter
+	minFilter    uint32         // minification filter
+	size         int32          // faces width and height in pixels
+	format       uint32         // format of the pixel data
+	formatType   uint32         // type of the pixel data
+	iformat      int32          // internal format
+	updateData   bool           // texture data needs to be sent
+	updateParams bool           // texture parameters needs to be sent
+	genMipmap    bool           // generate mipmaps flag
+	data         [6]interface{} // arrays with the faces data
+	uTexture     gls.Uniform1i  // Texture unit uniform
+}
+
+// NewTextureCubeFromImages creates and returns a pointer to a new TextureCube
+// using the specified image files as the data of the faces in the order:
+// +X, -X, +Y, -Y, +Z, -Z. All the images must be square and of the same size.
+// Supported image formats are: PNG, JPEG and GIF.
+func NewTextureCubeFromImages(files [6]string) (*TextureCube, error) {
+
+	var faces [6]*image.RGBA
+	for i, file := range files {
+		rgba, err := DecodeImage(file)
+		if err != nil {
+			return nil, err
+		}
+		faces[i] = rgba
+	}
+	return NewTextureCubeFromRGBA(faces)
+}
+
+// NewTextureCubeFromRGBA creates and returns a pointer to a new TextureCube
+// using the specified images as the data of the faces in the order:
+// +X, -X, +Y, -Y, +Z, -Z. All the images must be square and of the same size.
+func NewTextureCubeFromRGBA(faces [6]*image.RGBA) (*TextureCube, error) {
+
+	t := newTextureCube()
+	err := t.SetFromRGBA(faces)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func newTextureCube() *TextureCube {
+
+	t := new(TextureCube)
+	t.refcount = 1
+	t.magFilter = gls.LINEAR
+	t.minFilter = gls.LINEAR_MIPMAP_LINEAR
+	t.updateParams = true
+	t.genMipmap = true
+	t.uTexture.Init("MatEnvMap")
+	return t
+}
+
+// Incref increments the reference count for this texture
+// and returns a pointer to the texture.
+// It should be used when this texture is shared by another material.
+func (t *TextureCube) Incref() *TextureCube {
+
+	t.refcount++
+	return t
+}
+
+// Dispose decrements this texture reference count and
+// if necessary releases OpenGL resources associated with this texture.
+func (t *TextureCube) Dispose() {
+
+	if t.refcount > 1 {
+		t.refcount--
+		return
+	}
+	if t.gs != nil {
+		t.gs.DeleteTextures(t.texname)
+		t.gs = nil
+	}
+}
+
+// SetFromRGBA sets the data of the faces from the specified images
+// in the order: +X, -X, +Y, -Y, +Z, -Z
+func (t *TextureCube) SetFromRGBA(faces [6]*image.RGBA) error {
+
+	size := faces[0].Rect.Size()
+	if size.X != size.Y {
+		return fmt.Errorf("cube map faces must be square")
+	}
+	var data [6]interface{}
+	for i, rgba := range faces {
+		if rgba.Rect.Size() != size {
+			return fmt.Errorf("cube map faces must have the same size")
+		}
+		data[i] = rgba.Pix
+	}
+	t.SetData(size.X, gls.RGBA, gls.UNSIGNED_BYTE, gls.RGBA8, data)
+	return nil
+}
+
+// SetData sets the size in pixels of the faces and their data
+// in the order: +X, -X, +Y, -Y, +Z, -Z
+func (t *TextureCube) SetData(size int, format int, formatType, iformat int, data [6]interface{}) {
+
+	t.size = int32(size)
+	t.format = uint32(format)
+	t.formatType = uint32(formatType)
+	t.iformat = int32(iformat)
+	t.data = data
+	t.updateData = true
+}
+
+// SetUniformName sets the name of the sampler uniform of this texture.
+// The default name is "MatEnvMap".
+func (t *TextureCube) SetUniformName(name string) {
+
+	t.uTexture.Init(name)
+}
+
+// SetMagFilter sets the filter to be applied when the texture element
+// covers more than on pixel. The default value is gls.LINEAR.
+func (t *TextureCube) SetMagFilter(magFilter uint32) {
+
+	t.magFilter = magFilter
+	t.updateParams = true
+}
+
+// SetMinFilter sets the filter to be applied when the texture element
+// covers less than on pixel. The default value is gls.LINEAR_MIPMAP_LINEAR.
+func (t *TextureCube) SetMinFilter(minFilter uint32) {
+
+	t.minFilter = minFilter
+	t.updateParams = true
+}
+
+// SetGenMipmap sets if mipmaps are generated when the texture data
+// is transferred to OpenGL (default = true)
+func (t *TextureCube) SetGenMipmap(state bool) {
+
+	t.genMipmap = state
+}
+
+// Size returns the width and height of the faces in pixels
+func (t *TextureCube) Size() int {
+
+	return int(t.size)
+}
+
+// TexName returns the OpenGL handle of this texture or
+// zero if the texture was not yet created by Bind or RenderSetup.
+func (t *TextureCube) TexName() uint32 {
+
+	return t.texname
+}
+
+// Bind creates the OpenGL texture if necessary, transfer its data and
+// parameters if they were changed and binds it to the specified texture unit.
+func (t *TextureCube) Bind(gs *gls.GLS, unit int) {
+
+	// One time initialization
+	if t.gs == nil {
+		t.texname = gs.GenTexture()
+		t.gs = gs
+		gs.Enable(gls.TEXTURE_CUBE_MAP_SEAMLESS)
+	}
+
+	// Sets the texture unit for this texture
+	gs.ActiveTexture(uint32(gls.TEXTURE0 + unit))
+	gs.BindTexture(gls.TEXTURE_CUBE_MAP, t.texname)
+
+	// Transfer the faces data to OpenGL if necessary
+	if t.updateData {
+		for face, data := range t.data {
+			gs.TexImage2D(
+				uint32(gls.TEXTURE_CUBE_MAP_POSITIVE_X+face), // face target
+				0,            // level of detail
+				t.iformat,    // internal format
+				t.size,       // width in texels
+				t.size,       // height in texels
+				0,            // border must be 0
+				t.format,     // format of supplied texture data
+				t.formatType, // type of external format color component
+				data,         // image data
+			)
+		}
+		// Generates mipmaps if requested
+		if t.genMipmap {
+			gs.GenerateMipmap(gls.TEXTURE_CUBE_MAP)
+		}
+		t.updateData = false
+	}
+
+	// Sets texture parameters if needed
+	if t.updateParams {
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_MAG_FILTER, int32(t.magFilter))
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_MIN_FILTER, int32(t.minFilter))
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_WRAP_S, gls.CLAMP_TO_EDGE)
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_WRAP_T, gls.CLAMP_TO_EDGE)
+		gs.TexParameteri(gls.TEXTURE_CUBE_MAP, gls.TEXTURE_WRAP_R, gls.CLAMP_TO_EDGE)
+		t.updateParams = false
+	}
+}
+
+// RenderSetup is called by materials to bind this texture to the
+// specified texture unit and transfer its sampler uniform
+func (t *TextureCube) RenderSetup(gs *gls.GLS, unit int) {
+
+	t.Bind(gs, unit)
+	t.uTexture.Set(int32(unit))
+	t.uTexture.Transfer(gs)
+}
@@ -0,0 +1,287 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build egl
+// +build egl
+
+package window
+
+/*
+#cgo pkg-config: egl
+#include <stdlib.h>
+#include <string.h>
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+
+// headlessDisplay returns the EGL display of the Mesa surfaceless
+// platform, which does not need a display server, if supported,
+// or else the default EGL display.
+static EGLDisplay headlessDisplay() {
+
+	const char* exts = eglQueryString(EGL_NO_DISPLAY, EGL_EXTENSIONS);
+	if (exts != NULL && strstr(exts, "EGL_MESA_platform_surfaceless") != NULL) {
+		PFNEGLGETPLATFORMDISPLAYEXTPROC getPlatformDisplay =
+			(PFNEGLGETPLATFORMDISPLAYEXTPROC)eglGetProcAddress("eglGetPlatformDisplayEXT");
+		if (getPlatformDisplay != NULL) {
+			EGLDisplay dpy = getPlatformDisplay(EGL_PLATFORM_SURFACELESS_MESA, EGL_DEFAULT_DISPLAY, NULL);
+			if (dpy != EGL_NO_DISPLAY) {
+				return dpy;
+			}
+		}
+	}
+	return eglGetDisplay(EGL_DEFAULT_DISPLAY);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/g3n/engine/core"
+	"github.com/g3n/engine/gls"
+)
+
+// Headless is a window without display which renders into an offscreen
+// EGL pbuffer surface. It allows rendering in servers and in continuous
+// integration containers without a display server, using the GPU drivers
+// or the Mesa software rasterizer. It does not generate input events.
+// The application and the go-gl/gl package must be built with the
+// "egl" build tag, so the OpenGL functions are loaded through EGL.
+type Headless struct {
+	core.Dispatcher
+	display     C.EGLDisplay // EGL display connection
+	config      C.EGLConfig  // EGL frame buffer configuration
+	context     C.EGLContext // OpenGL context
+	surface     C.EGLSurface // Pbuffer surface
+	width       int          // Surface width in pixels
+	height      int          // Surface height in pixels
+	xpos        int          // Position reported by GetPos
+	ypos        int          // Position reported by GetPos
+	title       string       // Title reported by Title
+	shouldClose bool         // Close requested flag
+	start       time.Time    // Creation time
+	sizeEv      SizeEvent
+}
+
+// newHeadless creates and returns a headless window with an OpenGL 3.3
+// core profile context with the specified size, which is made current.
+func newHeadless(width, height int, title string) (*Headless, error) {
+
+	w := new(Headless)
+	w.Dispatcher.Initialize()
+	w.title = title
+	w.start = time.Now()
+
+	w.display = C.headlessDisplay()
+	if w.display == C.EGLDisplay(C.EGL_NO_DISPLAY) {
+		return nil, fmt.Errorf("EGL display not available")
+	}
+	if C.eglInitialize(w.display, nil, nil) == C.EGL_FALSE {
+		return nil, eglError("eglInitialize")
+	}
+	if C.eglBindAPI(C.EGL_OPENGL_API) == C.EGL_FALSE {
+		w.Destroy()
+		return nil, eglError("eglBindAPI")
+	}
+
+	configAttribs := []C.EGLint{
+		C.EGL_SURFACE_TYPE, C.EGL_PBUFFER_BIT,
+		C.EGL_RENDERABLE_TYPE, C.EGL_OPENGL_BIT,
+		C.EGL_RED_SIZE, 8,
+		C.EGL_GREEN_SIZE, 8,
+		C.EGL_BLUE_SIZE, 8,
+		C.EGL_ALPHA_SIZE, 8,
+		C.EGL_DEPTH_SIZE, 24,
+		C.EGL_STENCIL_SIZE, 8,
+		C.EGL_NONE,
+	}
+	var count C.EGLint
+	if C.eglChooseConfig(w.display, &configAttribs[0], &w.config, 1, &count) == C.EGL_FALSE || count == 0 {
+		w.Destroy()
+		return nil, fmt.Errorf("EGL pbuffer configuration not available")
+	}
+
+	contextAttribs := []C.EGLint{
+		C.EGL_CONTEXT_MAJOR_VERSION, 3,
+		C.EGL_CONTEXT_MINOR_VERSION, 3,
+		C.EGL_CONTEXT_OPENGL_PROFILE_MASK, C.EGL_CONTEXT_OPENGL_CORE_PROFILE_BIT,
+		C.EGL_NONE,
+	}
+	w.context = C.eglCreateContext(w.display, w.config, C.EGLContext(C.EGL_NO_CONTEXT), &contextAttribs[0])
+	if w.context == C.EGLContext(C.EGL_NO_CONTEXT) {
+		w.Destroy()
+		return nil, eglError("eglCreateContext")
+	}
+	err := w.createSurface(width, height)
+	if err != nil {
+		w.Destroy()
+		return nil, err
+	}
+	return w, nil
+}
+
+// createSurface creates the pbuffer surface with the specified
+// size, replacing the current one, and makes the context current.
+func (w *Headless) createSurface(width, height int) error {
+
+	if w.surface != C.EGLSurface(C.EGL_NO_SURFACE) {
+		C.eglMakeCurrent(w.display, C.EGLSurface(C.EGL_NO_SURFACE), C.EGLSurface(C.EGL_NO_SURFACE), C.EGLContext(C.EGL_NO_CONTEXT))
+		C.eglDestroySurface(w.display, w.surface)
+		w.surface = C.EGLSurface(C.EGL_NO_SURFACE)
+	}
+	surfaceAttribs := []C.EGLint{
+		C.EGL_WIDTH, C.EGLint(width),
+		C.EGL_HEIGHT, C.EGLint(height),
+		C.EGL_NONE,
+	}
+	w.surface = C.eglCreatePbufferSurface(w.display, w.config, &surfaceAttribs[0])
+	if w.surface == C.EGLSurface(C.EGL_NO_SURFACE) {
+		return eglError("eglCreatePbufferSurface")
+	}
+	w.width = width
+	w.height = height
+	if C.eglMakeCurrent(w.display, w.surface, w.surface, w.context) == C.EGL_FALSE {
+		return eglError("eglMakeCurrent")
+	}
+	return nil
+}
+
+// eglError returns an error with the name of the specified
+// EGL function and the code of the last EGL error
+func eglError(fname string) error {
+
+	return fmt.Errorf("%s failed: EGL error 0x%X", fname, int(C.eglGetError()))
+}
+
+// ReadImage reads the pixels of the window surface as rendered
+// after the last call to Renderer.Render and returns them as a new
+// RGBA image, which may be compared with golden images in tests.
+func (w *Headless) ReadImage(gs *gls.GLS) *image.RGBA {
+
+	gs.BindFramebuffer(gls.READ_FRAMEBUFFER, 0)
+	img := gs.ReadImage(0, 0, int32(w.width), int32(w.height))
+	gs.BindFramebuffer(gls.READ_FRAMEBUFFER, gs.Framebuffer())
+	return img
+}
+
+// GetScreenResolution returns the size of the window surface
+func (w *Headless) GetScreenResolution(p interface{}) (width, height int) {
+
+	return w.width, w.height
+}
+
+// SwapInterval does nothing as there is no display to synchronize with
+func (w *Headless) SwapInterval(interval int) {
+
+}
+
+// MakeContextCurrent makes the OpenGL context of this window current
+func (w *Headless) MakeContextCurrent() {
+
+	C.eglMakeCurrent(w.display, w.surface, w.surface, w.context)
+}
+
+// GetSize returns the size of the window surface in pixels
+func (w *Headless) GetSize() (width int, height int) {
+
+	return w.width, w.height
+}
+
+// SetSize recreates the window surface with the specified size,
+// which discards its contents, and dispatches OnWindowSize.
+func (w *Headless) SetSize(width int, height int) {
+
+	if width == w.width && height == w.height {
+		return
+	}
+	err := w.createSurface(width, height)
+	if err != nil {
+		log.Error("%v", err)
+		return
+	}
+	w.sizeEv.W = w
+	w.sizeEv.Width = width
+	w.sizeEv.Height = height
+	w.Dispatch(OnWindowSize, &w.sizeEv)
+}
+
+// GetPos returns the last position set by SetPos
+func (w *Headless) GetPos() (xpos, ypos int) {
+
+	return w.xpos, w.ypos
+}
+
+// SetPos sets the position returned by GetPos
+func (w *Headless) SetPos(xpos, ypos int) {
+
+	w.xpos = xpos
+	w.ypos = ypos
+}
+
+// SetTitle sets the title returned by Title
+func (w *Headless) SetTitle(title string) {
+
+	w.title = title
+}
+
+// Title returns the current title of this window
+func (w *Headless) Title() string {
+
+	return w.title
+}
+
+// SetStandardCursor does nothing as there is no cursor
+func (w *Headless) SetStandardCursor(cursor StandardCursor) {
+
+}
+
+// SwapBuffers finishes rendering the current frame
+func (w *Headless) SwapBuffers() {
+
+	C.eglSwapBuffers(w.display, w.surface)
+}
+
+// ShouldClose returns the current state of the close flag
+func (w *Headless) ShouldClose() bool {
+
+	return w.shouldClose
+}
+
+// SetShouldClose sets the state of the close flag
+func (w *Headless) SetShouldClose(v bool) {
+
+	w.shouldClose = v
+}
+
+// Destroy releases the OpenGL context and the surface of this window
+func (w *Headless) Destroy() {
+
+	if w.display == C.EGLDisplay(C.EGL_NO_DISPLAY) {
+		return
+	}
+	C.eglMakeCurrent(w.display, C.EGLSurface(C.EGL_NO_SURFACE), C.EGLSurface(C.EGL_NO_SURFACE), C.EGLContext(C.EGL_NO_CONTEXT))
+	if w.surface != C.EGLSurface(C.EGL_NO_SURFACE) {
+		C.eglDestroySurface(w.display, w.surface)
+	}
+	if w.context != C.EGLContext(C.EGL_NO_CONTEXT) {
+		C.eglDestroyContext(w.display, w.context)
+	}
+	C.eglTerminate(w.display)
+	w.display = C.EGLDisplay(C.EGL_NO_DISPLAY)
+	w.surface = C.EGLSurface(C.EGL_NO_SURFACE)
+	w.context = C.EGLContext(C.EGL_NO_CONTEXT)
+}
+
+// PollEvents does nothing as there are no input events
+func (w *Headless) PollEvents() {
+
+}
+
+// GetTime returns the number of seconds since this window was created
+func (w *Headless) GetTime() float64 {
+
+	return time.Since(w.start).Seconds()
+}
@@ -0,0 +1,19 @@
+// Copyright 2016 The G3N Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !egl
+// +build !egl
+
+package window
+
+import (
+	"fmt"
+)
+
+// newHeadless returns an error as the headless window
+// requires building with the "egl" build tag
+func newHeadless(width, height int, title string) (IWindow, error) {
+
+	return nil, fmt.Errorf("Headless window requires the egl build tag")
+}
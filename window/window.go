@@ -4,7 +4,7 @@
 
 /*
  Package window abstracts the OpenGL Window manager
- Currently only "glfw" is supported
+ Currently "glfw" and "headless" are supported
 */
 package window
 
@@ -309,11 +309,16 @@ type ScrollEvent struct {
 // New creates and returns a new window of the specified type, width, height and title.
 // If full is true, the window will be opened in full screen and the width and height
 // parameters will be ignored.
-// Currently only "glfw" type is supported.
+// The "glfw" type opens a window in the desktop. The "headless" type creates
+// an offscreen window without display server (see Headless), which requires
+// the "egl" build tag; the full parameter is ignored.
 func New(wtype string, width, height int, title string, full bool) (IWindow, error) {
 
-	if wtype != "glfw" {
-		panic("Unsupported window type")
+	switch wtype {
+	case "glfw":
+		return newGLFW(width, height, title, full)
+	case "headless":
+		return newHeadless(width, height, title)
 	}
-	return newGLFW(width, height, title, full)
+	panic("Unsupported window type")
 }